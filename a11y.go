@@ -0,0 +1,91 @@
+package tmplx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Accessibility is an OutputCheck reporting basic accessibility problems:
+// images without alt text, form controls without a label and ids used more
+// than once, typically by blocks from different files. It is no substitute
+// for a full checker, which can be plugged in as another OutputCheck.
+var Accessibility OutputCheck = OutputCheckFunc(checkAccessibility)
+
+// unlabelledInputs are input types that need no label
+var unlabelledInputs = map[string]bool{"hidden": true, "submit": true, "reset": true, "button": true, "image": true}
+
+type formControl struct {
+	tag  string
+	id   string
+	line int
+}
+
+func checkAccessibility(html string) []OutputProblem {
+	var problems []OutputProblem
+	report := func(line int, format string, args ...interface{}) {
+		problems = append(problems, OutputProblem{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	ids := make(map[string]int)
+	labelled := make(map[string]bool)
+	var controls []formControl
+	inLabel := 0
+
+	scanTags(html, func(t htmlToken) {
+		line, name, attrs := t.line, t.name, t.attrs
+		if name == "label" {
+			if t.end {
+				if inLabel > 0 {
+					inLabel--
+				}
+				return
+			}
+			inLabel++
+			if target := attrValue(attrs, "for"); target != "" {
+				labelled[target] = true
+			}
+		}
+		if t.end {
+			return
+		}
+
+		if id := attrValue(attrs, "id"); id != "" {
+			if first, ok := ids[id]; ok {
+				report(line, "duplicate id %q, first used on line %d", id, first)
+			} else {
+				ids[id] = line
+			}
+		}
+
+		switch name {
+		case "img":
+			if !hasAttribute(attrs, "alt") && attrValue(attrs, "role") != "presentation" {
+				report(line, "<img> without alt text")
+			}
+		case "input", "select", "textarea":
+			if name == "input" && unlabelledInputs[strings.ToLower(attrValue(attrs, "type"))] {
+				return
+			}
+			if inLabel > 0 || attrValue(attrs, "aria-label") != "" || attrValue(attrs, "aria-labelledby") != "" || attrValue(attrs, "title") != "" {
+				return
+			}
+			controls = append(controls, formControl{tag: name, id: attrValue(attrs, "id"), line: line})
+		}
+	})
+
+	for _, c := range controls {
+		if c.id == "" || !labelled[c.id] {
+			report(c.line, "<%s> without a label", c.tag)
+		}
+	}
+	return problems
+}
+
+func hasAttribute(attrs []htmlAttribute, name string) bool {
+	for _, a := range attrs {
+		if a.name == name {
+			return true
+		}
+	}
+	return false
+}
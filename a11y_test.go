@@ -0,0 +1,32 @@
+package tmplx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAccessibility(t *testing.T) {
+	html := `<img src="logo.png" alt="Logo"><img src="spacer.gif" alt="">
+<img src="chart.png">
+<label>Name <input name="name"></label>
+<label for="email">Email</label><input id="email" type="email">
+<input name="q" aria-label="Search"><input type="hidden" name="token"><input type="submit">
+<select name="sort"></select>
+<!-- <img src="commented.png"> -->
+<div id="main"></div>
+<textarea id="main"></textarea>`
+
+	var got []string
+	for _, p := range Accessibility.Check(html) {
+		got = append(got, p.String())
+	}
+	expected := []string{
+		"line 2: <img> without alt text",
+		`line 9: duplicate id "main", first used on line 8`,
+		"line 6: <select> without a label",
+		"line 9: <textarea> without a label",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
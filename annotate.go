@@ -0,0 +1,393 @@
+package tmplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"time"
+)
+
+// markFunc is the template func that emits boundary marks around templates,
+// blocks and includes when the engine is instrumented.
+const markFunc = "tmplxMark"
+
+// markPattern matches a boundary mark in any of the forms html/template may
+// have escaped it to, depending on the context the boundary sits in.
+var markPattern = regexp.MustCompile(`(?i)(?:<|&lt;|\\u003c|%3c|\\3c ?)!--tmplx(?::|%3a|\\3a ?)(begin|end)(?::|%3a|\\3a ?)(\d+)--(?:>|&gt;|\\u003e|%3e|\\3e ?)`)
+
+// boundary is a region of output produced by a single template, block or include
+type boundary struct {
+	kind   string // "template", "block" or "include"
+	name   string
+	source string // file the content was defined in
+}
+
+// String is the text annotated into dev mode comments
+func (b boundary) String() string {
+	if b.kind != "block" {
+		return b.name
+	}
+	if b.source == "" {
+		return fmt.Sprintf("block %q", b.name)
+	}
+	return fmt.Sprintf("block %q (%s)", b.name, b.source)
+}
+
+// setupAnnotations registers the mark func. Boundaries are only marked for
+// the HTML backend; text output has no comment syntax to put them in.
+func (e *TemplateEngine) setupAnnotations() {
+	e.instrument = (e.devMode || e.trace) && e.backend == HTMLBackend
+	if !e.instrument {
+		return
+	}
+	e.registerRenderFunc(markFunc, func(st *renderState) any {
+		return func(kind string, id int, dot interface{}) template.HTML {
+			if st.calls != nil {
+				if b, ok := e.mark(id); ok {
+					st.calls.record(kind, b, dot)
+				}
+			}
+			return template.HTML("<!--tmplx:" + kind + ":" + strconv.Itoa(id) + "-->")
+		}
+	})
+}
+
+// mark returns the boundary with the given id
+func (e *TemplateEngine) mark(id int) (boundary, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if id >= len(e.marks) {
+		return boundary{}, false
+	}
+	return e.marks[id], true
+}
+
+// newMark allocates an id for a boundary
+func (e *TemplateEngine) newMark(b boundary) int {
+	e.resolveMu.Lock()
+	defer e.resolveMu.Unlock()
+	e.marks = append(e.marks, b)
+	return len(e.marks) - 1
+}
+
+func markAction(kind string, id int) string {
+	return fmt.Sprintf("{{%s %q %d .}}", markFunc, kind, id)
+}
+
+// annotateInclude wraps processed include content in boundary marks
+func (e *TemplateEngine) annotateInclude(path, content string) string {
+	if !e.instrument {
+		return content
+	}
+	id := e.newMark(boundary{kind: "include", name: path, source: path})
+	return markAction("begin", id) + content + markAction("end", id)
+}
+
+// annotated returns a copy of a resolved template whose root and blocks are
+// wrapped in boundary marks. Marks are only kept where they land in HTML
+// text; see textMarks.
+func (e *TemplateEngine) annotated(name string, tmpl *template.Template) (*template.Template, error) {
+	annotated, err := e.rebuild(name, tmpl, func(t *template.Template, tree *parse.Tree) error {
+		b := boundary{kind: "block", name: t.Name(), source: tree.ParseName}
+		if t.Name() == tmpl.Name() {
+			b = boundary{kind: "template", name: name, source: name}
+		}
+		return e.wrapTree(tree, e.newMark(b))
+	})
+	if err != nil {
+		return nil, err
+	}
+	keep, err := textMarks(annotated)
+	if err != nil {
+		// the template doesn't escape; rendering it reports why
+		return annotated, nil
+	}
+	for _, t := range annotated.Templates() {
+		if t.Tree != nil {
+			dropMarks(t.Tree.Root, keep)
+		}
+	}
+	return annotated, nil
+}
+
+// errEscaped stops the execution textMarks uses to escape a template
+var errEscaped = errors.New("escaped")
+
+type escapeProbe struct{}
+
+func (escapeProbe) Write([]byte) (int, error) { return 0, errEscaped }
+
+// textMarks returns the ids of the marks html/template escapes as HTML text.
+// Marks anywhere else, such as in a URL, attribute, script or style, would
+// be escaped into the output and corrupt it. html/template only escapes on
+// execution, so a copy is executed into a writer that fails on the first
+// write; the root starts with a mark, so no other func runs before it.
+func textMarks(tmpl *template.Template) (map[int]bool, error) {
+	probe, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if err := probe.Execute(escapeProbe{}, nil); !errors.Is(err, errEscaped) {
+		return nil, fmt.Errorf("escaping %s: %v", tmpl.Name(), err)
+	}
+
+	// Templates called outside HTML text are escaped through copies that
+	// aren't listed, so everything they contain or call counts as unsafe
+	var queue []string
+	for _, t := range probe.Templates() {
+		if t.Tree != nil {
+			walkCalls(t.Tree.Root, func(call *parse.TemplateNode) {
+				if i := strings.Index(call.Name, "$htmltemplate_"); i >= 0 {
+					queue = append(queue, call.Name[:i])
+				}
+			})
+		}
+	}
+	derived := make(map[string]bool)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		t := probe.Lookup(name)
+		if derived[name] || t == nil || t.Tree == nil {
+			continue
+		}
+		derived[name] = true
+		walkCalls(t.Tree.Root, func(call *parse.TemplateNode) {
+			queue = append(queue, call.Name)
+		})
+	}
+
+	keep := make(map[int]bool)
+	unsafe := make(map[int]bool)
+	for _, t := range probe.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		walkActions(t.Tree.Root, func(action *parse.ActionNode) {
+			id, ok := markID(action)
+			if !ok {
+				return
+			}
+			cmds := action.Pipe.Cmds
+			switch {
+			case derived[t.Name()]:
+				unsafe[id] = true
+			case len(cmds) == 1:
+				// not reached from the root, so never escaped or executed
+			case len(cmds) == 2 && isIdent(cmds[1], "_html_template_htmlescaper"):
+				keep[id] = true
+			default:
+				unsafe[id] = true
+			}
+		})
+	}
+	for id := range unsafe {
+		delete(keep, id)
+	}
+	return keep, nil
+}
+
+// walkCalls calls fn for every template call under node
+func walkCalls(node parse.Node, fn func(*parse.TemplateNode)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			walkCalls(c, fn)
+		}
+	case *parse.TemplateNode:
+		fn(n)
+	case *parse.IfNode:
+		walkCalls(n.List, fn)
+		walkCalls(n.ElseList, fn)
+	case *parse.RangeNode:
+		walkCalls(n.List, fn)
+		walkCalls(n.ElseList, fn)
+	case *parse.WithNode:
+		walkCalls(n.List, fn)
+		walkCalls(n.ElseList, fn)
+	}
+}
+
+// dropMarks removes the mark actions whose ids aren't in keep
+func dropMarks(node parse.Node, keep map[int]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		nodes := n.Nodes[:0]
+		for _, c := range n.Nodes {
+			if action, ok := c.(*parse.ActionNode); ok {
+				if id, ok := markID(action); ok && !keep[id] {
+					continue
+				}
+			}
+			dropMarks(c, keep)
+			nodes = append(nodes, c)
+		}
+		n.Nodes = nodes
+	case *parse.IfNode:
+		dropMarks(n.List, keep)
+		dropMarks(n.ElseList, keep)
+	case *parse.RangeNode:
+		dropMarks(n.List, keep)
+		dropMarks(n.ElseList, keep)
+	case *parse.WithNode:
+		dropMarks(n.List, keep)
+		dropMarks(n.ElseList, keep)
+	}
+}
+
+// markID returns the id of a mark action
+func markID(action *parse.ActionNode) (int, bool) {
+	if !isMarkAction(action) {
+		return 0, false
+	}
+	args := action.Pipe.Cmds[0].Args
+	if len(args) < 3 {
+		return 0, false
+	}
+	num, ok := args[2].(*parse.NumberNode)
+	if !ok || !num.IsInt {
+		return 0, false
+	}
+	return int(num.Int64), true
+}
+
+func isIdent(cmd *parse.CommandNode, name string) bool {
+	if len(cmd.Args) != 1 {
+		return false
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	return ok && ident.Ident == name
+}
+
+func (e *TemplateEngine) wrapTree(tree *parse.Tree, id int) error {
+	marks, err := template.New("").Funcs(e.funcMap).Parse(markAction("begin", id) + markAction("end", id))
+	if err != nil {
+		return err
+	}
+	begin, end := marks.Tree.Root.Nodes[0], marks.Tree.Root.Nodes[1]
+
+	nodes := make([]parse.Node, 0, len(tree.Root.Nodes)+2)
+	nodes = append(nodes, begin)
+	nodes = append(nodes, tree.Root.Nodes...)
+	tree.Root.Nodes = append(nodes, end)
+	return nil
+}
+
+// isMarkAction reports whether action was inserted by the engine to mark a boundary
+func isMarkAction(action *parse.ActionNode) bool {
+	if len(action.Pipe.Cmds) == 0 || len(action.Pipe.Cmds[0].Args) == 0 {
+		return false
+	}
+	ident, ok := action.Pipe.Cmds[0].Args[0].(*parse.IdentifierNode)
+	return ok && ident.Ident == markFunc
+}
+
+// markWriter rewrites boundary marks in rendered output. Marks emitted in
+// HTML text become comments naming the boundary when annot is set; marks that
+// html/template escaped for another context (attributes, scripts, styles) are
+// always dropped. When trace is set it records the output lines between
+// each pair of marks.
+//
+// Each mark is written by a single action, so a mark never spans writes.
+type markWriter struct {
+	w     io.Writer
+	e     *TemplateEngine
+	annot bool
+	trace *RenderTrace
+
+	line    int
+	lastNL  bool
+	open    []int
+	started []time.Time
+}
+
+func newMarkWriter(w io.Writer, e *TemplateEngine, name string, trace *RenderTrace) *markWriter {
+	return &markWriter{w: w, e: e, annot: e.devMode && !isSVG(name), trace: trace, line: 1}
+}
+
+func (mw *markWriter) Write(p []byte) (int, error) {
+	s := string(p)
+	matches := markPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		mw.advance(s)
+		return mw.w.Write(p)
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		mw.advance(s[last:m[0]])
+		out.WriteString(s[last:m[0]])
+		last = m[1]
+
+		id, _ := strconv.Atoi(s[m[4]:m[5]])
+		b, ok := mw.e.mark(id)
+		if !ok {
+			continue
+		}
+		kind := s[m[2]:m[3]]
+		mw.record(kind, b)
+
+		if mw.annot && strings.HasPrefix(s[m[0]:m[1]], "<!--") {
+			// "--" would end the comment early
+			out.WriteString("<!-- " + kind + " " + strings.ReplaceAll(b.String(), "--", "- -") + " -->")
+		}
+	}
+	mw.advance(s[last:])
+	out.WriteString(s[last:])
+
+	if _, err := io.WriteString(mw.w, out.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// advance tracks the output line the next write starts on
+func (mw *markWriter) advance(s string) {
+	if s == "" {
+		return
+	}
+	mw.line += strings.Count(s, "\n")
+	mw.lastNL = s[len(s)-1] == '\n'
+}
+
+func (mw *markWriter) record(kind string, b boundary) {
+	if mw.trace == nil {
+		return
+	}
+	if kind == "begin" {
+		mw.trace.Spans = append(mw.trace.Spans, TraceSpan{
+			Kind:      b.kind,
+			Name:      b.name,
+			Source:    b.source,
+			StartLine: mw.line,
+			Depth:     len(mw.open),
+		})
+		mw.open = append(mw.open, len(mw.trace.Spans)-1)
+		mw.started = append(mw.started, time.Now())
+		return
+	}
+	if len(mw.open) == 0 {
+		return
+	}
+	span := &mw.trace.Spans[mw.open[len(mw.open)-1]]
+	span.Duration = time.Since(mw.started[len(mw.started)-1])
+	mw.open = mw.open[:len(mw.open)-1]
+	mw.started = mw.started[:len(mw.started)-1]
+	span.EndLine = mw.line
+	if mw.lastNL && span.EndLine > span.StartLine {
+		// output ending in a newline doesn't reach into the next line
+		span.EndLine--
+	}
+}
@@ -0,0 +1,82 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDevModeAnnotations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{
+			Data: []byte(`<html><head><title>{{block "title" .}}Site{{end}}</title></head>
+<body>{{include "partials/nav.html" .}}<div class="{{block "class" .}}main{{end}}">{{block "content" .}}{{end}}</div></body></html>`),
+		},
+		"partials/nav.html": &fstest.MapFile{
+			Data: []byte(`<nav>{{.Name}}</nav>`),
+		},
+		"pages/home.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/base.html"}}
+{{define "title"}}Home{{end}}
+{{define "content"}}<p>Hello</p>{{end}}`),
+		},
+	}
+
+	engine := New(Options{FS: fsys, DevMode: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("pages/home.html", map[string]string{"Name": "nav"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	containsAll(t, []string{
+		`<!-- begin pages/home.html --><html>`,
+		`<!-- begin partials/nav.html --><nav>nav</nav><!-- end partials/nav.html -->`,
+		`<!-- begin block "content" (pages/home.html) --><p>Hello</p><!-- end block "content" (pages/home.html) -->`,
+		`<title>Home</title>`,
+		`<div class="main">`,
+	}, result)
+
+	if strings.Contains(result, "tmplx") {
+		t.Errorf("Expected no raw marks in output, got: %s", result)
+	}
+
+	plain := New(Options{FS: fsys})
+	if err := plain.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err = plain.Render("pages/home.html", map[string]string{"Name": "nav"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result, "<!--") {
+		t.Errorf("Expected no annotations outside dev mode, got: %s", result)
+	}
+}
+
+func TestAnnotationContexts(t *testing.T) {
+	engine := New(Options{DevMode: true, Loader: MapLoader{
+		"page.html": `{{define "url"}}/x{{end}}{{define "js"}}{"a": 1}{{end}}` +
+			`<a href="{{template "url" .}}">{{template "url" .}}</a>` +
+			`<script>var u = {{template "js" .}}; var s = "{{template "url" .}}";</script>` +
+			`<p style="color: {{block "color" .}}red{{end}}">x</p>`,
+	}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("page.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{
+		`<a href="/x">/x</a>`,
+		`<script>var u = {"a": 1}; var s = "/x";</script>`,
+		`<p style="color: red">`,
+	}, result)
+	if strings.Contains(result, "ZgotmplZ") {
+		t.Errorf("Expected marks to be left out of URLs, got: %s", result)
+	}
+}
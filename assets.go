@@ -0,0 +1,280 @@
+package tmplx
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/template/parse"
+)
+
+// Assets configures the asset func, which maps the name of a static asset
+// to its URL: {{asset "app.js"}}, the assetSRI func, which adds its
+// Subresource Integrity hash, and the img func for responsive images.
+type Assets struct {
+	// Prefix is prepended to asset paths, e.g. "/static/" or a CDN URL
+	Prefix string
+
+	// Manifest maps asset names to the paths a build wrote them to, e.g.
+	// "app.js" to "app.3f2a1c.js". Names not in it are used as they are.
+	Manifest map[string]string
+
+	// Integrity maps asset names to their integrity hashes, such as
+	// "sha384-...", usually from the build manifest too
+	Integrity map[string]string
+
+	// FS, if set, holds the built assets at their manifest paths. Hashes
+	// missing from Integrity are computed from it.
+	FS fs.FS
+
+	// ImageVariant names the variant of an image resized to a width, for
+	// the srcset written by the img func. Defaults to inserting the width
+	// before the extension: "hero.jpg" at 480 is "hero-480w.jpg".
+	ImageVariant func(name string, width int) string
+
+	computed sync.Map
+}
+
+// ParseManifest reads a JSON build manifest mapping asset names either to
+// paths or to objects with "file" and optionally "integrity" fields, as
+// written by Vite and similar tools:
+//
+//	{"app.js": "app.3f2a1c.js"}
+//	{"app.js": {"file": "app.3f2a1c.js", "integrity": "sha384-..."}}
+func ParseManifest(data []byte) (*Assets, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing asset manifest: %v", err)
+	}
+
+	a := &Assets{Manifest: make(map[string]string), Integrity: make(map[string]string)}
+	for name, value := range raw {
+		var file string
+		if err := json.Unmarshal(value, &file); err == nil {
+			a.Manifest[name] = file
+			continue
+		}
+		var entry struct {
+			File      string `json:"file"`
+			Integrity string `json:"integrity"`
+		}
+		if err := json.Unmarshal(value, &entry); err != nil || entry.File == "" {
+			return nil, fmt.Errorf("error parsing asset manifest entry %s", name)
+		}
+		a.Manifest[name] = entry.File
+		if entry.Integrity != "" {
+			a.Integrity[name] = entry.Integrity
+		}
+	}
+	return a, nil
+}
+
+// URL returns the URL of the named asset
+func (a *Assets) URL(name string) string {
+	p := name
+	if fingerprinted, ok := a.Manifest[name]; ok {
+		p = fingerprinted
+	}
+	if a.Prefix == "" {
+		return p
+	}
+	return strings.TrimSuffix(a.Prefix, "/") + "/" + strings.TrimPrefix(p, "/")
+}
+
+// SRIAsset is an asset with its integrity hash, as returned by assetSRI:
+//
+//	{{with assetSRI "app.js"}}<script src="{{.URL}}" {{.Attr}}></script>{{end}}
+type SRIAsset struct {
+	URL       string
+	Integrity string
+}
+
+// Attr returns the integrity and crossorigin attributes of the asset
+func (s SRIAsset) Attr() template.HTMLAttr {
+	return template.HTMLAttr(`integrity="` + template.HTMLEscapeString(s.Integrity) + `" crossorigin="anonymous"`)
+}
+
+// SRI returns the URL and integrity hash of the named asset. It fails if the
+// hash is neither in Integrity nor computable from FS, so pages never ship
+// an unprotected script by mistake.
+func (a *Assets) SRI(name string) (SRIAsset, error) {
+	integrity, ok := a.Integrity[name]
+	if !ok {
+		var err error
+		if integrity, err = a.computeIntegrity(name); err != nil {
+			return SRIAsset{}, err
+		}
+	}
+	return SRIAsset{URL: a.URL(name), Integrity: integrity}, nil
+}
+
+// computeIntegrity hashes an asset in FS, once
+func (a *Assets) computeIntegrity(name string) (string, error) {
+	if v, ok := a.computed.Load(name); ok {
+		return v.(string), nil
+	}
+	if a.FS == nil {
+		return "", fmt.Errorf("no integrity hash for asset %s", name)
+	}
+
+	p := name
+	if fingerprinted, ok := a.Manifest[name]; ok {
+		p = fingerprinted
+	}
+	content, err := fs.ReadFile(a.FS, strings.TrimPrefix(p, "/"))
+	if err != nil {
+		return "", fmt.Errorf("error hashing asset %s: %v", name, err)
+	}
+	sum := sha512.Sum384(content)
+	integrity := "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+	a.computed.Store(name, integrity)
+	return integrity, nil
+}
+
+// setupAssets registers the asset, assetSRI and img funcs
+func (e *TemplateEngine) setupAssets(opts Options) {
+	if opts.Assets == nil {
+		return
+	}
+	e.assets = opts.Assets
+	if _, userDefined := opts.FuncMap["asset"]; !userDefined {
+		e.funcMap["asset"] = e.assets.URL
+	}
+	if _, userDefined := opts.FuncMap["assetSRI"]; !userDefined {
+		e.funcMap["assetSRI"] = e.assets.SRI
+	}
+	if _, userDefined := opts.FuncMap["img"]; !userDefined {
+		e.funcMap["img"] = e.assets.Image
+	}
+}
+
+// Preload is an asset a page should have the browser fetch early
+type Preload struct {
+	URL string
+
+	// As is the kind of request: "script", "style", "font", "image" or
+	// "fetch"
+	As string
+}
+
+// Header returns the preload as a Link header value
+func (p Preload) Header() string {
+	h := "<" + p.URL + ">; rel=preload; as=" + p.As
+	if p.As == "font" {
+		h += "; crossorigin"
+	}
+	return h
+}
+
+// Tag returns the preload as a <link> element
+func (p Preload) Tag() template.HTML {
+	attrs := ""
+	if p.As == "font" {
+		attrs = " crossorigin"
+	}
+	return template.HTML(fmt.Sprintf(`<link rel="preload" href="%s" as="%s"%s>`,
+		template.HTMLEscapeString(p.URL), p.As, attrs))
+}
+
+// Preloads returns the assets the named template references with the asset
+// or assetSRI func and a constant name, found in the resolved template
+// including its layouts, blocks and includes. Assets chosen at render time
+// are not found.
+func (e *TemplateEngine) Preloads(name string) ([]Preload, error) {
+	if e.assets == nil {
+		return nil, fmt.Errorf("no assets configured")
+	}
+	tmpl, err := e.GetTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		WalkTree(t.Tree.Root, func(n parse.Node) {
+			switch n := n.(type) {
+			case *parse.ActionNode:
+				assetNames(n.Pipe, seen)
+			case *parse.PipeNode:
+				assetNames(n, seen)
+			}
+		})
+	}
+
+	names := make([]string, 0, len(seen))
+	for asset := range seen {
+		names = append(names, asset)
+	}
+	sort.Strings(names)
+
+	preloads := make([]Preload, 0, len(names))
+	for _, asset := range names {
+		preloads = append(preloads, Preload{URL: e.assets.URL(asset), As: preloadAs(asset)})
+	}
+	return preloads, nil
+}
+
+// PreloadHeaders returns the Link header values preloading the assets of the
+// named template:
+//
+//	for _, link := range links {
+//		w.Header().Add("Link", link)
+//	}
+func (e *TemplateEngine) PreloadHeaders(name string) ([]string, error) {
+	preloads, err := e.Preloads(name)
+	if err != nil {
+		return nil, err
+	}
+	headers := make([]string, len(preloads))
+	for i, p := range preloads {
+		headers[i] = p.Header()
+	}
+	return headers, nil
+}
+
+// assetNames adds the constant names passed to the asset funcs in pipe,
+// including nested pipelines, to seen
+func assetNames(pipe *parse.PipeNode, seen map[string]bool) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		if len(cmd.Args) >= 2 {
+			if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok && (ident.Ident == "asset" || ident.Ident == "assetSRI") {
+				if s, ok := cmd.Args[1].(*parse.StringNode); ok {
+					seen[s.Text] = true
+				}
+			}
+		}
+		for _, arg := range cmd.Args {
+			if p, ok := arg.(*parse.PipeNode); ok {
+				assetNames(p, seen)
+			}
+		}
+	}
+}
+
+// preloadAs returns the request kind of an asset from its extension
+func preloadAs(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".js", ".mjs":
+		return "script"
+	case ".css":
+		return "style"
+	case ".woff", ".woff2", ".ttf", ".otf":
+		return "font"
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg", ".ico":
+		return "image"
+	default:
+		return "fetch"
+	}
+}
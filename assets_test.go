@@ -0,0 +1,108 @@
+package tmplx
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAssets(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html":    `<head><link rel="stylesheet" href="{{asset "app.css"}}">{{block "head" .}}{{end}}</head>{{include "partials/footer.html" .}}`,
+			"partials/footer.html": `<script src="{{asset "app.js"}}"></script>`,
+			"page.html": `{{extend "layouts/base.html"}}{{define "head"}}{{if .}}<img src="{{printf "%s" (asset "logo.png")}}">{{end}}` +
+				`<link rel="preload" href="{{asset "fonts/inter.woff2"}}">{{asset .Dynamic}}{{end}}`,
+		},
+		Assets: &Assets{
+			Prefix:   "https://cdn.example.com/static/",
+			Manifest: map[string]string{"app.js": "app.3f2a1c.js"},
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("page.html", H{"Dynamic": "x.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{
+		`href="https://cdn.example.com/static/app.css"`,
+		`src="https://cdn.example.com/static/app.3f2a1c.js"`,
+		`https://cdn.example.com/static/x.txt`,
+	}, result)
+
+	headers, err := engine.PreloadHeaders("page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"<https://cdn.example.com/static/app.css>; rel=preload; as=style",
+		"<https://cdn.example.com/static/app.3f2a1c.js>; rel=preload; as=script",
+		"<https://cdn.example.com/static/fonts/inter.woff2>; rel=preload; as=font; crossorigin",
+		"<https://cdn.example.com/static/logo.png>; rel=preload; as=image",
+	}
+	if !reflect.DeepEqual(headers, expected) {
+		t.Errorf("Expected %v, got %v", expected, headers)
+	}
+
+	preloads, _ := engine.Preloads("page.html")
+	if tag := preloads[2].Tag(); tag != `<link rel="preload" href="https://cdn.example.com/static/fonts/inter.woff2" as="font" crossorigin>` {
+		t.Errorf("Unexpected preload tag %s", tag)
+	}
+
+	if _, err := engine.PreloadHeaders("missing.html"); err == nil {
+		t.Error("Expected error for missing template, got nil")
+	}
+}
+
+func TestAssetSRI(t *testing.T) {
+	assets, err := ParseManifest([]byte(`{
+		"app.js": {"file": "app.3f2a1c.js", "integrity": "sha384-abc"},
+		"app.css": "app.9d8e7f.css",
+		"vendor.js": {"file": "vendor.1b2c.js"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets.Prefix = "/static/"
+	assets.FS = fstest.MapFS{"vendor.1b2c.js": {Data: []byte("alert(1)")}}
+
+	engine := New(Options{
+		Loader: MapLoader{
+			"page.html": `{{with assetSRI "app.js"}}<script src="{{.URL}}" {{.Attr}}></script>{{end}}` +
+				`{{with assetSRI "vendor.js"}}<script src="{{.URL}}" {{.Attr}}></script>{{end}}`,
+			"unhashed.html": `{{with assetSRI "app.css"}}<link href="{{.URL}}" {{.Attr}}>{{end}}`,
+		},
+		Assets: assets,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("page.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha512.Sum384([]byte("alert(1)"))
+	containsAll(t, []string{
+		`<script src="/static/app.3f2a1c.js" integrity="sha384-abc" crossorigin="anonymous"></script>`,
+		`<script src="/static/vendor.1b2c.js" integrity="sha384-` + base64.StdEncoding.EncodeToString(sum[:]) + `" crossorigin="anonymous"></script>`,
+	}, result)
+
+	if _, err := engine.Render("unhashed.html", nil); err == nil {
+		t.Error("Expected error for asset without integrity hash, got nil")
+	}
+
+	headers, _ := engine.PreloadHeaders("page.html")
+	if len(headers) != 2 {
+		t.Errorf("Expected preloads for assetSRI references, got %v", headers)
+	}
+
+	if _, err := ParseManifest([]byte(`{"app.js": 1}`)); err == nil {
+		t.Error("Expected error for invalid manifest entry, got nil")
+	}
+}
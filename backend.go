@@ -0,0 +1,187 @@
+package tmplx
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+// Backend selects the template package used to execute resolved templates.
+// Loading, inheritance, blocks and includes work the same for every backend.
+type Backend int
+
+const (
+	// HTMLBackend executes templates with html/template, escaping output
+	// according to its HTML context. This is the default.
+	HTMLBackend Backend = iota
+
+	// TextBackend executes templates with text/template and writes output
+	// verbatim, for producing config files, SQL, plain-text emails and the like.
+	TextBackend
+)
+
+func (b Backend) String() string {
+	switch b {
+	case HTMLBackend:
+		return "html"
+	case TextBackend:
+		return "text"
+	default:
+		return fmt.Sprintf("Backend(%d)", int(b))
+	}
+}
+
+// executor is the subset of html/template and text/template used for rendering
+type executor interface {
+	Name() string
+	Execute(w io.Writer, data any) error
+	ExecuteTemplate(w io.Writer, name string, data any) error
+}
+
+// defaultExtensions lists the file extensions loaded when Options.Extensions is empty
+var defaultExtensions = []string{".html"}
+
+// extContentTypes are the content types of template extensions that aren't
+// HTML pages, whatever Options.ContentType says
+var extContentTypes = map[string]string{
+	".svg": "image/svg+xml",
+}
+
+// contentType returns the content type of responses rendering name
+func (e *TemplateEngine) contentType(name string) string {
+	if ctype, ok := extContentTypes[path.Ext(name)]; ok {
+		return ctype
+	}
+	return e.ctype
+}
+
+func (e *TemplateEngine) isTemplateFile(path string) bool {
+	for _, ext := range e.exts {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// store caches a resolved template under name, preparing the executor for
+// the configured backend.
+func (e *TemplateEngine) store(name string, tmpl *template.Template) error {
+	// html/template escapes a template in place when it first runs, and the
+	// resolved template stays in loadCache for the templates extending it,
+	// so the engine renders a copy
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		return fmt.Errorf("error copying %s: %v", name, err)
+	}
+	if len(e.transforms) > 0 {
+		transformed, err := e.transformed(name, tmpl)
+		if err != nil {
+			return fmt.Errorf("error transforming %s: %v", name, err)
+		}
+		tmpl = transformed
+	}
+
+	digest := digestTemplate(tmpl)
+	contextual := e.usesStore(tmpl)
+	if e.instrument {
+		annotated, err := e.annotated(name, tmpl)
+		if err != nil {
+			return fmt.Errorf("error annotating %s: %v", name, err)
+		}
+		tmpl = annotated
+	}
+	var text *texttemplate.Template
+	if e.backend == TextBackend {
+		text, err = toTextTemplate(tmpl, e.funcMap)
+		if err != nil {
+			return fmt.Errorf("error converting %s to text template: %v", name, err)
+		}
+	}
+
+	e.resolveMu.Lock()
+	defer e.resolveMu.Unlock()
+	e.digests[name] = digest
+	if contextual {
+		e.contextual.Store(true)
+	}
+	if _, replaced := e.cache[name]; replaced {
+		e.cacheEvicted(name, CacheTemplates)
+	}
+	e.cache[name] = tmpl
+	e.cacheLoaded(name, CacheTemplates)
+	if text != nil {
+		e.text[name] = text
+	}
+	return e.prepareBase(name)
+}
+
+// rebuild returns a copy of a resolved template with fn applied to a copy of
+// each of its trees. The trees are copied because resolved templates share
+// block trees with the templates they extend.
+func (e *TemplateEngine) rebuild(name string, tmpl *template.Template, fn func(t *template.Template, tree *parse.Tree) error) (*template.Template, error) {
+	cp := template.New(name).Funcs(e.funcMap)
+	root := cp
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+
+		tree := t.Tree.Copy()
+		if err := fn(t, tree); err != nil {
+			return nil, err
+		}
+
+		added, err := cp.AddParseTree(t.Name(), tree)
+		if err != nil {
+			return nil, err
+		}
+		if t.Name() == tmpl.Name() {
+			// AddParseTree hands back a new template for the root name
+			root = added
+		}
+	}
+	return root, nil
+}
+
+// executor returns the executor of a loaded template
+func (e *TemplateEngine) executor(name string) (executor, bool) {
+	if e.lazy {
+		if err := e.loadLazily(name); err != nil {
+			e.logf(LogLoad, LogError, "[TMPLX] %v", err)
+		}
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.lookup(name)
+}
+
+// lookup is executor for callers holding e.mu
+func (e *TemplateEngine) lookup(name string) (executor, bool) {
+	if e.backend == TextBackend {
+		tmpl, ok := e.text[name]
+		return tmpl, ok
+	}
+	tmpl, ok := e.cache[name]
+	return tmpl, ok
+}
+
+// toTextTemplate rebuilds a resolved html/template as a text/template sharing
+// copies of the same parse trees. It must run before tmpl is executed, since
+// html/template rewrites its trees when escaping.
+func toTextTemplate(tmpl *template.Template, funcMap template.FuncMap) (*texttemplate.Template, error) {
+	text := texttemplate.New(tmpl.Name()).Funcs(texttemplate.FuncMap(funcMap))
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		if _, err := text.AddParseTree(t.Name(), t.Tree.Copy()); err != nil {
+			return nil, err
+		}
+	}
+	return text, nil
+}
@@ -0,0 +1,63 @@
+package tmplx
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestTextBackend(t *testing.T) {
+	fsys := fstest.MapFS{
+		"emails/base.txt": &fstest.MapFile{
+			Data: []byte(`Hello {{.Name}},
+{{block "body" .}}{{end}}
+-- The Team`),
+		},
+		"emails/welcome.txt": &fstest.MapFile{
+			Data: []byte(`{{extend "emails/base.txt"}}
+{{define "body"}}Welcome to <{{.Site}}> & enjoy!{{end}}`),
+		},
+		"pages/ignored.html": &fstest.MapFile{
+			Data: []byte(`{{.Broken`),
+		},
+	}
+
+	engine := New(Options{
+		FS:         fsys,
+		Backend:    TextBackend,
+		Extensions: []string{".txt"},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("emails/welcome.txt", H{"Name": "O'Brien", "Site": "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Hello O'Brien,\nWelcome to <example.com> & enjoy!\n-- The Team"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	if _, err := engine.Render("pages/ignored.html", nil); err == nil {
+		t.Error("Expected files outside Extensions to be skipped")
+	}
+}
+
+func TestHTMLBackendEscapes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.html": &fstest.MapFile{Data: []byte(`<p>{{.Name}}</p>`)},
+	}
+
+	engine := New(Options{FS: fsys})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("page.html", H{"Name": "<O'Brien>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{"<p>&lt;O&#39;Brien&gt;</p>"}, result)
+}
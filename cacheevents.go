@@ -0,0 +1,74 @@
+package tmplx
+
+import "html/template"
+
+// CacheKind names one of the engine's caches
+type CacheKind string
+
+const (
+	// CacheTemplates holds the resolved templates renders execute
+	CacheTemplates CacheKind = "templates"
+
+	// CacheInheritance holds templates resolved with their layouts while
+	// loading, shared by every template extending them
+	CacheInheritance CacheKind = "inheritance"
+
+	// CacheIncludes holds include files expanded while loading
+	CacheIncludes CacheKind = "includes"
+)
+
+// CacheEvents are callbacks for what happens in the engine's caches, for
+// wiring them into an application's own metrics or alerting. Any of them
+// may be nil. They are called synchronously, so they should be quick.
+type CacheEvents struct {
+	// Load is called when a template is added to a cache
+	Load func(name string, kind CacheKind)
+
+	// Hit and Miss are called when a cache is looked up: by renders for
+	// CacheTemplates, while loading for the others
+	Hit  func(name string, kind CacheKind)
+	Miss func(name string, kind CacheKind)
+
+	// Evict is called when a single entry is dropped or replaced
+	Evict func(name string, kind CacheKind)
+
+	// Invalidate is called for every entry when Reload discards the caches
+	Invalidate func(name string, kind CacheKind)
+}
+
+func (e *TemplateEngine) cacheLoaded(name string, kind CacheKind) {
+	if e.cacheEvents.Load != nil {
+		e.cacheEvents.Load(name, kind)
+	}
+}
+
+func (e *TemplateEngine) cacheLookup(name string, kind CacheKind, hit bool) {
+	if hit && e.cacheEvents.Hit != nil {
+		e.cacheEvents.Hit(name, kind)
+	}
+	if !hit && e.cacheEvents.Miss != nil {
+		e.cacheEvents.Miss(name, kind)
+	}
+}
+
+func (e *TemplateEngine) cacheEvicted(name string, kind CacheKind) {
+	if e.cacheEvents.Evict != nil {
+		e.cacheEvents.Evict(name, kind)
+	}
+}
+
+// cacheInvalidated reports every entry of caches discarded by a reload
+func (e *TemplateEngine) cacheInvalidated(templates, inheritance map[string]*template.Template, includes map[string]*inclCache) {
+	if e.cacheEvents.Invalidate == nil {
+		return
+	}
+	for name := range templates {
+		e.cacheEvents.Invalidate(name, CacheTemplates)
+	}
+	for name := range inheritance {
+		e.cacheEvents.Invalidate(name, CacheInheritance)
+	}
+	for name := range includes {
+		e.cacheEvents.Invalidate(name, CacheIncludes)
+	}
+}
@@ -0,0 +1,54 @@
+package tmplx
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCacheEvents(t *testing.T) {
+	counts := make(map[string]int)
+	record := func(event string) func(string, CacheKind) {
+		return func(name string, kind CacheKind) { counts[fmt.Sprintf("%s %s %s", event, kind, name)]++ }
+	}
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html": `{{include "partials/nav.html" .}}{{block "content" .}}{{end}}`,
+			"partials/nav.html": `<nav></nav>`,
+			"pages/a.html":      `{{extend "layouts/base.html"}}{{define "content"}}a{{end}}`,
+			"pages/b.html":      `{{extend "layouts/base.html"}}{{define "content"}}b{{end}}`,
+		},
+		CacheEvents: CacheEvents{
+			Load:       record("load"),
+			Hit:        record("hit"),
+			Miss:       record("miss"),
+			Evict:      record("evict"),
+			Invalidate: record("invalidate"),
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"pages/a.html", "pages/a.html", "pages/missing.html"} {
+		engine.Render(name, nil)
+	}
+	if err := engine.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{
+		"load templates pages/a.html":         2,
+		"load inheritance layouts/base.html":  2,
+		"hit inheritance layouts/base.html":   4,
+		"load includes layouts/base.html":     2,
+		"hit templates pages/a.html":          2,
+		"miss templates pages/missing.html":   1,
+		"invalidate templates pages/a.html":   1,
+		"invalidate inheritance pages/b.html": 1,
+		"evict templates pages/a.html":        0,
+	}
+	for key, n := range expected {
+		if counts[key] != n {
+			t.Errorf("Expected %d %q events, got %d", n, key, counts[key])
+		}
+	}
+}
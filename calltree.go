@@ -0,0 +1,94 @@
+package tmplx
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// TraceNode is a template, block or include that ran during a render, with
+// the ones that ran inside it in order. Together they are an explain plan
+// for the render.
+type TraceNode struct {
+	// Kind is "template", "block" or "include"
+	Kind string
+
+	// Name is the template path, block name or included path
+	Name string
+
+	// Source is the file the block was defined in
+	Source string
+
+	// DataType is the Go type of dot when it ran, e.g. "main.HomePage",
+	// or "nil"
+	DataType string
+
+	// Children ran inside this node, in order
+	Children []*TraceNode
+}
+
+// callTree builds TraceNodes from boundary marks as they execute
+type callTree struct {
+	root  TraceNode
+	stack []*TraceNode
+}
+
+func (c *callTree) record(kind string, b boundary, dot interface{}) {
+	if kind == "end" {
+		if len(c.stack) > 0 {
+			c.stack = c.stack[:len(c.stack)-1]
+		}
+		return
+	}
+
+	node := &TraceNode{Kind: b.kind, Name: b.name, Source: b.source, DataType: "nil"}
+	if dot != nil {
+		node.DataType = reflect.TypeOf(dot).String()
+	}
+	parent := &c.root
+	if len(c.stack) > 0 {
+		parent = c.stack[len(c.stack)-1]
+	}
+	parent.Children = append(parent.Children, node)
+	c.stack = append(c.stack, node)
+}
+
+// Trace executes the named template with data, discarding the output, and
+// returns the tree of templates, blocks and includes that ran, in order and
+// with the type of data each received. It shows how a deep extends chain
+// actually renders. Like RenderWithTrace it requires Options.Trace or
+// Options.DevMode and the HTML backend.
+func (e *TemplateEngine) Trace(name string, data interface{}, opts ...RenderOption) (*TraceNode, error) {
+	if !e.instrument {
+		return nil, errTraceDisabled
+	}
+
+	cfg := newRenderConfig(opts)
+	cfg.calls = &callTree{}
+	if err := e.renderTo(io.Discard, name, data, cfg); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.calls.root.Children) == 1 {
+		return cfg.calls.root.Children[0], nil
+	}
+	root := cfg.calls.root
+	root.Kind, root.Name = "template", name
+	return &root, nil
+}
+
+// String prints the tree, one node per line, indented by depth
+func (n *TraceNode) String() string {
+	var b strings.Builder
+	n.print(&b, 0)
+	return b.String()
+}
+
+func (n *TraceNode) print(b *strings.Builder, depth int) {
+	label := boundary{kind: n.Kind, name: n.Name, source: n.Source}
+	fmt.Fprintf(b, "%s%s [%s]\n", strings.Repeat("  ", depth), label, n.DataType)
+	for _, c := range n.Children {
+		c.print(b, depth+1)
+	}
+}
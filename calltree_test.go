@@ -0,0 +1,61 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+)
+
+type tracePage struct {
+	Title string
+	Items []traceItem
+}
+
+type traceItem struct {
+	Name string
+}
+
+func TestTrace(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html":    `<html>{{block "title" .}}{{.Title}}{{end}}{{include "partials/nav.html" .}}{{block "content" .}}{{end}}</html>`,
+			"layouts/section.html": `{{extend "layouts/base.html"}}{{define "content"}}<section>{{block "main" .}}{{end}}</section>{{end}}`,
+			"partials/nav.html":    `<nav></nav>`,
+			"pages/list.html":      `{{extend "layouts/section.html"}}{{define "main"}}{{range .Items}}{{block "item" .}}{{.Name}}{{end}}{{end}}{{end}}`,
+		},
+		Trace: true,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	page := tracePage{Title: "List", Items: []traceItem{{"a"}, {"b"}}}
+	tree, err := engine.Trace("pages/list.html", page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `pages/list.html [tmplx.tracePage]
+  block "title" (layouts/base.html) [tmplx.tracePage]
+  partials/nav.html [tmplx.tracePage]
+  block "content" (layouts/section.html) [tmplx.tracePage]
+    block "main" (pages/list.html) [tmplx.tracePage]
+      block "item" (pages/list.html) [tmplx.traceItem]
+      block "item" (pages/list.html) [tmplx.traceItem]
+`
+	if tree.String() != expected {
+		t.Errorf("Unexpected trace tree:\n%s\nexpected:\n%s", tree, expected)
+	}
+
+	// plain renders are unaffected
+	result, err := engine.Render("pages/list.html", page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result, "tmplx") {
+		t.Errorf("Unexpected marks in output %q", result)
+	}
+
+	if _, err := New(Options{Loader: MapLoader{"a.html": "a"}}).Trace("a.html", nil); err == nil {
+		t.Error("Expected error without tracing enabled")
+	}
+}
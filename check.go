@@ -0,0 +1,239 @@
+package tmplx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OutputCheck audits a rendered HTML page, e.g. for broken markup created
+// by composing blocks and includes. Checks set in Options.OutputChecks run
+// on every page rendered in dev mode.
+type OutputCheck interface {
+	Check(html string) []OutputProblem
+}
+
+// OutputCheckFunc adapts a function to an OutputCheck
+type OutputCheckFunc func(html string) []OutputProblem
+
+func (f OutputCheckFunc) Check(html string) []OutputProblem {
+	return f(html)
+}
+
+// OutputProblem is a problem an OutputCheck found in rendered output
+type OutputProblem struct {
+	// Line is the 1-based output line of the problem
+	Line    int
+	Message string
+
+	// Source is the template file that produced the line, filled in by the
+	// engine from the render's trace
+	Source string
+}
+
+func (p OutputProblem) String() string {
+	if p.Source == "" {
+		return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+	}
+	return fmt.Sprintf("line %d (%s): %s", p.Line, p.Source, p.Message)
+}
+
+// CheckOutput renders a template and runs the engine's output checks on
+// it, returning the problems found mapped back to the templates that
+// produced them. It runs regardless of dev mode, e.g. from tests.
+func (e *TemplateEngine) CheckOutput(name string, data interface{}, opts ...RenderOption) ([]OutputProblem, error) {
+	cfg := newRenderConfig(opts)
+	name = e.localeVariant(name, cfg.locale)
+	if e.instrument && cfg.trace == nil {
+		cfg.trace = &RenderTrace{}
+	}
+	var buf strings.Builder
+	if err := e.execute(&buf, name, data, cfg); err != nil {
+		return nil, fmt.Errorf("error rendering template %s: %v", name, err)
+	}
+	return e.runOutputChecks(buf.String(), cfg.trace), nil
+}
+
+// runOutputChecks runs every output check on html, filling in the source
+// of each problem from trace when there is one
+func (e *TemplateEngine) runOutputChecks(html string, trace *RenderTrace) []OutputProblem {
+	var problems []OutputProblem
+	for _, check := range e.outputChecks {
+		for _, p := range check.Check(html) {
+			if trace != nil && p.Source == "" {
+				p.Source = trace.SourceAt(p.Line)
+			}
+			problems = append(problems, p)
+		}
+	}
+	return problems
+}
+
+// logOutputProblems runs the output checks on a page rendered in dev mode,
+// logging what they find
+func (e *TemplateEngine) logOutputProblems(name, html string, trace *RenderTrace) {
+	for _, p := range e.runOutputChecks(html, trace) {
+		e.logf(LogRender, LogWarn, "[TMPLX] Warning: %s %s", name, p)
+	}
+}
+
+var (
+	// elements without content or end tag
+	voidElements = map[string]bool{
+		"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+		"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+	}
+
+	// elements whose end tag may be left out
+	optionalEnd = map[string]bool{
+		"html": true, "head": true, "body": true, "p": true, "li": true, "dt": true, "dd": true,
+		"option": true, "optgroup": true, "tr": true, "td": true, "th": true, "thead": true,
+		"tbody": true, "tfoot": true, "colgroup": true, "caption": true, "rt": true, "rp": true,
+	}
+
+	// elements closing an open paragraph
+	closesP = map[string]bool{
+		"address": true, "article": true, "aside": true, "blockquote": true, "details": true,
+		"div": true, "dl": true, "fieldset": true, "figure": true, "footer": true, "form": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+		"hr": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+		"section": true, "table": true, "ul": true,
+	}
+
+	// elements implicitly closing an open sibling, by the sibling
+	closesSibling = map[string][]string{
+		"li": {"li"}, "dt": {"dt", "dd"}, "dd": {"dt", "dd"}, "tr": {"tr", "td", "th"},
+		"td": {"td", "th"}, "th": {"td", "th"}, "option": {"option"}, "thead": {"tbody"},
+		"tbody": {"thead", "tbody"}, "tfoot": {"thead", "tbody"},
+	}
+
+	// elements whose content is raw text
+	rawTextElements = map[string]bool{"script": true, "style": true, "textarea": true, "title": true}
+
+	endTag = regexp.MustCompile(`^</([a-zA-Z][a-zA-Z0-9-]*)\s*>`)
+)
+
+// WellFormed is an OutputCheck reporting unclosed elements, end tags
+// without a start tag and misnested elements. End tags HTML allows to be
+// left out, such as </li> and </p>, are not required.
+var WellFormed OutputCheck = OutputCheckFunc(checkWellFormed)
+
+type openElement struct {
+	name string
+	line int
+}
+
+func checkWellFormed(html string) []OutputProblem {
+	var problems []OutputProblem
+	report := func(line int, format string, args ...interface{}) {
+		problems = append(problems, OutputProblem{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var stack []openElement
+	scanTags(html, func(t htmlToken) {
+		if voidElements[t.name] {
+			return
+		}
+		if t.end {
+			i := len(stack) - 1
+			for i >= 0 && stack[i].name != t.name {
+				i--
+			}
+			if i < 0 {
+				report(t.line, "end tag </%s> without a start tag", t.name)
+				return
+			}
+			for _, open := range stack[i+1:] {
+				if !optionalEnd[open.name] {
+					report(open.line, "<%s> is not closed before </%s> on line %d", open.name, t.name, t.line)
+				}
+			}
+			stack = stack[:i]
+			return
+		}
+
+		if len(stack) > 0 {
+			top := stack[len(stack)-1].name
+			if (top == "p" && closesP[t.name]) || containsString(closesSibling[t.name], top) {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		if !t.selfClosing {
+			stack = append(stack, openElement{name: t.name, line: t.line})
+		}
+	})
+
+	for _, open := range stack {
+		if !optionalEnd[open.name] {
+			report(open.line, "<%s> is never closed", open.name)
+		}
+	}
+	return problems
+}
+
+// htmlToken is a start or end tag found by scanTags
+type htmlToken struct {
+	line        int
+	name        string
+	attrs       []htmlAttribute
+	end         bool
+	selfClosing bool
+}
+
+// scanTags calls fn for every start and end tag in html, skipping comments,
+// doctypes and the content of raw text elements
+func scanTags(html string, fn func(t htmlToken)) {
+	line := 1
+	for offset := 0; offset < len(html); {
+		next := strings.IndexByte(html[offset:], '<')
+		if next < 0 {
+			return
+		}
+		line += strings.Count(html[offset:offset+next], "\n")
+		offset += next
+		rest := html[offset:]
+
+		var skip string
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			skip = "-->"
+		case strings.HasPrefix(rest, "<!"), strings.HasPrefix(rest, "<?"):
+			skip = ">"
+		}
+		if skip != "" {
+			end := strings.Index(rest, skip)
+			if end < 0 {
+				return
+			}
+			line += strings.Count(rest[:end], "\n")
+			offset += end + len(skip)
+			continue
+		}
+
+		if m := endTag.FindStringSubmatch(rest); m != nil {
+			fn(htmlToken{line: line, name: strings.ToLower(m[1]), end: true})
+			offset += len(m[0])
+			continue
+		}
+
+		loc := htmlTag.FindStringSubmatchIndex(rest)
+		if loc == nil || loc[0] != 0 {
+			offset++
+			continue
+		}
+		name := strings.ToLower(rest[loc[2]:loc[3]])
+		attrs := rest[loc[4]:loc[5]]
+		fn(htmlToken{line: line, name: name, attrs: parseAttrs(attrs), selfClosing: strings.HasSuffix(attrs, "/")})
+		line += strings.Count(rest[:loc[1]], "\n")
+		offset += loc[1]
+
+		if rawTextElements[name] {
+			end := strings.Index(strings.ToLower(html[offset:]), "</"+name)
+			if end < 0 {
+				return
+			}
+			line += strings.Count(html[offset:offset+end], "\n")
+			offset += end
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package tmplx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWellFormed(t *testing.T) {
+	html := `<!DOCTYPE html>
+<html><head><title>a < b</title><meta charset="utf-8"></head>
+<body>
+<!-- <div> -->
+<ul><li>one<li>two</ul>
+<p>para<div>block</div>
+<table><tr><td>a<td>b<tr><td>c</table>
+<div><span>text</div>
+<section>
+<br/><img src="x.png"></em>
+<script>if (a < b) { document.write("<div>") }</script>
+</body></html>`
+
+	var got []string
+	for _, p := range WellFormed.Check(html) {
+		got = append(got, p.String())
+	}
+	expected := []string{
+		"line 8: <span> is not closed before </div> on line 8",
+		"line 10: end tag </em> without a start tag",
+		"line 9: <section> is not closed before </body> on line 12",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	if problems := WellFormed.Check(`<div><p>ok</p></div>`); len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+	if problems := WellFormed.Check("<div>\n<span>"); len(problems) != 2 || problems[1].Message != "<span> is never closed" || problems[1].Line != 2 {
+		t.Errorf("Unexpected problems %v", problems)
+	}
+}
+
+func TestOutputChecks(t *testing.T) {
+	logger := &recordingLogger{}
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html":  "<main>\n{{block \"content\" .}}{{end}}\n{{include \"partials/card.html\" .}}</main>",
+			"pages/home.html":    "{{extend \"layouts/base.html\"}}{{define \"content\"}}<div>{{end}}",
+			"partials/card.html": "<section>card</section>",
+		},
+		DevMode:      true,
+		OutputChecks: []OutputCheck{WellFormed},
+		Logger:       logger,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := engine.CheckOutput("pages/home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Source != "pages/home.html" || !strings.Contains(problems[0].Message, "<div> is not closed before </main>") {
+		t.Errorf("Unexpected problems %v", problems)
+	}
+
+	if _, err := engine.Render("pages/home.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !containsString(logger.lines, "[TMPLX] Warning: pages/home.html "+problems[0].String()) {
+		t.Errorf("Expected output problem to be logged, got %v", logger.lines)
+	}
+}
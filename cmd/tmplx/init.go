@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// scaffoldFiles are the files init creates, by path below the target dir
+var scaffoldFiles = []struct {
+	path    string
+	content string
+}{
+	{"layouts/base.html", `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8">
+    <title>{{block "title" .}}My Site{{end}}</title>
+</head>
+<body>
+    {{include "partials/nav.html" .}}
+    <main>
+        {{block "content" .}}{{end}}
+    </main>
+</body>
+</html>
+`},
+	{"partials/nav.html", `<nav>
+    <a href="/">Home</a>
+</nav>
+`},
+	{"pages/home.html", `{{extend "layouts/base.html"}}
+
+{{define "title"}}Home | My Site{{end}}
+
+{{define "content"}}
+    <h1>Hello, {{.Name}}!</h1>
+{{end}}
+`},
+}
+
+// scaffold writes the scaffold files below dir, returning the paths created.
+// Existing files are an error unless force is set, and nothing is written
+// in that case.
+func scaffold(dir string, force bool) ([]string, error) {
+	if !force {
+		var existing []string
+		for _, f := range scaffoldFiles {
+			path := filepath.Join(dir, filepath.FromSlash(f.path))
+			if _, err := os.Stat(path); err == nil {
+				existing = append(existing, path)
+			}
+		}
+		if len(existing) > 0 {
+			return nil, fmt.Errorf("%s already exist, use -force to overwrite", strings.Join(existing, ", "))
+		}
+	}
+
+	var created []string
+	for _, f := range scaffoldFiles {
+		path := filepath.Join(dir, filepath.FromSlash(f.path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return created, fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(f.content), 0644); err != nil {
+			return created, fmt.Errorf("error writing %s: %v", path, err)
+		}
+		created = append(created, path)
+	}
+	return created, nil
+}
+
+// setupSnippet is the Go code to load and serve the scaffolded templates
+func setupSnippet(dir string) string {
+	return fmt.Sprintf(`engine := tmplx.New(tmplx.Options{Dir: %q})
+if err := engine.Load(); err != nil {
+    log.Fatal(err)
+}
+
+http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+    engine.RenderHTTP(w, r, "pages/home.html", tmplx.H{"Name": "World"})
+})
+`, filepath.ToSlash(dir))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kalyan02/tmplx"
+)
+
+func TestScaffold(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "templates")
+	created, err := scaffold(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != len(scaffoldFiles) {
+		t.Errorf("Expected %d files, got %v", len(scaffoldFiles), created)
+	}
+
+	engine := tmplx.New(tmplx.Options{Dir: dir})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("pages/home.html", tmplx.H{"Name": "World"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<title>Home | My Site</title>", `<a href="/">Home</a>`, "Hello, World!"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in scaffolded page:\n%s", want, result)
+		}
+	}
+
+	// existing files are kept unless forced
+	home := filepath.Join(dir, "pages", "home.html")
+	if err := os.WriteFile(home, []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scaffold(dir, false); err == nil {
+		t.Error("Expected error scaffolding over existing files")
+	}
+	if content, _ := os.ReadFile(home); string(content) != "mine" {
+		t.Errorf("Expected existing file untouched, got %q", content)
+	}
+	if _, err := scaffold(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(home); string(content) == "mine" {
+		t.Error("Expected -force to overwrite")
+	}
+
+	if !strings.Contains(setupSnippet(dir), "tmplx.New") {
+		t.Error("Expected engine setup in snippet")
+	}
+}
@@ -0,0 +1,67 @@
+// Command tmplx provides tooling for projects using the tmplx template
+// engine.
+//
+// Usage:
+//
+//	tmplx init [-force] [dir]
+//
+// init scaffolds the conventional layouts/, pages/ and partials/ structure
+// in dir (default "templates") with a working base layout and example page,
+// and prints the code to set up an engine for it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "init":
+		os.Exit(runInit(os.Args[2:]))
+	case "help", "-h", "-help", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "tmplx: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: tmplx <command> [arguments]
+
+Commands:
+  init [-force] [dir]   scaffold layouts/, pages/ and partials/ in dir (default "templates")
+`)
+}
+
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	force := fs.Bool("force", false, "overwrite existing files")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	dir := "templates"
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+
+	created, err := scaffold(dir, *force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmplx: %v\n", err)
+		return 1
+	}
+	for _, path := range created {
+		fmt.Println("created", path)
+	}
+	fmt.Printf("\nSet up the engine with:\n\n%s", setupSnippet(dir))
+	return 0
+}
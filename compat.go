@@ -0,0 +1,66 @@
+package tmplx
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// TemplateExecutor is the ExecuteTemplate method shared by html/template and
+// the engine. Code written against it can switch from a raw template set to
+// the engine without touching call sites.
+type TemplateExecutor interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+var (
+	_ TemplateExecutor = (*template.Template)(nil)
+	_ TemplateExecutor = (*TemplateEngine)(nil)
+	_ Renderer         = (*TemplateEngine)(nil)
+)
+
+// Renderer is the rendering API of the engine. It is also implemented by
+// RenderPool and by tmplxtest.Recorder, so handlers that take a Renderer can
+// be unit tested without executing templates.
+type Renderer interface {
+	TemplateExecutor
+	Render(name string, data interface{}, opts ...RenderOption) (string, error)
+	RenderResponse(w io.Writer, name string, data interface{}, opts ...RenderOption) error
+	RenderHTTP(w http.ResponseWriter, r *http.Request, name string, data interface{}, opts ...RenderOption) error
+}
+
+// ExecuteTemplate renders the named template to w, like the method of the
+// same name on html/template. Names are template paths as loaded, e.g.
+// "pages/home.html". It is equivalent to RenderResponse.
+func (e *TemplateEngine) ExecuteTemplate(w io.Writer, name string, data interface{}) error {
+	return e.RenderResponse(w, name, data)
+}
+
+// Lookup returns the resolved template with the given name, or nil if there
+// is none, like the method of the same name on html/template.
+func (e *TemplateEngine) Lookup(name string) *template.Template {
+	tmpl, err := e.GetTemplate(name)
+	if err != nil {
+		return nil
+	}
+	return tmpl
+}
+
+// DefinedTemplates returns a string listing the loaded templates, prefixed by
+// "; defined templates are: ", or "" if none are loaded, like the method of
+// the same name on html/template.
+func (e *TemplateEngine) DefinedTemplates() string {
+	e.compileAll()
+	e.mu.RLock()
+	names := e.templateNames()
+	e.mu.RUnlock()
+	if len(names) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = `"` + name + `"`
+	}
+	return "; defined templates are: " + strings.Join(quoted, ", ")
+}
@@ -0,0 +1,42 @@
+package tmplx
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplateExecutor(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{Data: []byte(`<body>{{block "content" .}}{{end}}</body>`)},
+		"pages/home.html":   &fstest.MapFile{Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}<p>{{.}}</p>{{end}}`)},
+	}
+	engine := New(Options{FS: fsys})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	render := func(exec TemplateExecutor, name string) string {
+		var buf bytes.Buffer
+		if err := exec.ExecuteTemplate(&buf, name, "hi"); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	raw := template.Must(template.New("home").Parse(`<body><p>{{.}}</p></body>`))
+	if got, want := render(engine, "pages/home.html"), render(raw, "home"); got != want {
+		t.Errorf("Expected engine output %q to match html/template %q", got, want)
+	}
+
+	if engine.Lookup("pages/home.html") == nil || engine.Lookup("missing.html") != nil {
+		t.Error("Expected Lookup to find only loaded templates")
+	}
+	if got := engine.DefinedTemplates(); got != `; defined templates are: "layouts/base.html", "pages/home.html"` {
+		t.Errorf("Unexpected DefinedTemplates %q", got)
+	}
+	if err := engine.ExecuteTemplate(&bytes.Buffer{}, "missing.html", nil); err == nil {
+		t.Error("Expected an error for a missing template")
+	}
+}
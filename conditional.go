@@ -0,0 +1,112 @@
+package tmplx
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// loadTimeTag matches the load-time conditional tags {{#if ...}},
+// {{#else}} and {{#end}}
+var loadTimeTag = regexp.MustCompile(`\{\{#(if|else|end)\b([^}]*)\}\}`)
+
+// conditionals resolves the load-time conditional sections of a template
+// file against the engine configuration, keeping the markup of those whose
+// condition holds and stripping the rest before the file is parsed:
+//
+//	{{#if env "dev" "staging"}}<div class="banner">{{.Build}}</div>{{#end}}
+//	{{#if not devMode}}<script src="/analytics.js"></script>{{#end}}
+//
+// Conditions are env with one or more environment names, true when
+// Options.Env is one of them, and devMode, each optionally negated by not.
+func (e *TemplateEngine) conditionals(file, content string) (string, error) {
+	if !strings.Contains(content, "{{#") {
+		return content, nil
+	}
+
+	type section struct {
+		line      int
+		keep      bool // the section's markup is kept
+		inherited bool // the enclosing sections are kept
+		seenElse  bool
+	}
+	var open []section
+	keeping := func() bool {
+		return len(open) == 0 || open[len(open)-1].keep && open[len(open)-1].inherited
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range loadTimeTag.FindAllStringSubmatchIndex(content, -1) {
+		line := 1 + strings.Count(content[:m[0]], "\n")
+		if keeping() {
+			b.WriteString(content[last:m[0]])
+		}
+		last = m[1]
+
+		tag, args := content[m[2]:m[3]], strings.TrimSpace(content[m[4]:m[5]])
+		if tag != "if" && args != "" {
+			return "", fmt.Errorf("%s:%d: {{#%s}} takes no arguments", file, line, tag)
+		}
+		switch tag {
+		case "if":
+			holds, err := e.loadTimeCondition(args)
+			if err != nil {
+				return "", fmt.Errorf("%s:%d: %v", file, line, err)
+			}
+			open = append(open, section{line: line, keep: holds, inherited: keeping()})
+		case "else":
+			if len(open) == 0 || open[len(open)-1].seenElse {
+				return "", fmt.Errorf("%s:%d: {{#else}} without {{#if}}", file, line)
+			}
+			open[len(open)-1].keep = !open[len(open)-1].keep
+			open[len(open)-1].seenElse = true
+		case "end":
+			if len(open) == 0 {
+				return "", fmt.Errorf("%s:%d: {{#end}} without {{#if}}", file, line)
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		return "", fmt.Errorf("%s:%d: {{#if}} without {{#end}}", file, open[len(open)-1].line)
+	}
+	b.WriteString(content[last:])
+	return b.String(), nil
+}
+
+// loadTimeCondition evaluates the condition of a {{#if}}
+func (e *TemplateEngine) loadTimeCondition(cond string) (bool, error) {
+	fields := strings.Fields(cond)
+	negate := len(fields) > 0 && fields[0] == "not"
+	if negate {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return false, fmt.Errorf("{{#if}} needs a condition")
+	}
+
+	var holds bool
+	switch name, args := fields[0], fields[1:]; name {
+	case "env":
+		if len(args) == 0 {
+			return false, fmt.Errorf("env needs at least one environment name")
+		}
+		for _, arg := range args {
+			env, err := strconv.Unquote(arg)
+			if err != nil {
+				return false, fmt.Errorf("env takes quoted names, got %s", arg)
+			}
+			holds = holds || env == e.env
+		}
+	case "devMode":
+		if len(args) > 0 {
+			return false, fmt.Errorf("devMode takes no arguments")
+		}
+		holds = e.devMode
+	default:
+		return false, fmt.Errorf("unknown load-time condition %q", name)
+	}
+	return holds != negate, nil
+}
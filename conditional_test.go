@@ -0,0 +1,54 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadTimeConditionals(t *testing.T) {
+	loader := MapLoader{"page.html": `<main>` +
+		`{{#if env "dev" "staging"}}<div class="banner">{{.Build}}</div>{{#end}}` +
+		`{{#if not devMode}}<script src="/analytics.js"></script>{{#else}}<script src="/reload.js"></script>{{#end}}` +
+		`{{#if env "dev"}}{{#if devMode}}[debug]{{#end}}{{#end}}` +
+		`</main>`}
+
+	tests := []struct {
+		opts     Options
+		expected string
+	}{
+		{Options{Env: "prod"}, `<main><script src="/analytics.js"></script></main>`},
+		{Options{Env: "staging"}, `<main><div class="banner">42</div><script src="/analytics.js"></script></main>`},
+		{Options{Env: "dev", DevMode: true}, `<main><div class="banner">42</div><script src="/reload.js"></script>[debug]</main>`},
+	}
+	for _, tt := range tests {
+		tt.opts.Loader = loader
+		engine := New(tt.opts)
+		if err := engine.Load(); err != nil {
+			t.Fatal(err)
+		}
+		result, err := engine.Render("page.html", H{"Build": 42})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// dev mode wraps the output in boundary comments
+		if !strings.Contains(result, tt.expected) {
+			t.Errorf("%s: expected %s, got %s", tt.opts.Env, tt.expected, result)
+		}
+	}
+}
+
+func TestLoadTimeConditionalErrors(t *testing.T) {
+	tests := map[string]string{
+		"{{#if env \"dev\"}}x":                      "page.html:1: {{#if}} without {{#end}}",
+		"x\n{{#end}}":                               "page.html:2: {{#end}} without {{#if}}",
+		"{{#if feature}}x{{#end}}":                  `unknown load-time condition "feature"`,
+		"{{#if env dev}}x{{#end}}":                  "env takes quoted names",
+		"{{#if devMode}}{{#else}}{{#else}}{{#end}}": "{{#else}} without {{#if}}",
+	}
+	for content, message := range tests {
+		engine := New(Options{Loader: MapLoader{"page.html": content}})
+		if err := engine.Load(); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%q: expected an error containing %q, got %v", content, message, err)
+		}
+	}
+}
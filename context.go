@@ -0,0 +1,39 @@
+package tmplx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RenderContext renders the named template with ctx, aborting once ctx is
+// cancelled or its deadline passes, e.g. when the client of a slow page
+// goes away. ctx is checked whenever the template writes output, and on
+// every func call on engines created with Options.Limits; the error of an
+// aborted render wraps ctx.Err(). Templates reach ctx with the context
+// func, e.g. {{range fetchRows context}}, and ServiceFuncs are bound to it.
+func (e *TemplateEngine) RenderContext(ctx context.Context, name string, data interface{}, opts ...RenderOption) (string, error) {
+	var buf strings.Builder
+	err := e.renderTo(&buf, name, data, newRenderConfig(append([]RenderOption{WithContext(ctx)}, opts...)))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", fmt.Errorf("render of %s aborted: %w", name, ctxErr)
+		}
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// cancellable reports whether ctx can be done, so renders with it must
+// check it
+func cancellable(ctx context.Context) bool {
+	return ctx != nil && ctx.Done() != nil
+}
+
+// renderContext returns the context of the render st belongs to
+func renderContext(st *renderState) context.Context {
+	if st.ctx == nil {
+		return context.Background()
+	}
+	return st.ctx
+}
@@ -0,0 +1,60 @@
+package tmplx
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"strings"
+	"testing"
+	"time"
+)
+
+type userKey struct{}
+
+func TestRenderContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine := New(Options{
+		Loader: MapLoader{
+			"user.html": `<p>{{user context}}</p>`,
+			"rows.html": `{{range $i, $_ := .}}{{if eq $i 2}}{{stop}}{{end}}<tr>{{$i}}</tr>{{end}}`,
+		},
+		FuncMap: template.FuncMap{
+			"user": func(ctx context.Context) string {
+				name, _ := ctx.Value(userKey{}).(string)
+				return name
+			},
+			"stop": func() string {
+				cancel()
+				return ""
+			},
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.RenderContext(context.WithValue(ctx, userKey{}, "ada"), "user.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "<p>ada</p>" {
+		t.Errorf("Expected the context passed to the func, got %q", result)
+	}
+	if result, _ := engine.Render("user.html", nil); result != "<p></p>" {
+		t.Errorf("Expected a background context without one, got %q", result)
+	}
+
+	_, err = engine.RenderContext(ctx, "rows.html", make([]int, 100))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the render to abort once cancelled, got %v", err)
+	}
+
+	expired, stop := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer stop()
+	<-expired.Done()
+	_, err = engine.RenderContext(expired, "user.html", nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "user.html") {
+		t.Errorf("Expected a deadline error naming the template, got %v", err)
+	}
+}
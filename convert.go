@@ -0,0 +1,65 @@
+package tmplx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// Converter turns rendered HTML into another format, such as PDF for
+// invoices and reports. Converters are registered by format name with
+// Options.Converters and used by RenderAs.
+type Converter interface {
+	Convert(w io.Writer, html io.Reader) error
+}
+
+// ConverterFunc adapts a function to a Converter
+type ConverterFunc func(w io.Writer, html io.Reader) error
+
+func (f ConverterFunc) Convert(w io.Writer, html io.Reader) error {
+	return f(w, html)
+}
+
+// CommandConverter returns a Converter running an external program that reads
+// HTML on stdin and writes the result to stdout, e.g.
+//
+//	tmplx.CommandConverter("wkhtmltopdf", "--quiet", "-", "-")
+func CommandConverter(name string, args ...string) Converter {
+	return ConverterFunc(func(w io.Writer, html io.Reader) error {
+		var stderr bytes.Buffer
+		cmd := exec.Command(name, args...)
+		cmd.Stdin = html
+		cmd.Stdout = w
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return fmt.Errorf("%s: %v: %s", name, err, msg)
+			}
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		return nil
+	})
+}
+
+// RenderAs renders the named template and converts the output with the
+// Converter registered for format, e.g. "pdf". The template is rendered in
+// full before conversion starts.
+func (e *TemplateEngine) RenderAs(name string, data interface{}, format string, opts ...RenderOption) ([]byte, error) {
+	c, ok := e.converters[format]
+	if !ok {
+		return nil, fmt.Errorf("no converter registered for format %s", format)
+	}
+
+	var html bytes.Buffer
+	if err := e.renderTo(&html, name, data, newRenderConfig(opts)); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := c.Convert(&out, &html); err != nil {
+		return nil, fmt.Errorf("error converting %s to %s: %v", name, format, err)
+	}
+	return out.Bytes(), nil
+}
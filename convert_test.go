@@ -0,0 +1,68 @@
+package tmplx
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRenderAs(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{"invoice.html": `<h1>Invoice {{.Number}}</h1>`},
+		Converters: map[string]Converter{
+			"upper": ConverterFunc(func(w io.Writer, html io.Reader) error {
+				b, err := io.ReadAll(html)
+				if err != nil {
+					return err
+				}
+				_, err = w.Write(bytes.ToUpper(b))
+				return err
+			}),
+			"failing": ConverterFunc(func(w io.Writer, html io.Reader) error {
+				return io.ErrUnexpectedEOF
+			}),
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := engine.RenderAs("invoice.html", H{"Number": 42}, "upper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "<H1>INVOICE 42</H1>" {
+		t.Errorf("Expected converted output, got %q", out)
+	}
+
+	if _, err := engine.RenderAs("invoice.html", nil, "pdf"); err == nil || !strings.Contains(err.Error(), "no converter") {
+		t.Errorf("Expected error for unknown format, got %v", err)
+	}
+	if _, err := engine.RenderAs("invoice.html", nil, "failing"); err == nil || !strings.Contains(err.Error(), "to failing") {
+		t.Errorf("Expected converter error, got %v", err)
+	}
+	if _, err := engine.RenderAs("missing.html", nil, "upper"); err == nil {
+		t.Error("Expected error for missing template, got nil")
+	}
+}
+
+func TestCommandConverter(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not available")
+	}
+
+	var out bytes.Buffer
+	if err := CommandConverter("cat").Convert(&out, strings.NewReader("<p>hi</p>")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "<p>hi</p>" {
+		t.Errorf("Expected command output, got %q", out.String())
+	}
+
+	err := CommandConverter("cat", "/nonexistent").Convert(&out, strings.NewReader(""))
+	if err == nil || !strings.Contains(err.Error(), "/nonexistent") {
+		t.Errorf("Expected error with stderr, got %v", err)
+	}
+}
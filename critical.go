@@ -0,0 +1,231 @@
+package tmplx
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// CSSExtractor finds the CSS a rendered page needs for its first paint
+type CSSExtractor interface {
+	CriticalCSS(html []byte) (string, error)
+}
+
+// CSSExtractorFunc adapts a function to a CSSExtractor
+type CSSExtractorFunc func(html []byte) (string, error)
+
+func (f CSSExtractorFunc) CriticalCSS(html []byte) (string, error) {
+	return f(html)
+}
+
+var (
+	stylesheetLink = regexp.MustCompile(`(?i)<link\b[^>]*\brel=["']?stylesheet["']?[^>]*>`)
+	stylesheetRel  = regexp.MustCompile(`(?i)\brel=["']?stylesheet["']?`)
+	headClose      = regexp.MustCompile(`(?i)</head>`)
+	nonceAttr      = regexp.MustCompile(`(?i)<(?:script|style)\b[^>]*?\snonce=["']?([^"'\s>]+)`)
+)
+
+// stylesheetLoader turns the stylesheets deferred by preloadStylesheet into
+// stylesheets. Set by a script, they don't block rendering.
+const stylesheetLoader = `document.querySelectorAll("link[data-tmplx-css]").forEach(function (l) { l.rel = "stylesheet"; });`
+
+// inlineCriticalCSS inlines the critical CSS of a rendered page in a <style>
+// block at the end of its head and defers its stylesheets until after the
+// first paint. Pages without a head or without critical CSS are unchanged.
+//
+// With StrictCSP the style block and a script loading the stylesheets carry
+// the nonce the page's own scripts or styles use, as a strict policy blocks
+// inline styles and event handlers without it. Pages without a nonce are
+// left unchanged.
+func (e *TemplateEngine) inlineCriticalCSS(html []byte) ([]byte, error) {
+	head := headClose.FindIndex(html)
+	if head == nil {
+		return html, nil
+	}
+	var nonce string
+	if e.strictCSP != CSPOff {
+		m := nonceAttr.FindSubmatch(html)
+		if m == nil {
+			return html, nil
+		}
+		nonce = string(m[1])
+	}
+	css, err := e.criticalCSS.CriticalCSS(html)
+	if err != nil {
+		return nil, fmt.Errorf("error extracting critical CSS: %v", err)
+	}
+	if strings.TrimSpace(css) == "" {
+		return html, nil
+	}
+
+	css = strings.ReplaceAll(css, "</", `<\/`)
+
+	var out bytes.Buffer
+	out.Grow(len(html) + len(css) + 32)
+	if nonce == "" {
+		out.Write(stylesheetLink.ReplaceAllFunc(html[:head[0]], deferStylesheet))
+		out.WriteString("<style>" + css + "</style>")
+	} else {
+		attr := ` nonce="` + template.HTMLEscapeString(nonce) + `"`
+		out.Write(stylesheetLink.ReplaceAllFunc(html[:head[0]], preloadStylesheet))
+		out.WriteString("<style" + attr + ">" + css + "</style>")
+		out.WriteString("<script" + attr + ">" + stylesheetLoader + "</script>")
+	}
+	out.Write(html[head[0]:])
+	return out.Bytes(), nil
+}
+
+// deferStylesheet turns a stylesheet link into one that loads without
+// blocking rendering, keeping the original for browsers without scripts
+func deferStylesheet(link []byte) []byte {
+	deferred := stylesheetRel.ReplaceAll(link, []byte(`rel="preload" as="style" onload="this.onload=null;this.rel='stylesheet'"`))
+	return []byte(string(deferred) + "<noscript>" + string(link) + "</noscript>")
+}
+
+// preloadStylesheet is deferStylesheet without the inline event handler,
+// leaving stylesheetLoader to apply the stylesheet
+func preloadStylesheet(link []byte) []byte {
+	deferred := stylesheetRel.ReplaceAll(link, []byte(`rel="preload" as="style" data-tmplx-css`))
+	return []byte(string(deferred) + "<noscript>" + string(link) + "</noscript>")
+}
+
+// UsedCSS returns a basic CSSExtractor keeping the rules of stylesheet whose
+// selectors can match the page: every tag, class and id in the subject of
+// one of the rule's selectors appears in the page. Rules in @media blocks
+// are filtered the same way; other at-rules such as @font-face are kept.
+// Pseudo-classes and attribute selectors are ignored when matching, so the
+// result errs on the side of keeping rules.
+func UsedCSS(stylesheet string) CSSExtractor {
+	rules := parseCSS(stylesheet)
+	return CSSExtractorFunc(func(html []byte) (string, error) {
+		page := scanPage(html)
+		var b strings.Builder
+		writeUsedRules(&b, rules, page)
+		return b.String(), nil
+	})
+}
+
+// cssRule is a rule or, with children, an at-rule block
+type cssRule struct {
+	prelude  string
+	body     string
+	children []cssRule
+}
+
+// parseCSS splits a stylesheet into rules, one level of nesting deep
+func parseCSS(css string) []cssRule {
+	css = cssComment.ReplaceAllString(css, "")
+	var rules []cssRule
+	for {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			return rules
+		}
+		prelude := strings.TrimSpace(css[:open])
+		end := matchingBrace(css, open)
+		if end < 0 {
+			return rules
+		}
+		body := css[open+1 : end]
+		css = css[end+1:]
+
+		rule := cssRule{prelude: prelude, body: strings.TrimSpace(body)}
+		if strings.HasPrefix(prelude, "@media") || strings.HasPrefix(prelude, "@supports") {
+			rule.children = parseCSS(body)
+		}
+		rules = append(rules, rule)
+	}
+}
+
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func writeUsedRules(b *strings.Builder, rules []cssRule, page pageTokens) {
+	for _, r := range rules {
+		switch {
+		case r.children != nil:
+			var inner strings.Builder
+			writeUsedRules(&inner, r.children, page)
+			if inner.Len() > 0 {
+				b.WriteString(r.prelude + "{" + inner.String() + "}")
+			}
+		case strings.HasPrefix(r.prelude, "@"):
+			b.WriteString(r.prelude + "{" + r.body + "}")
+		default:
+			for _, sel := range strings.Split(r.prelude, ",") {
+				if page.matches(sel) {
+					b.WriteString(r.prelude + "{" + r.body + "}")
+					break
+				}
+			}
+		}
+	}
+}
+
+// pageTokens are the tags, classes and ids used in a page
+type pageTokens map[string]bool
+
+var (
+	tagToken   = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9-]*)`)
+	classAttr  = regexp.MustCompile(`(?i)\bclass\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	idAttr     = regexp.MustCompile(`(?i)\bid\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	selectorID = regexp.MustCompile(`([.#]?)([a-zA-Z_][a-zA-Z0-9_-]*|\*)`)
+
+	// attribute selectors, pseudo-classes and pseudo-elements
+	selectorExtras = regexp.MustCompile(`\[[^\]]*\]|::?[a-zA-Z-]+(\([^)]*\))?`)
+	cssComment     = regexp.MustCompile(`(?s)/\*.*?\*/`)
+)
+
+func scanPage(html []byte) pageTokens {
+	page := pageTokens{"*": true, "html": true, ":root": true}
+	for _, m := range tagToken.FindAllSubmatch(html, -1) {
+		page[strings.ToLower(string(m[1]))] = true
+	}
+	for _, m := range classAttr.FindAllSubmatch(html, -1) {
+		for _, class := range strings.Fields(string(m[1]) + string(m[2]) + string(m[3])) {
+			page["."+class] = true
+		}
+	}
+	for _, m := range idAttr.FindAllSubmatch(html, -1) {
+		page["#"+string(m[1])+string(m[2])+string(m[3])] = true
+	}
+	return page
+}
+
+// matches reports whether every tag, class and id of the selector's subject,
+// its last compound selector, is used in the page
+func (p pageTokens) matches(selector string) bool {
+	fields := strings.FieldsFunc(selector, func(r rune) bool {
+		return r == ' ' || r == '>' || r == '+' || r == '~' || r == '\t' || r == '\n'
+	})
+	if len(fields) == 0 {
+		return false
+	}
+	subject := fields[len(fields)-1]
+	subject = selectorExtras.ReplaceAllString(subject, "")
+	for _, m := range selectorID.FindAllStringSubmatch(subject, -1) {
+		token := m[1] + m[2]
+		if m[1] == "" {
+			token = strings.ToLower(token)
+		}
+		if !p[token] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCriticalCSS(t *testing.T) {
+	css := `/* base */
+body { margin: 0 }
+.card > .title:hover { color: red }
+.unused { color: blue }
+#main, .sidebar { width: 50% }
+@media (max-width: 600px) { .card { padding: 0 } .gone { display: none } }
+@font-face { font-family: x; src: url(x.woff2) }`
+
+	engine := New(Options{
+		Loader: MapLoader{
+			"page.html":  `<html><head><link rel="stylesheet" href="/app.css"></head><body><div id="main" class="card"><h1 class="title">{{.}}</h1></div></body></html>`,
+			"frag.html":  `<p>no head</p>`,
+			"empty.html": `<html><head><link rel="stylesheet" href="/app.css"></head><body><table></table></body></html>`,
+		},
+		CriticalCSS: UsedCSS(css),
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("page.html", "Hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{
+		`<link rel="preload" as="style" onload="this.onload=null;this.rel='stylesheet'" href="/app.css"><noscript><link rel="stylesheet" href="/app.css"></noscript>`,
+		`<style>body{margin: 0}.card > .title:hover{color: red}#main, .sidebar{width: 50%}@media (max-width: 600px){.card{padding: 0}}@font-face{font-family: x; src: url(x.woff2)}</style></head>`,
+	}, result)
+	for _, unexpected := range []string{".unused", ".gone", "base"} {
+		if strings.Contains(result, unexpected) {
+			t.Errorf("Expected %s left out, got %s", unexpected, result)
+		}
+	}
+
+	result, err = engine.Render("frag.html", nil)
+	if err != nil || result != "<p>no head</p>" {
+		t.Errorf("Expected pages without a head unchanged, got %q %v", result, err)
+	}
+
+	result, err = engine.Render("empty.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, `<style>body{margin: 0}@font-face`) {
+		t.Errorf("Expected only matching rules, got %s", result)
+	}
+}
+
+func TestCriticalCSSStrictCSP(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"page.html":    `<html><head><link rel="stylesheet" href="/app.css"><script nonce="{{.}}" src="/app.js"></script></head><body><p>hi</p></body></html>`,
+			"nononce.html": `<html><head><link rel="stylesheet" href="/app.css"></head><body><p>hi</p></body></html>`,
+		},
+		CriticalCSS: UsedCSS(`p { color: red }`),
+		StrictCSP:   CSPFail,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("page.html", "r4nd0m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{
+		`<link rel="preload" as="style" data-tmplx-css href="/app.css"><noscript><link rel="stylesheet" href="/app.css"></noscript>`,
+		`<style nonce="r4nd0m">p{color: red}</style><script nonce="r4nd0m">`,
+	}, result)
+	if violations := AuditCSP(result); len(violations) > 0 {
+		t.Errorf("Expected the engine's markup to pass a strict CSP, got %v", violations)
+	}
+
+	// without a nonce to give them, inline styles would be blocked
+	result, err = engine.Render("nononce.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result, "<style") || strings.Contains(result, "preload") {
+		t.Errorf("Expected the page unchanged, got %s", result)
+	}
+}
@@ -0,0 +1,174 @@
+package tmplx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CSPMode controls the audit of rendered HTML for output a strict
+// Content-Security-Policy would block
+type CSPMode int
+
+const (
+	// CSPOff disables the audit
+	CSPOff CSPMode = iota
+
+	// CSPWarn logs a warning for every violation found in a rendered page
+	CSPWarn
+
+	// CSPFail fails the render of a page with violations, so they show up
+	// in development and tests rather than in the browser console
+	CSPFail
+)
+
+// CSPViolation is markup in a rendered page that a strict
+// Content-Security-Policy, one allowing scripts and styles by nonce only,
+// would block
+type CSPViolation struct {
+	Line int
+
+	// Kind is "event handler", "javascript URL", "inline script",
+	// "inline style" or "style attribute"
+	Kind string
+
+	// Snippet is the offending tag, shortened
+	Snippet string
+}
+
+func (v CSPViolation) String() string {
+	return fmt.Sprintf("line %d: %s in %s", v.Line, v.Kind, v.Snippet)
+}
+
+var (
+	htmlTag  = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9-]*)((?:[^>"']|"[^"]*"|'[^']*')*)>`)
+	htmlAttr = regexp.MustCompile(`([^\s"'=/>]+)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+)))?`)
+)
+
+// AuditCSP returns the markup in html that a strict Content-Security-Policy
+// would block: inline event handlers, javascript: URLs, style attributes, and
+// inline scripts and styles without a nonce. Scripts with a src and data
+// blocks such as JSON scripts are allowed.
+func AuditCSP(html string) []CSPViolation {
+	var violations []CSPViolation
+	add := func(offset int, kind, tag string) {
+		if len(tag) > 80 {
+			tag = tag[:77] + "..."
+		}
+		violations = append(violations, CSPViolation{
+			Line:    strings.Count(html[:offset], "\n") + 1,
+			Kind:    kind,
+			Snippet: tag,
+		})
+	}
+
+	for offset := 0; offset < len(html); {
+		rest := html[offset:]
+		if strings.HasPrefix(rest, "<!--") {
+			end := strings.Index(rest, "-->")
+			if end < 0 {
+				break
+			}
+			offset += end + len("-->")
+			continue
+		}
+		loc := htmlTag.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			break
+		}
+		if comment := strings.Index(rest[:loc[0]], "<!--"); comment >= 0 {
+			offset += comment
+			continue
+		}
+
+		start := offset + loc[0]
+		tag := rest[loc[0]:loc[1]]
+		name := strings.ToLower(rest[loc[2]:loc[3]])
+		attrs := parseAttrs(rest[loc[4]:loc[5]])
+		offset += loc[1]
+
+		for _, a := range attrs {
+			switch {
+			case strings.HasPrefix(a.name, "on"):
+				add(start, "event handler", tag)
+			case a.name == "style":
+				add(start, "style attribute", tag)
+			case (a.name == "href" || a.name == "src" || a.name == "action" || a.name == "formaction") &&
+				strings.HasPrefix(strings.ToLower(strings.TrimSpace(a.value)), "javascript:"):
+				add(start, "javascript URL", tag)
+			}
+		}
+
+		if name != "script" && name != "style" {
+			continue
+		}
+		// the element's content is raw text up to its closing tag
+		end := strings.Index(strings.ToLower(html[offset:]), "</"+name)
+		if end < 0 {
+			end = len(html) - offset
+		}
+		content := html[offset : offset+end]
+		offset += end
+
+		switch {
+		case attrValue(attrs, "nonce") != "":
+		case name == "script" && (attrValue(attrs, "src") != "" || !executableScript(attrValue(attrs, "type"))):
+		case name == "script" && strings.TrimSpace(content) != "":
+			add(start, "inline script", tag)
+		case name == "style" && strings.TrimSpace(content) != "":
+			add(start, "inline style", tag)
+		}
+	}
+	return violations
+}
+
+type htmlAttribute struct {
+	name, value string
+}
+
+// parseAttrs returns the attributes of a tag in order, with lower-cased names
+func parseAttrs(s string) []htmlAttribute {
+	var attrs []htmlAttribute
+	for _, m := range htmlAttr.FindAllStringSubmatch(s, -1) {
+		attrs = append(attrs, htmlAttribute{strings.ToLower(m[1]), m[2] + m[3] + m[4]})
+	}
+	return attrs
+}
+
+func attrValue(attrs []htmlAttribute, name string) string {
+	for _, a := range attrs {
+		if a.name == name {
+			return a.value
+		}
+	}
+	return ""
+}
+
+// executableScript reports whether a script of the given type runs, rather
+// than being a data block
+func executableScript(typ string) bool {
+	switch strings.ToLower(strings.TrimSpace(typ)) {
+	case "", "module", "text/javascript", "application/javascript", "text/ecmascript", "application/ecmascript":
+		return true
+	}
+	return false
+}
+
+// auditCSP checks a rendered page according to the engine's CSPMode
+func (e *TemplateEngine) auditCSP(name string, html []byte) error {
+	violations := AuditCSP(string(html))
+	if len(violations) == 0 {
+		return nil
+	}
+	if e.strictCSP == CSPWarn {
+		for _, v := range violations {
+			e.logf(LogRender, LogWarn, "[TMPLX] Warning: %s %s blocked by a strict CSP", name, v)
+		}
+		return nil
+	}
+	msgs := make([]string, len(violations))
+	for i, v := range violations {
+		msgs[i] = v.String()
+	}
+	return fmt.Errorf("output blocked by a strict CSP: %s", strings.Join(msgs, "; "))
+}
@@ -0,0 +1,72 @@
+package tmplx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAuditCSP(t *testing.T) {
+	html := `<!-- <button onclick="ignored()"> -->
+<button onclick="go()">Go</button><a href=" JavaScript:void(0)">x</a>
+<div style="color: red">red</div>
+<script>alert(1)</script><script nonce="r4nd0m">ok()</script>
+<script src="/app.js"></script><script type="application/ld+json">{"a": "<b onclick=x>"}</script>
+<style>p { color: red }</style><style nonce="r4nd0m">p {}</style>`
+
+	var kinds []string
+	for _, v := range AuditCSP(html) {
+		kinds = append(kinds, v.Kind)
+	}
+	expected := []string{"event handler", "javascript URL", "style attribute", "inline script", "inline style"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Expected %v, got %v", expected, kinds)
+	}
+
+	violations := AuditCSP(html)
+	if violations[0].Line != 2 || violations[0].Snippet != `<button onclick="go()">` {
+		t.Errorf("Unexpected violation %v", violations[0])
+	}
+	if violations[4].Line != 6 {
+		t.Errorf("Expected inline style on line 6, got %d", violations[4].Line)
+	}
+}
+
+func TestStrictCSP(t *testing.T) {
+	loader := MapLoader{
+		"clean.html": `<script nonce="{{.}}">run()</script>`,
+		"page.html":  `<button onclick="save()">Save</button>`,
+	}
+
+	engine := New(Options{Loader: loader, StrictCSP: CSPFail})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Render("clean.html", "n0nce"); err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	err := engine.RenderResponse(&buf, "page.html", nil)
+	if err == nil || !strings.Contains(err.Error(), `line 1: event handler in <button onclick="save()">`) {
+		t.Errorf("Expected CSP error, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output for failed render, got %q", buf.String())
+	}
+
+	logger := &recordingLogger{}
+	engine = New(Options{Loader: loader, StrictCSP: CSPWarn, Logger: logger})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("page.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != `<button onclick="save()">Save</button>` {
+		t.Errorf("Unexpected output %q", result)
+	}
+	if !containsString(logger.lines, `[TMPLX] Warning: page.html line 1: event handler in <button onclick="save()"> blocked by a strict CSP`) {
+		t.Errorf("Expected CSP warning, got %v", logger.lines)
+	}
+}
@@ -0,0 +1,153 @@
+package tmplx
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// TemplateDebugInfo describes a resolved template and its associated
+// templates (blocks), for logging or display in debug tooling.
+type TemplateDebugInfo struct {
+	// Name is the name of the template
+	Name string
+
+	// Templates lists the template itself first, then its blocks by name
+	Templates []TemplateTreeInfo
+
+	// Size is the total size of all trees in bytes
+	Size int
+}
+
+// TemplateTreeInfo describes a single parse tree of a resolved template
+type TemplateTreeInfo struct {
+	// Name is the template or block name
+	Name string
+
+	// Source is the file the tree was defined in
+	Source string
+
+	// Content is the tree printed back as template source
+	Content string
+
+	// Size is the length of Content in bytes
+	Size int
+
+	// Nodes is the number of parse nodes in the tree
+	Nodes int
+}
+
+// DebugTemplate collects the names, sources, contents and sizes of every tree
+// in t. Print the result or render it with HTML.
+func DebugTemplate(t *template.Template) *TemplateDebugInfo {
+	info := &TemplateDebugInfo{Name: t.Name()}
+
+	templates := t.Templates()
+	sort.Slice(templates, func(i, j int) bool {
+		if (templates[i].Name() == t.Name()) != (templates[j].Name() == t.Name()) {
+			return templates[i].Name() == t.Name()
+		}
+		return templates[i].Name() < templates[j].Name()
+	})
+
+	for _, tmpl := range templates {
+		entry := TemplateTreeInfo{Name: tmpl.Name()}
+		if tmpl.Tree != nil && tmpl.Tree.Root != nil {
+			entry.Source = tmpl.Tree.ParseName
+			entry.Content = tmpl.Tree.Root.String()
+			entry.Size = len(entry.Content)
+			entry.Nodes = countNodes(tmpl.Tree.Root)
+		}
+		info.Size += entry.Size
+		info.Templates = append(info.Templates, entry)
+	}
+	return info
+}
+
+// DebugInfo returns DebugTemplate for a loaded template, named as loaded.
+func (e *TemplateEngine) DebugInfo(name string) (*TemplateDebugInfo, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	tmpl, err := e.getTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := e.loadCache[name]; ok {
+		// free of the boundary marks added to the cached copy in dev mode
+		tmpl = resolved
+	}
+	info := DebugTemplate(tmpl)
+	info.Name = name
+	return info, nil
+}
+
+// String formats the info as plain text, one tree per entry
+func (d *TemplateDebugInfo) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Template %q (%d bytes):\n", d.Name, d.Size)
+	for _, t := range d.Templates {
+		fmt.Fprintf(&b, "  - %q from %s (%d bytes, %d nodes):\n", t.Name, t.Source, t.Size, t.Nodes)
+		if t.Content != "" {
+			fmt.Fprintf(&b, "    Content: %s\n", t.Content)
+		}
+	}
+	return b.String()
+}
+
+var debugFragment = template.Must(template.New("debug").Parse(`<section class="tmplx-debug">
+<h2>Trees of <code>{{.Name}}</code> ({{.Size}} bytes)</h2>
+{{- range .Templates}}
+<details>
+<summary><code>{{.Name}}</code>{{if .Source}} from <code>{{.Source}}</code>{{end}} &middot; {{.Size}} bytes, {{.Nodes}} nodes</summary>
+<pre>{{.Content}}</pre>
+</details>
+{{- end}}
+</section>`))
+
+// HTML renders the info as an HTML fragment
+func (d *TemplateDebugInfo) HTML() template.HTML {
+	var buf bytes.Buffer
+	if err := debugFragment.Execute(&buf, d); err != nil {
+		return template.HTML("<pre>" + template.HTMLEscapeString(err.Error()) + "</pre>")
+	}
+	return template.HTML(buf.String())
+}
+
+// countNodes counts the nodes below node, including node itself
+func countNodes(node parse.Node) int {
+	count := 1
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return 0
+		}
+		for _, c := range n.Nodes {
+			count += countNodes(c)
+		}
+	case *parse.ActionNode:
+		count += countNodes(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return 0
+		}
+		for _, c := range n.Cmds {
+			count += countNodes(c)
+		}
+	case *parse.CommandNode:
+		for _, c := range n.Args {
+			count += countNodes(c)
+		}
+	case *parse.IfNode:
+		count += countNodes(n.Pipe) + countNodes(n.List) + countNodes(n.ElseList)
+	case *parse.RangeNode:
+		count += countNodes(n.Pipe) + countNodes(n.List) + countNodes(n.ElseList)
+	case *parse.WithNode:
+		count += countNodes(n.Pipe) + countNodes(n.List) + countNodes(n.ElseList)
+	case *parse.TemplateNode:
+		count += countNodes(n.Pipe)
+	}
+	return count
+}
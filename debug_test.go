@@ -0,0 +1,45 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDebugTemplate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{Data: []byte(`<body>{{block "content" .}}{{end}}</body>`)},
+		"pages/home.html":   &fstest.MapFile{Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}<p>{{.Name}}</p>{{end}}`)},
+	}
+	engine := New(Options{FS: fsys})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := engine.DebugInfo("pages/home.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "pages/home.html" || len(info.Templates) != 2 || info.Templates[0].Name == "content" {
+		t.Fatalf("Expected the template first and one block, got %+v", info.Templates)
+	}
+	content := info.Templates[1]
+	if content.Name != "content" || content.Source != "pages/home.html" || content.Content != "<p>{{.Name}}</p>" {
+		t.Errorf("Unexpected block info %+v", content)
+	}
+	if content.Size != len(content.Content) || content.Nodes == 0 || info.Size < content.Size {
+		t.Errorf("Unexpected sizes %+v (total %d)", content, info.Size)
+	}
+
+	containsAll(t, []string{`"content" from pages/home.html`, "Content: <p>{{.Name}}</p>"}, info.String())
+
+	html := string(info.HTML())
+	containsAll(t, []string{"<code>content</code>", "&lt;p&gt;{{.Name}}&lt;/p&gt;"}, html)
+	if strings.Contains(html, "<p>{{") {
+		t.Errorf("Expected tree contents to be escaped, got %s", html)
+	}
+
+	if _, err := engine.DebugInfo("missing.html"); err == nil {
+		t.Error("Expected an error for a missing template")
+	}
+}
@@ -3,10 +3,15 @@ package tmplx
 import (
 	"fmt"
 	"io"
+	"net/http"
+	"sync"
 )
 
 var (
 	DefaultEngine *TemplateEngine
+
+	named   = make(map[string]*TemplateEngine)
+	namedMu sync.RWMutex
 )
 
 // H is a shortcut for map[string]interface{}
@@ -16,18 +21,84 @@ type H map[string]any
 func Load(opts Options) error {
 	DefaultEngine = New(opts)
 	if err := DefaultEngine.Load(); err != nil {
-		return fmt.Errorf("error loading tmplx engine: %v", err)
+		return fmt.Errorf("error loading tmplx engine: %w", err)
 	}
 
 	return nil
 }
 
 // Render renders a template and returns the output as a string
-func Render(name string, data H) (string, error) {
-	return DefaultEngine.Render(name, data)
+func Render(name string, data H, opts ...RenderOption) (string, error) {
+	return DefaultEngine.Render(name, data, opts...)
+}
+
+// Write renders a template as an HTTP response with the given status code
+func Write(w http.ResponseWriter, status int, name string, data H, opts ...RenderOption) error {
+	return DefaultEngine.Write(w, status, name, data, opts...)
+}
+
+// RenderResponse renders a template and streams it to the response writer
+func RenderResponse(w io.Writer, name string, data H, opts ...RenderOption) error {
+	return DefaultEngine.RenderResponse(w, name, data, opts...)
+}
+
+// RenderResponseBuffered renders a template and writes it to the response
+// writer only if rendering succeeded
+func RenderResponseBuffered(w io.Writer, name string, data H, opts ...RenderOption) error {
+	return DefaultEngine.RenderResponseBuffered(w, name, data, opts...)
+}
+
+// RenderJSON writes the data a template would receive as a JSON response
+func RenderJSON(w http.ResponseWriter, name string, data H, opts ...RenderOption) error {
+	return DefaultEngine.RenderJSON(w, name, data, opts...)
 }
 
-// RenderResponse renders a template and writes it to the response writer
-func RenderResponse(w io.Writer, name string, data H) error {
-	return DefaultEngine.RenderResponse(w, name, data)
+// LoadNamed initializes and loads an engine registered under group, for
+// applications with several distinct template sets, e.g. "web" and
+// "emails". Loading a group again replaces it.
+func LoadNamed(group string, opts Options) error {
+	e := New(opts)
+	if err := e.Load(); err != nil {
+		return fmt.Errorf("error loading tmplx engine %s: %w", group, err)
+	}
+
+	namedMu.Lock()
+	named[group] = e
+	namedMu.Unlock()
+	return nil
+}
+
+// Named returns the engine loaded under group with LoadNamed, or nil
+func Named(group string) *TemplateEngine {
+	namedMu.RLock()
+	defer namedMu.RUnlock()
+	return named[group]
+}
+
+func namedEngine(group string) (*TemplateEngine, error) {
+	e := Named(group)
+	if e == nil {
+		return nil, fmt.Errorf("tmplx engine %s is not loaded", group)
+	}
+	return e, nil
+}
+
+// RenderAs renders a template of the engine loaded under group and returns
+// the output as a string
+func RenderAs(group, name string, data H, opts ...RenderOption) (string, error) {
+	e, err := namedEngine(group)
+	if err != nil {
+		return "", err
+	}
+	return e.Render(name, data, opts...)
+}
+
+// WriteAs renders a template of the engine loaded under group as an HTTP
+// response with the given status code
+func WriteAs(group string, w http.ResponseWriter, status int, name string, data H, opts ...RenderOption) error {
+	e, err := namedEngine(group)
+	if err != nil {
+		return err
+	}
+	return e.Write(w, status, name, data, opts...)
 }
@@ -0,0 +1,44 @@
+package tmplx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNamedEngines(t *testing.T) {
+	if err := LoadNamed("web", Options{Loader: MapLoader{"home.html": "<p>{{.Name}}</p>"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadNamed("emails", Options{Loader: MapLoader{"home.html": "Dear {{.Name}}"}, Backend: TextBackend}); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := RenderAs("web", "home.html", H{"Name": "<Ann>"}); err != nil || result != "<p>&lt;Ann&gt;</p>" {
+		t.Errorf("Unexpected web output %q, %v", result, err)
+	}
+	if result, err := RenderAs("emails", "home.html", H{"Name": "<Ann>"}); err != nil || result != "Dear <Ann>" {
+		t.Errorf("Unexpected email output %q, %v", result, err)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := WriteAs("web", rec, http.StatusCreated, "home.html", H{"Name": "Bo"}); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated || rec.Body.String() != "<p>Bo</p>" {
+		t.Errorf("Unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+
+	if Named("web") == nil || Named("sms") != nil {
+		t.Error("Expected only loaded groups to be registered")
+	}
+	if _, err := RenderAs("sms", "home.html", nil); err == nil {
+		t.Error("Expected error rendering with an unknown group")
+	}
+	if err := LoadNamed("broken", Options{Loader: MapLoader{"a.html": "{{if}}"}}); err == nil {
+		t.Error("Expected load error")
+	}
+	if Named("broken") != nil {
+		t.Error("Expected a failed load not to be registered")
+	}
+}
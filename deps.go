@@ -0,0 +1,65 @@
+package tmplx
+
+import "sort"
+
+// dependencyGraph maps a template name to the templates it extends or includes
+type dependencyGraph map[string][]string
+
+// add records that name extends or includes dep. The graph is filled while
+// templates are resolved; callers hold resolveMu.
+func (g dependencyGraph) add(name, dep string) {
+	if !containsString(g[name], dep) {
+		g[name] = append(g[name], dep)
+	}
+}
+
+// findCycle returns the first dependency cycle in the graph as a path that
+// starts and ends with the same template, or nil if the graph is acyclic.
+func (g dependencyGraph) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	names := make([]string, 0, len(g))
+	for name := range g {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	state := make(map[string]int, len(g))
+	var stack []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			for i, n := range stack {
+				if n == name {
+					return append(append([]string{}, stack[i:]...), name)
+				}
+			}
+		case done:
+			return nil
+		}
+
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range g[name] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
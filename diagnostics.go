@@ -0,0 +1,284 @@
+package tmplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// EscapeInfo describes the escaping context html/template chose for a single
+// action in a resolved template.
+type EscapeInfo struct {
+	// Template is the name of the associated template (block) holding the action
+	Template string
+
+	// Location is the parser position of the action, e.g. "pages/home.html:3:14"
+	Location string
+
+	// Action is the action as authored, without the escapers html/template added
+	Action string
+
+	// Context is the escaping context the action ended up in, e.g. "html", "attr", "url", "js"
+	Context string
+
+	// Escapers lists the escaping functions applied to the action's output
+	Escapers []string
+
+	// Warning is non-empty when the output is likely to be escaped twice
+	Warning string
+}
+
+// escaperContexts maps html/template's internal escaper functions to the
+// context they are inserted for.
+var escaperContexts = map[string]string{
+	"_html_template_htmlescaper":      "html",
+	"_html_template_rcdataescaper":    "rcdata",
+	"_html_template_commentescaper":   "comment",
+	"_html_template_attrescaper":      "attr",
+	"_html_template_nospaceescaper":   "attr (unquoted)",
+	"_html_template_htmlnamefilter":   "attr name",
+	"_html_template_urlfilter":        "url",
+	"_html_template_urlescaper":       "url",
+	"_html_template_urlnormalizer":    "url",
+	"_html_template_srcsetescaper":    "srcset",
+	"_html_template_jsvalescaper":     "js",
+	"_html_template_jsstrescaper":     "js string",
+	"_html_template_jsregexpescaper":  "js regexp",
+	"_html_template_jstmpllitescaper": "js template literal",
+	"_html_template_cssescaper":       "css",
+	"_html_template_cssvaluefilter":   "css",
+}
+
+// predefinedEscapers are the text/template builtins html/template treats as escapers
+var predefinedEscapers = map[string]bool{"html": true, "urlquery": true, "js": true}
+
+// safeContexts lists the contexts in which each html/template safe type is
+// emitted verbatim. Anywhere else its content gets escaped as plain text.
+var safeContexts = map[reflect.Type][]string{
+	reflect.TypeOf(template.HTML("")):     {"html"},
+	reflect.TypeOf(template.HTMLAttr("")): {"attr name"},
+	reflect.TypeOf(template.JS("")):       {"js"},
+	reflect.TypeOf(template.JSStr("")):    {"js string"},
+	reflect.TypeOf(template.CSS("")):      {"css"},
+	reflect.TypeOf(template.URL("")):      {"url", "attr"},
+	reflect.TypeOf(template.Srcset("")):   {"srcset"},
+}
+
+var errEscapeOnly = errors.New("tmplx: escape analysis only")
+
+// EscapeReport reports the escaping context of every action in the resolved
+// template. Because tmplx stringifies and reparses template content while
+// resolving inheritance, the context an action ends up in can differ from what
+// its author intended; entries with a Warning point at output that is likely
+// double-escaped (for example a func returning template.HTML rendered inside
+// an attribute).
+func (e *TemplateEngine) EscapeReport(name string) ([]EscapeInfo, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	tmpl, err := e.getTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	return e.escapeReport(name, tmpl)
+}
+
+func (e *TemplateEngine) escapeReport(name string, tmpl *template.Template) ([]EscapeInfo, error) {
+
+	escaped, err := e.escapedCopy(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("error escaping template %s: %v", name, err)
+	}
+
+	var report []EscapeInfo
+	templates := escaped.Templates()
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name() < templates[j].Name() })
+
+	for _, t := range templates {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		walkActions(t.Tree.Root, func(action *parse.ActionNode) {
+			if isMarkAction(action) {
+				return
+			}
+			info := e.escapeInfo(t, action)
+			if info != nil {
+				report = append(report, *info)
+			}
+		})
+	}
+
+	return report, nil
+}
+
+// escapedCopy copies the trees of tmpl into a fresh template and runs
+// html/template's escaper over the copy without executing any user funcs.
+func (e *TemplateEngine) escapedCopy(tmpl *template.Template) (*template.Template, error) {
+	// Stub out every func so executing the copy can't have side effects. The
+	// stubs fail immediately, which stops execution right after escaping.
+	stubs := make(template.FuncMap, len(e.funcMap))
+	for name := range e.funcMap {
+		stubs[name] = func(...interface{}) (string, error) { return "", errEscapeOnly }
+	}
+
+	cp := template.New(tmpl.Name()).Funcs(stubs)
+	root := cp
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		added, err := cp.AddParseTree(t.Name(), t.Tree.Copy())
+		if err != nil {
+			return nil, err
+		}
+		if t.Name() == tmpl.Name() {
+			// AddParseTree hands back a new template for the root name
+			root = added
+		}
+	}
+
+	// Escaping happens before the first write, so a writer that refuses all
+	// output stops execution as early as possible.
+	err := root.Execute(failingWriter{}, nil)
+	var tmplErr *template.Error
+	if errors.As(err, &tmplErr) {
+		return nil, err
+	}
+	return root, nil
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errEscapeOnly }
+
+func (e *TemplateEngine) escapeInfo(t *template.Template, action *parse.ActionNode) *EscapeInfo {
+	var authored, escapers []string
+	for _, cmd := range action.Pipe.Cmds {
+		if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok && len(cmd.Args) == 1 {
+			if _, isEscaper := escaperContexts[ident.Ident]; isEscaper || predefinedEscapers[ident.Ident] {
+				escapers = append(escapers, ident.Ident)
+				if predefinedEscapers[ident.Ident] {
+					authored = append(authored, cmd.String())
+				}
+				continue
+			}
+		}
+		authored = append(authored, cmd.String())
+	}
+
+	if len(escapers) == 0 {
+		return nil
+	}
+
+	location, _ := t.Tree.ErrorContext(action)
+	info := &EscapeInfo{
+		Template: t.Name(),
+		Location: location,
+		Action:   "{{" + strings.Join(authored, " | ") + "}}",
+		Escapers: escapers,
+	}
+
+	var userEscaper string
+	for _, esc := range escapers {
+		if ctx, ok := escaperContexts[esc]; ok && info.Context == "" {
+			info.Context = ctx
+		}
+		if predefinedEscapers[esc] {
+			userEscaper = esc
+		}
+	}
+
+	switch {
+	case userEscaper != "" && info.Context != "":
+		info.Warning = fmt.Sprintf("output of %q is escaped again for %s context", userEscaper, info.Context)
+	default:
+		if typ := e.actionResultType(action); typ != nil {
+			if allowed, ok := safeContexts[typ]; ok && !containsString(allowed, info.Context) {
+				info.Warning = fmt.Sprintf("%s value is escaped as plain text in %s context", typ, info.Context)
+			}
+		}
+	}
+
+	return info
+}
+
+// actionResultType returns the static result type of an action whose last
+// command calls a registered func, or nil if it can't be determined.
+func (e *TemplateEngine) actionResultType(action *parse.ActionNode) reflect.Type {
+	var typ reflect.Type
+	for _, cmd := range action.Pipe.Cmds {
+		ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+		if ok {
+			if _, isEscaper := escaperContexts[ident.Ident]; isEscaper {
+				break
+			}
+		}
+		typ = nil
+		if !ok {
+			continue
+		}
+		fn, ok := e.funcMap[ident.Ident]
+		if !ok {
+			continue
+		}
+		ft := reflect.TypeOf(fn)
+		if ft.Kind() == reflect.Func && ft.NumOut() > 0 {
+			typ = ft.Out(0)
+		}
+	}
+	return typ
+}
+
+// walkActions calls fn for every action node below node.
+func walkActions(node parse.Node, fn func(*parse.ActionNode)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			walkActions(c, fn)
+		}
+	case *parse.ActionNode:
+		fn(n)
+	case *parse.IfNode:
+		walkActions(n.List, fn)
+		walkActions(n.ElseList, fn)
+	case *parse.RangeNode:
+		walkActions(n.List, fn)
+		walkActions(n.ElseList, fn)
+	case *parse.WithNode:
+		walkActions(n.List, fn)
+		walkActions(n.ElseList, fn)
+	}
+}
+
+// logEscapeWarnings reports likely double-escaping in every loaded template.
+// It runs after loading when DevMode is enabled.
+func (e *TemplateEngine) logEscapeWarnings() {
+	for name, tmpl := range e.cache {
+		report, err := e.escapeReport(name, tmpl)
+		if err != nil {
+			e.logf(LogLoad, LogWarn, "[TMPLX] Escape analysis failed for %s: %v", name, err)
+			continue
+		}
+		for _, info := range report {
+			if info.Warning != "" {
+				e.logf(LogLoad, LogWarn, "[TMPLX] Warning: %s %s in block %q: %s", info.Location, info.Action, info.Template, info.Warning)
+			}
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package tmplx
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestEscapeReport(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{
+			Data: []byte(`<html><head><title>{{block "title" .}}Site{{end}}</title></head>
+<body>{{block "content" .}}{{end}}</body></html>`),
+		},
+		"pages/home.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/base.html"}}
+{{define "content"}}<a href="{{.URL}}" title="{{bold .Name}}">{{bold .Name}}</a>{{end}}`),
+		},
+	}
+
+	logger := &recordingLogger{}
+	engine := New(Options{
+		FS: fsys,
+		FuncMap: template.FuncMap{
+			"bold": func(s string) template.HTML { return template.HTML("<b>" + s + "</b>") },
+		},
+		Logger:  logger,
+		DevMode: true,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := engine.EscapeReport("pages/home.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contexts := make(map[string]EscapeInfo)
+	for _, info := range report {
+		contexts[info.Action] = info
+	}
+
+	if got := contexts["{{.URL}}"].Context; got != "url" {
+		t.Errorf("Expected url context for {{.URL}}, got %q", got)
+	}
+
+	var htmlWarn, attrWarn bool
+	for _, info := range report {
+		if info.Action != "{{bold .Name}}" {
+			continue
+		}
+		switch info.Context {
+		case "html":
+			htmlWarn = info.Warning != ""
+		case "attr":
+			attrWarn = info.Warning != ""
+		}
+	}
+	if htmlWarn {
+		t.Error("Expected no warning for template.HTML in html context")
+	}
+	if !attrWarn {
+		t.Error("Expected a warning for template.HTML in attr context")
+	}
+
+	var logged bool
+	for _, line := range logger.lines {
+		if strings.Contains(line, "escaped as plain text in attr context") {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Errorf("Expected DevMode to log the double-escape warning, got %q", logger.lines)
+	}
+
+	// The report must not interfere with rendering the real template
+	result, err := engine.Render("pages/home.html", H{"URL": "/x", "Name": "Go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{`<a href="/x" title="Go"><b>Go</b></a>`}, result)
+}
@@ -0,0 +1,140 @@
+package tmplx
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"unicode"
+)
+
+// maxDiffCells bounds the word table diffHTML compares; texts with more
+// changed words than that are shown as one deletion and one insertion
+const maxDiffCells = 4 << 20
+
+// diffHTML shows the edit from one text to another word by word, removed
+// words in <del> and added words in <ins>, for revision histories and audit
+// logs:
+//
+//	{{diffHTML .Before.Title .After.Title}}
+//
+// Both texts are escaped; values other than strings are formatted with
+// fmt.Sprint and nil is empty.
+func diffHTML(before, after interface{}) template.HTML {
+	a, b := diffWords(diffText(before)), diffWords(diffText(after))
+
+	// the common prefix and suffix are kept out of the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var out strings.Builder
+	for _, w := range a[:prefix] {
+		out.WriteString(template.HTMLEscapeString(w))
+	}
+	writeDiff(&out, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, w := range a[len(a)-suffix:] {
+		out.WriteString(template.HTMLEscapeString(w))
+	}
+	return template.HTML(out.String())
+}
+
+func diffText(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// diffWords splits text into words and the runs of space between them
+func diffWords(s string) []string {
+	var words []string
+	start, space := 0, false
+	for i, r := range s {
+		if i > start && unicode.IsSpace(r) != space {
+			words = append(words, s[start:i])
+			start = i
+		}
+		space = unicode.IsSpace(r)
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// writeDiff writes the edit from a to b using their longest common
+// subsequence, grouping neighbouring removals and additions
+func writeDiff(out *strings.Builder, a, b []string) {
+	if len(a)*len(b) > maxDiffCells {
+		writeChange(out, "del", a)
+		writeChange(out, "ins", b)
+		return
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var del, ins []string
+	flush := func() {
+		writeChange(out, "del", del)
+		writeChange(out, "ins", ins)
+		del, ins = del[:0], ins[:0]
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			// a space between two changes joins them
+			changing := len(del) > 0 || len(ins) > 0
+			more := i+1 < len(a) || j+1 < len(b)
+			if changing && more && strings.TrimSpace(a[i]) == "" && !(i+1 < len(a) && j+1 < len(b) && a[i+1] == b[j+1]) {
+				del, ins = append(del, a[i]), append(ins, b[j])
+				i, j = i+1, j+1
+				continue
+			}
+			flush()
+			out.WriteString(template.HTMLEscapeString(a[i]))
+			i, j = i+1, j+1
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			ins = append(ins, b[j])
+			j++
+		default:
+			del = append(del, a[i])
+			i++
+		}
+	}
+	flush()
+}
+
+// writeChange writes words removed or added in a del or ins element
+func writeChange(out *strings.Builder, tag string, words []string) {
+	if len(words) == 0 {
+		return
+	}
+	out.WriteString("<" + tag + ">")
+	for _, w := range words {
+		out.WriteString(template.HTMLEscapeString(w))
+	}
+	out.WriteString("</" + tag + ">")
+}
@@ -0,0 +1,35 @@
+package tmplx
+
+import "testing"
+
+func TestDiffHTML(t *testing.T) {
+	tests := []struct {
+		before, after interface{}
+		expected      string
+	}{
+		{"the quick brown fox", "the quick brown fox", "the quick brown fox"},
+		{"the quick brown fox", "the slow brown fox", "the <del>quick</del><ins>slow</ins> brown fox"},
+		{"the quick brown fox", "the quick red fox jumps", "the quick <del>brown</del><ins>red</ins> fox<ins> jumps</ins>"},
+		{"one two three four", "one five six four", "one <del>two three</del><ins>five six</ins> four"},
+		{"a <b> c", "a <i> c", "a <del>&lt;b&gt;</del><ins>&lt;i&gt;</ins> c"},
+		{nil, "new", "<ins>new</ins>"},
+		{3, 4, "<del>3</del><ins>4</ins>"},
+	}
+	for _, tt := range tests {
+		if got := string(diffHTML(tt.before, tt.after)); got != tt.expected {
+			t.Errorf("diffHTML(%q, %q) = %s, expected %s", tt.before, tt.after, got, tt.expected)
+		}
+	}
+
+	engine := New(Options{Loader: MapLoader{"audit.html": `<p>{{diffHTML .Before .After}}</p>`}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("audit.html", H{"Before": "Price: $5", "After": "Price: $7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<p>Price: <del>$5</del><ins>$7</ins></p>`; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
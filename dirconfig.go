@@ -0,0 +1,285 @@
+package tmplx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// dirConfigFile is the optional file in a template directory declaring
+// defaults for every template in its subtree:
+//
+//	# templates/pages/admin/_tmplx.yaml
+//	layout: layouts/admin/base.html
+//	includes:
+//	  - partials/admin/macros.html
+//	extensions: [.tmpl]
+//	skip: [drafts, "*.wip.html"]
+//
+// Names are template names, like those given to extend and include; skip
+// patterns are relative to the directory, and skip: true skips all of it.
+const dirConfigFile = "_tmplx.yaml"
+
+// dirConfig holds the defaults of a directory, merged with those of the
+// directories above it
+type dirConfig struct {
+	// layout is extended by templates without an extend directive. The
+	// nearest directory setting one wins.
+	layout string
+
+	// includes are included by every template, e.g. files of shared defines
+	includes []string
+
+	// extensions are template extensions in addition to Options.Extensions
+	extensions []string
+
+	// skip are patterns of templates not to load
+	skip []skipPattern
+}
+
+// skipPattern is a skip pattern and the directory it is relative to
+type skipPattern struct {
+	dir, pattern string
+}
+
+// dirConfigFor returns the merged config of the directories holding the
+// named template, from the source root down. While a source loads, the
+// config of each directory is read once and shared by its templates.
+func (e *TemplateEngine) dirConfigFor(s Source, name string) (*dirConfig, error) {
+	return e.dirConfigOf(s, path.Dir(name))
+}
+
+// dirConfigOf returns the merged config of dir, a directory of s
+func (e *TemplateEngine) dirConfigOf(s Source, dir string) (*dirConfig, error) {
+	e.resolveMu.Lock()
+	cached, ok := e.dirConfigs[dir]
+	e.resolveMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	cfg := &dirConfig{}
+	if dir != "." {
+		parent, err := e.dirConfigOf(s, path.Dir(dir))
+		if err != nil {
+			return nil, err
+		}
+		// copy the parent's lists, so appending doesn't write to them
+		cfg.layout = parent.layout
+		cfg.includes = append([]string(nil), parent.includes...)
+		cfg.extensions = append([]string(nil), parent.extensions...)
+		cfg.skip = append([]skipPattern(nil), parent.skip...)
+	}
+
+	rel := dir
+	if dir == "." {
+		rel = ""
+	}
+	data, err := fs.ReadFile(s.FS, path.Join(s.Dir, rel, dirConfigFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := cfg.parse(path.Join(rel, dirConfigFile), rel, data); err != nil {
+			return nil, err
+		}
+	}
+
+	e.resolveMu.Lock()
+	if e.dirConfigs != nil {
+		e.dirConfigs[dir] = cfg
+	}
+	e.resolveMu.Unlock()
+	return cfg, nil
+}
+
+// parse merges a config file of dir into cfg. It reads the subset of YAML
+// the file needs: keys with a scalar, a [flow, list] or a block list.
+func (cfg *dirConfig) parse(file, dir string, data []byte) error {
+	// add appends an item of the block list being read, if any
+	var add func(string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := stripYAMLComment(scanner.Text())
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if item, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok && line[0] == ' ' {
+			if add == nil {
+				return fmt.Errorf("%s:%d: list item outside a list", file, n)
+			}
+			v, err := yamlScalar(item)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %v", file, n, err)
+			}
+			add(v)
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || key != strings.TrimSpace(key) {
+			return fmt.Errorf("%s:%d: expected key: value, got %q", file, n, line)
+		}
+		values, err := yamlValues(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", file, n, err)
+		}
+
+		switch key {
+		case "layout":
+			if len(values) > 1 {
+				return fmt.Errorf("%s:%d: layout takes a single template", file, n)
+			}
+			if len(values) == 1 {
+				cfg.layout = values[0]
+			}
+			add = nil
+			continue
+		case "includes":
+			add = func(v string) { cfg.includes = append(cfg.includes, v) }
+		case "extensions":
+			add = func(v string) { cfg.extensions = append(cfg.extensions, v) }
+		case "skip":
+			add = func(v string) { cfg.skip = append(cfg.skip, skipPattern{dir, v}) }
+			if len(values) == 1 && (values[0] == "true" || values[0] == "false") {
+				if values[0] == "true" {
+					add("*")
+				}
+				add = nil
+				continue
+			}
+		default:
+			return fmt.Errorf("%s:%d: unknown key %q", file, n, key)
+		}
+		for _, v := range values {
+			add(v)
+		}
+	}
+	return scanner.Err()
+}
+
+// yamlValues returns the values of a scalar or a [flow, list]; none for an
+// empty value, which a block list may follow
+func yamlValues(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(value, "[") {
+		v, err := yamlScalar(value)
+		return []string{v}, err
+	}
+	if !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("unterminated list %s", value)
+	}
+	var values []string
+	for _, item := range strings.Split(value[1:len(value)-1], ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		v, err := yamlScalar(item)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// stripYAMLComment removes a # comment from a line, outside quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return strings.TrimRight(line, " \t")
+}
+
+// yamlScalar returns the value of a plain or quoted scalar
+func yamlScalar(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	return s, nil
+}
+
+// skipped reports whether the named template matches a skip pattern.
+// Patterns without a slash match any file or directory name below their
+// directory, like "*.wip.html"; others match a path from it, like
+// "blog/drafts".
+func (cfg *dirConfig) skipped(name string) bool {
+	for _, skip := range cfg.skip {
+		rel := name
+		if skip.dir != "" {
+			var ok bool
+			if rel, ok = strings.CutPrefix(name, skip.dir+"/"); !ok {
+				continue
+			}
+		}
+		if !strings.Contains(skip.pattern, "/") {
+			for _, elem := range strings.Split(rel, "/") {
+				if ok, _ := path.Match(skip.pattern, elem); ok {
+					return true
+				}
+			}
+			continue
+		}
+		for p := rel; p != "."; p = path.Dir(p) {
+			if ok, _ := path.Match(strings.Trim(skip.pattern, "/"), p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadable reports whether the file at file in s is a template to load:
+// it has a template extension, including those of its directory configs,
+// and isn't skipped by them
+func (e *TemplateEngine) loadable(s Source, file string) (bool, error) {
+	if path.Base(file) == dirConfigFile || e.envVariant(file) {
+		return false, nil
+	}
+	name, err := filepath.Rel(s.Dir, file)
+	if err != nil {
+		return false, err
+	}
+	cfg, err := e.dirConfigFor(s, filepath.ToSlash(name))
+	if err != nil {
+		return false, err
+	}
+	if cfg.skipped(filepath.ToSlash(name)) {
+		return false, nil
+	}
+	if e.isTemplateFile(file) {
+		return true, nil
+	}
+	for _, ext := range cfg.extensions {
+		if strings.HasSuffix(file, ext) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
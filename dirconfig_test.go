@@ -0,0 +1,131 @@
+package tmplx
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDirConfig(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{
+		"_tmplx.yaml": `# defaults for every template
+layout: layouts/base.html
+skip: ["*.wip.html"]
+`,
+		"layouts/_tmplx.yaml":  `layout: ""`,
+		"layouts/base.html":    `<main>{{block "content" .}}{{end}}</main>`,
+		"layouts/admin.html":   `<div class="admin">{{block "content" .}}{{end}}</div>`,
+		"partials/macros.html": `{{define "badge"}}<b>{{.}}</b>{{end}}`,
+		"pages/home.html":      `{{define "content"}}home{{end}}`,
+		"pages/next.wip.html":  `{{define "content"}}{{.Missing.Field}}{{end}}`,
+		"pages/admin/_tmplx.yaml": `
+layout: layouts/admin.html   # nearer configs win
+includes:
+  - partials/macros.html
+extensions: [.tmpl]
+skip:
+  - drafts
+`,
+		"pages/admin/users.tmpl":        `{{define "content"}}{{template "badge" "users"}}{{end}}`,
+		"pages/admin/own.html":          `{{extend "layouts/base.html"}}{{define "content"}}{{template "badge" "own"}}{{end}}`,
+		"pages/admin/drafts/draft.html": `{{define "content"}}draft{{end}}`,
+	}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"pages/home.html":        `<main>home</main>`,
+		"pages/admin/users.tmpl": `<div class="admin"><b>users</b></div>`,
+		"pages/admin/own.html":   `<main><b>own</b></main>`,
+	}
+	for name, expected := range tests {
+		result, err := engine.Render(name, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, result)
+		}
+	}
+
+	for _, name := range []string{"pages/next.wip.html", "pages/admin/drafts/draft.html", "_tmplx.yaml"} {
+		if _, err := engine.GetTemplate(name); err == nil {
+			t.Errorf("Expected %s to be skipped", name)
+		}
+	}
+}
+
+// countingFS counts the reads of each config file
+type countingFS struct {
+	memFS
+	mu    sync.Mutex
+	reads map[string]int
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	if path.Base(name) == dirConfigFile {
+		c.mu.Lock()
+		c.reads[name]++
+		c.mu.Unlock()
+	}
+	return c.memFS.Open(name)
+}
+
+func (c *countingFS) ReadFile(name string) ([]byte, error) {
+	f, err := c.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return c.memFS.ReadFile(name)
+}
+
+func TestDirConfigReadOncePerLoad(t *testing.T) {
+	fsys := &countingFS{memFS: memFS{
+		"_tmplx.yaml":              []byte(`skip: ["*.wip.html"]`),
+		"pages/_tmplx.yaml":        []byte(`includes: [partials/macros.html]`),
+		"pages/a.html":             []byte(`a{{template "badge" .}}`),
+		"pages/b.html":             []byte(`b{{template "badge" .}}`),
+		"pages/c.html":             []byte(`c{{template "badge" .}}`),
+		"pages/deep/d.html":        []byte(`d{{template "badge" .}}`),
+		"partials/macros.html":     []byte(`{{define "badge"}}!{{end}}`),
+		"pages/deep/skip.wip.html": []byte(`{{.Missing.Field}}`),
+	}, reads: map[string]int{}}
+	engine := New(Options{Sources: []Source{{FS: fsys, Dir: "."}}, LoadConcurrency: 4})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for file, n := range fsys.reads {
+		if n != 1 {
+			t.Errorf("Expected %s to be read once, got %d", file, n)
+		}
+	}
+	if result, err := engine.Render("pages/deep/d.html", nil); err != nil || result != "d!" {
+		t.Errorf("Expected d!, got %q, %v", result, err)
+	}
+
+	if err := engine.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if n := fsys.reads["pages/_tmplx.yaml"]; n != 2 {
+		t.Errorf("Expected a reload to read the config again, got %d reads", n)
+	}
+}
+
+func TestDirConfigErrors(t *testing.T) {
+	tests := map[string]string{
+		"layouts: base.html":         `_tmplx.yaml:1: unknown key "layouts"`,
+		"layout: [a.html, b.html]":   "_tmplx.yaml:1: layout takes a single template",
+		"skip: [drafts":              "_tmplx.yaml:1: unterminated list",
+		"includes: a.html\n- b.html": `_tmplx.yaml:2: expected key: value`,
+	}
+	for config, message := range tests {
+		engine := New(Options{Loader: MapLoader{"_tmplx.yaml": config, "page.html": "page"}})
+		if err := engine.Load(); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%q: expected an error containing %q, got %v", config, message, err)
+		}
+	}
+}
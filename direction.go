@@ -0,0 +1,121 @@
+package tmplx
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// rtlLanguages are the languages written right to left
+var rtlLanguages = map[string]bool{
+	"ar": true, "arc": true, "ckb": true, "dv": true, "fa": true, "he": true, "iw": true,
+	"ks": true, "ps": true, "sd": true, "syr": true, "ug": true, "ur": true, "yi": true,
+}
+
+// rtlScripts are the scripts written right to left, for tags such as
+// "az-Arab" naming a script their language isn't usually written in
+var rtlScripts = map[string]bool{
+	"adlm": true, "arab": true, "hebr": true, "nkoo": true, "rohg": true, "syrc": true, "thaa": true,
+}
+
+// IsRTL reports whether a locale is written right to left
+func IsRTL(locale string) bool {
+	parts := strings.FieldsFunc(strings.ToLower(locale), func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) == 0 {
+		return false
+	}
+	for _, sub := range parts[1:] {
+		if len(sub) == 4 {
+			return rtlScripts[sub]
+		}
+	}
+	return rtlLanguages[parts[0]]
+}
+
+// direction returns the value of the dir attribute for a locale
+func direction(locale string) string {
+	if IsRTL(locale) {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// htmlLang returns the value of the lang attribute for a locale, a BCP 47
+// tag such as "pt-BR" for "pt_BR"
+func htmlLang(locale string) string {
+	return strings.ReplaceAll(locale, "_", "-")
+}
+
+// setupDirection registers the dir, isRTL, htmlLang and htmlDir funcs and,
+// with Assets, the assetDir func, unless the user defined their own. All
+// follow the render locale.
+func (e *TemplateEngine) setupDirection(opts Options) {
+	if _, userDefined := opts.FuncMap["htmlLang"]; !userDefined {
+		e.registerRenderFunc("htmlLang", func(st *renderState) any {
+			return func() string { return htmlLang(st.locale) }
+		})
+	}
+	if _, userDefined := opts.FuncMap["htmlDir"]; !userDefined {
+		e.registerRenderFunc("htmlDir", func(st *renderState) any {
+			return func() string { return direction(st.locale) }
+		})
+	}
+	if _, userDefined := opts.FuncMap["dir"]; !userDefined {
+		e.registerRenderFunc("dir", func(st *renderState) any {
+			return func() string { return direction(st.locale) }
+		})
+	}
+	if _, userDefined := opts.FuncMap["isRTL"]; !userDefined {
+		e.registerRenderFunc("isRTL", func(st *renderState) any {
+			return func() bool { return IsRTL(st.locale) }
+		})
+	}
+	if _, userDefined := opts.FuncMap["assetDir"]; !userDefined && e.assets != nil {
+		e.registerRenderFunc("assetDir", func(st *renderState) any {
+			return func(name string) string { return e.assets.DirURL(name, IsRTL(st.locale)) }
+		})
+	}
+}
+
+// DirURL returns the URL of the named asset for a text direction: for right
+// to left text, that of its .rtl variant, "main.css" becoming "main.rtl.css".
+// With a manifest listing the asset but no variant, the asset itself is used.
+func (a *Assets) DirURL(name string, rtl bool) string {
+	if !rtl {
+		return a.URL(name)
+	}
+	ext := path.Ext(name)
+	variant := strings.TrimSuffix(name, ext) + ".rtl" + ext
+	_, hasVariant := a.Manifest[variant]
+	if _, hasAsset := a.Manifest[name]; hasAsset && !hasVariant {
+		return a.URL(name)
+	}
+	return a.URL(variant)
+}
+
+var (
+	// htmlStartTag matches the start tag of an html element
+	htmlStartTag = regexp.MustCompile(`(?i)<html(\s[^>]*)?>`)
+
+	// langAttr and dirAttr match the attributes in a start tag
+	langAttr = regexp.MustCompile(`(?i)\slang(\s*=|[\s/>])`)
+	dirAttr  = regexp.MustCompile(`(?i)\sdir(\s*=|[\s/>])`)
+)
+
+// langAttrs adds lang and dir attributes following the render locale to the
+// <html> tags of an HTML template that lack them, with Options.AutoLangDir
+func (e *TemplateEngine) langAttrs(file, content string) string {
+	if !e.autoLangDir || !strings.HasPrefix(e.contentType(file), "text/html") {
+		return content
+	}
+	return htmlStartTag.ReplaceAllStringFunc(content, func(tag string) string {
+		var attrs string
+		if !langAttr.MatchString(tag) {
+			attrs += ` lang="{{htmlLang}}"`
+		}
+		if !dirAttr.MatchString(tag) {
+			attrs += ` dir="{{htmlDir}}"`
+		}
+		return tag[:len("<html")] + attrs + tag[len("<html"):]
+	})
+}
@@ -0,0 +1,76 @@
+package tmplx
+
+import "testing"
+
+func TestIsRTL(t *testing.T) {
+	tests := map[string]bool{
+		"ar": true, "he-IL": true, "fa_IR": true, "ur": true,
+		"en": false, "de-AT": false, "": false,
+		"az-Arab": true, "uz-Arab-AF": true, "ku-Latn": false,
+	}
+	for locale, expected := range tests {
+		if got := IsRTL(locale); got != expected {
+			t.Errorf("IsRTL(%q) = %v, expected %v", locale, got, expected)
+		}
+	}
+}
+
+func TestDirectionFuncs(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{"layout.html": `<html lang="{{locale}}" dir="{{dir}}">` +
+			`<link rel="stylesheet" href="{{assetDir "main.css"}}"><link rel="stylesheet" href="{{assetDir "print.css"}}">` +
+			`{{if isRTL}}rtl{{end}}</html>`},
+		Locales: []string{"en", "ar"},
+		Assets: &Assets{Prefix: "/static/", Manifest: map[string]string{
+			"main.css":     "main.1a2b.css",
+			"main.rtl.css": "main.rtl.3c4d.css",
+			"print.css":    "print.5e6f.css",
+		}},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"en": `<html lang="en" dir="ltr"><link rel="stylesheet" href="/static/main.1a2b.css"><link rel="stylesheet" href="/static/print.5e6f.css"></html>`,
+		"ar": `<html lang="ar" dir="rtl"><link rel="stylesheet" href="/static/main.rtl.3c4d.css"><link rel="stylesheet" href="/static/print.5e6f.css">rtl</html>`,
+	}
+	for locale, expected := range tests {
+		result, err := engine.Render("layout.html", nil, WithLocale(locale))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %s, got %s", locale, expected, result)
+		}
+	}
+}
+
+func TestAutoLangDir(t *testing.T) {
+	loader := MapLoader{
+		"layouts/base.html":  `<!DOCTYPE html><html class="no-js"><body>{{block "content" .}}{{end}}</body></html>`,
+		"layouts/fixed.html": `<html lang="en">{{htmlLang}} {{htmlDir}}</html>`,
+		"pages/home.html":    `{{extend "layouts/base.html"}}{{define "content"}}hi{{end}}`,
+	}
+	engine := New(Options{Loader: loader, Locales: []string{"en", "ar_EG"}, AutoLangDir: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name, locale, expected string
+	}{
+		{"pages/home.html", "", `<!DOCTYPE html><html lang="en" dir="ltr" class="no-js"><body>hi</body></html>`},
+		{"pages/home.html", "ar_EG", `<!DOCTYPE html><html lang="ar-EG" dir="rtl" class="no-js"><body>hi</body></html>`},
+		{"layouts/fixed.html", "ar_EG", `<html dir="rtl" lang="en">ar-EG rtl</html>`},
+	}
+	for _, c := range cases {
+		result, err := engine.Render(c.name, nil, WithLocale(c.locale))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != c.expected {
+			t.Errorf("%s in %q: expected %q, got %q", c.name, c.locale, c.expected, result)
+		}
+	}
+}
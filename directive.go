@@ -0,0 +1,206 @@
+package tmplx
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// DirectiveContext is what a Directive can see of the template it expands in
+type DirectiveContext struct {
+	// File is the template file containing the directive
+	File string
+
+	// Read reads another file from the same source, e.g. an icon or a
+	// markdown document named by the directive
+	Read func(name string) ([]byte, error)
+}
+
+// Directive expands a load-time directive such as {{svg "icons/star"}}.
+// It receives the directive's arguments, which must be constants, and
+// returns template source that replaces the action before the template is
+// parsed, the way include inlines a file. Return Verbatim(s) to insert text
+// that may itself contain delimiters.
+type Directive func(ctx DirectiveContext, args []string) (string, error)
+
+// maxDirectiveDepth bounds directives expanding to further directives
+const maxDirectiveDepth = 10
+
+// RegisterDirective adds a load-time directive, typically on behalf of a
+// library. It must be called before Load.
+func (e *TemplateEngine) RegisterDirective(name string, d Directive) error {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.loaded {
+		return fmt.Errorf("directive %s must be registered before Load", name)
+	}
+	if isReservedFunc(name) {
+		return fmt.Errorf("directive name %s is reserved", name)
+	}
+	if !isIdentifier(name) {
+		return fmt.Errorf("directive name %q is not a valid identifier", name)
+	}
+	if e.directives == nil {
+		e.directives = make(map[string]Directive)
+	}
+	e.directives[name] = d
+	e.addFunc(name, func(...interface{}) (string, error) {
+		return "", fmt.Errorf("%s can only be called during template parsing", name)
+	})
+	return nil
+}
+
+// Verbatim returns template source that renders s exactly as is, even if it
+// contains {{ or }}
+func Verbatim(s string) string {
+	return strings.ReplaceAll(s, "{{", `{{"{{"}}`)
+}
+
+// expandDirectives replaces every registered directive in content, in any
+// template or block of the file, with its expansion
+func (e *TemplateEngine) expandDirectives(s Source, file, content string) (string, error) {
+	if len(e.directives) == 0 {
+		return content, nil
+	}
+
+	ctx := DirectiveContext{
+		File: file,
+		Read: func(name string) ([]byte, error) {
+			return fs.ReadFile(s.FS, path.Join(s.Dir, name))
+		},
+	}
+
+	for depth := 0; ; depth++ {
+		trees := make(map[string]*parse.Tree)
+		scan := parse.New(file)
+		scan.Mode = parse.SkipFuncCheck
+		if _, err := scan.Parse(content, "", "", trees); err != nil {
+			return "", fmt.Errorf("error scanning directives in %s: %v", file, err)
+		}
+
+		var found []*parse.ActionNode
+		for _, tree := range trees {
+			found = append(found, e.directiveActions(tree.Root)...)
+		}
+		if len(found) == 0 {
+			return content, nil
+		}
+		if depth == maxDirectiveDepth {
+			return "", fmt.Errorf("directives in %s expand more than %d levels deep", file, maxDirectiveDepth)
+		}
+
+		// Replace from the end so earlier offsets stay valid
+		sort.Slice(found, func(i, j int) bool { return found[i].Pos > found[j].Pos })
+		for _, action := range found {
+			start, end, err := actionSpan(content, action)
+			if err != nil {
+				return "", fmt.Errorf("%s: %v", file, err)
+			}
+			cmd := action.Pipe.Cmds[0]
+			name := cmd.Args[0].(*parse.IdentifierNode).Ident
+
+			args, err := directiveArgs(name, cmd.Args[1:])
+			if err != nil {
+				return "", fmt.Errorf("%s: %v", file, err)
+			}
+			expanded, err := e.directives[name](ctx, args)
+			if err != nil {
+				return "", fmt.Errorf("%s: error expanding %s: %v", file, name, err)
+			}
+			content = content[:start] + expanded + content[end:]
+		}
+	}
+}
+
+// directiveActions returns the directive actions below node
+func (e *TemplateEngine) directiveActions(node parse.Node) []*parse.ActionNode {
+	var found []*parse.ActionNode
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			if len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) != 1 {
+				return
+			}
+			if ident, ok := n.Pipe.Cmds[0].Args[0].(*parse.IdentifierNode); ok {
+				if _, ok := e.directives[ident.Ident]; ok {
+					found = append(found, n)
+				}
+			}
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		}
+	}
+	walk(node)
+	return found
+}
+
+// actionSpan returns the byte range of an action in content, delimiters and
+// trim markers included
+func actionSpan(content string, action *parse.ActionNode) (int, int, error) {
+	start := strings.LastIndex(content[:action.Pos], "{{")
+	if start < 0 {
+		return 0, 0, fmt.Errorf("can't locate action %s", action)
+	}
+
+	// Skip past the last argument so a "}}" inside a string isn't mistaken
+	// for the closing delimiter
+	args := action.Pipe.Cmds[0].Args
+	last := args[len(args)-1]
+	from := int(last.Position())
+	if str, ok := last.(*parse.StringNode); ok {
+		from += len(str.Quoted)
+	}
+	end := strings.Index(content[from:], "}}")
+	if end < 0 {
+		return 0, 0, fmt.Errorf("can't locate end of action %s", action)
+	}
+	end += from
+
+	// Trim markers apply to the text around the directive, as they would
+	// have to the action
+	if strings.HasPrefix(content[start:], "{{- ") {
+		start = len(strings.TrimRight(content[:start], " \t\r\n"))
+	}
+	if strings.HasSuffix(content[:end], " -") {
+		end = len(content) - len(strings.TrimLeft(content[end+len("}}"):], " \t\r\n")) - len("}}")
+	}
+	return start, end + len("}}"), nil
+}
+
+func directiveArgs(name string, nodes []parse.Node) ([]string, error) {
+	args := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *parse.StringNode:
+			args = append(args, n.Text)
+		case *parse.NumberNode:
+			args = append(args, n.Text)
+		case *parse.BoolNode:
+			args = append(args, n.String())
+		default:
+			return nil, fmt.Errorf("directive %s takes constant arguments, got %s", name, node)
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,67 @@
+package tmplx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func svgDirective(ctx DirectiveContext, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("svg takes an icon name")
+	}
+	content, err := ctx.Read("icons/" + args[0] + ".svg")
+	if err != nil {
+		return "", err
+	}
+	return Verbatim(strings.TrimSpace(string(content))), nil
+}
+
+func TestDirectives(t *testing.T) {
+	fsys := fstest.MapFS{
+		"icons/star.svg":    &fstest.MapFile{Data: []byte("<svg>*{{</svg>\n")},
+		"layouts/base.html": &fstest.MapFile{Data: []byte(`<body>{{svg "star"}}{{block "content" .}}{{end}}{{include "partials/nav.html" .}}</body>`)},
+		"partials/nav.html": &fstest.MapFile{Data: []byte(`<nav>{{ svg "star" }}</nav>`)},
+		"pages/home.html": &fstest.MapFile{Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}
+{{- if .Show}} {{- stars 2 -}} {{end}}{{end}}`)},
+	}
+
+	engine := New(Options{
+		FS: fsys,
+		Directives: map[string]Directive{
+			"svg": svgDirective,
+			// expands to another directive
+			"stars": func(ctx DirectiveContext, args []string) (string, error) {
+				return strings.Repeat(`{{svg "star"}}`, len(args[0])+1), nil
+			},
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("pages/home.html", H{"Show": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "<body><svg>*{{</svg><svg>*{{</svg><svg>*{{</svg><nav><svg>*{{</svg></nav></body>"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	bad := New(Options{
+		Loader:     MapLoader{"page.html": `{{svg .Icon}}`},
+		Directives: map[string]Directive{"svg": svgDirective},
+	})
+	if err := bad.Load(); err == nil || !strings.Contains(err.Error(), "constant arguments") {
+		t.Errorf("Expected error for a non-constant argument, got %v", err)
+	}
+
+	if err := engine.RegisterDirective("late", svgDirective); err == nil {
+		t.Error("Expected error registering after Load")
+	}
+	if err := New(Options{}).RegisterDirective("include", svgDirective); err == nil {
+		t.Error("Expected error registering a reserved name")
+	}
+}
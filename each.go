@@ -0,0 +1,55 @@
+package tmplx
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// RenderEach renders the named template once per item, writing the results
+// to w one after another, for list rows or batches of emails. Every item is
+// rendered like a render of its own, with its own limits, context, store
+// and page variables, into the same reused buffer, and the escaped template
+// is reused from one item to the next.
+//
+// Each item's output goes through the post-processors on its own. An item
+// that fails to render writes nothing; the error names its index and the
+// items before it have already been written.
+func (e *TemplateEngine) RenderEach(name string, items []interface{}, w io.Writer, opts ...RenderOption) error {
+	cfg := newRenderConfig(opts)
+	e, err := e.versioned(&cfg)
+	if err != nil {
+		return err
+	}
+	if err := e.maybeRefresh(name); err != nil {
+		return err
+	}
+	if _, ok := e.executor(name); !ok {
+		return notFound(name)
+	}
+
+	e.logf(LogRender, LogDebug, "[TMPLX] Rendering %s for %d items", name, len(items))
+
+	out := bufio.NewWriter(w)
+	var buf bytes.Buffer
+	processors := e.postProcessors(cfg)
+	for i, item := range items {
+		buf.Reset()
+		err := e.execute(&buf, name, item, cfg)
+		var result []byte
+		if err == nil {
+			result, err = postProcess(processors, name, buf.Bytes())
+		}
+		if err != nil {
+			if ferr := out.Flush(); ferr != nil {
+				return ferr
+			}
+			return fmt.Errorf("error rendering template %s for item %d: %w", name, i, err)
+		}
+		if _, err := out.Write(result); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
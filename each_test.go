@@ -0,0 +1,174 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderEach(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{"row.html": `<li>{{.Name}}: {{greet}}</li>`},
+		LocaleFuncs: map[string]func(locale string) any{
+			"greet": func(locale string) any {
+				return func() string {
+					if locale == "fr" {
+						return "bonjour"
+					}
+					return "hello"
+				}
+			},
+		},
+		Locales: []string{"en", "fr"},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	items := []interface{}{H{"Name": "a"}, H{"Name": "<b>"}}
+	var out strings.Builder
+	if err := engine.RenderEach("row.html", items, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "<li>a: hello</li><li>&lt;b&gt;: hello</li>" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+
+	out.Reset()
+	if err := engine.RenderEach("row.html", items, &out, WithLocale("fr")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "<li>a: bonjour</li><li>&lt;b&gt;: bonjour</li>" {
+		t.Errorf("Unexpected localized output %q", out.String())
+	}
+
+	out.Reset()
+	err := engine.RenderEach("row.html", []interface{}{H{"Name": "a"}, 42, H{"Name": "c"}}, &out)
+	if err == nil || !strings.Contains(err.Error(), "item 1") {
+		t.Errorf("Expected error naming item 1, got %v", err)
+	}
+	if out.String() != "<li>a: hello</li>" {
+		t.Errorf("Expected only the rows before the failure, got %q", out.String())
+	}
+
+	if err := engine.RenderEach("missing.html", items, &out); err == nil {
+		t.Error("Expected error for a missing template")
+	}
+}
+
+func TestRenderEachLimits(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{"row.html": `<li>{{.}}</li>`},
+		Limits: Limits{MaxOutput: 12},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	err := engine.RenderEach("row.html", []interface{}{"ok", "too long for the limit", "ok"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "item 1") || !strings.Contains(err.Error(), "output limit of 12 bytes") {
+		t.Errorf("Expected item 1 to exceed the output limit, got %v", err)
+	}
+	if out.String() != "<li>ok</li>" {
+		t.Errorf("Expected only the rows before the failure, got %q", out.String())
+	}
+
+	// the limit applies to each item, not the batch
+	out.Reset()
+	if err := engine.RenderEach("row.html", []interface{}{"a", "b", "c"}, &out); err != nil {
+		t.Errorf("Expected items within the limit to render, got %v", err)
+	}
+}
+
+func TestRenderEachPageVars(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{
+		"row.html": `{{var "title" "Row"}}<li>{{.Page.Title}}: {{.Name}}</li>`,
+	}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := engine.RenderEach("row.html", []interface{}{H{"Name": "a"}, nil}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "<li>Row: a</li><li>Row: </li>" {
+		t.Errorf("Expected each row to see its page variables, got %q", out.String())
+	}
+}
+
+func TestRenderEachOptions(t *testing.T) {
+	files := map[string]string{
+		"layouts/row.html": `{{var "title" "Row"}}<li>{{.Page.Title}}: {{block "cell" .}}{{end}}</li>`,
+		"pages/row.html":   `{{extend "layouts/row.html"}}{{define "cell"}}{{.Name}}{{end}}`,
+	}
+	mapFS := func() fstest.MapFS {
+		fsys := fstest.MapFS{}
+		for name, content := range files {
+			fsys[name] = &fstest.MapFile{Data: []byte(content)}
+		}
+		return fsys
+	}
+	items := []interface{}{H{"Name": "a"}, H{"Name": "b"}}
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"default", Options{Loader: MapLoader(files)}},
+		{"lazy", Options{FS: mapFS(), LazyLoad: true}},
+		{"limits", Options{Loader: MapLoader(files), Limits: Limits{MaxCalls: 100, MaxOutput: 64}}},
+		{"lazy with limits", Options{FS: mapFS(), LazyLoad: true, Limits: Limits{MaxCalls: 100}}},
+		{"no cache", Options{FS: mapFS(), DisableCache: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := New(tt.opts)
+			if err := engine.Load(); err != nil {
+				t.Fatal(err)
+			}
+			var out strings.Builder
+			if err := engine.RenderEach("pages/row.html", items, &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != "<li>Row: a</li><li>Row: b</li>" {
+				t.Errorf("Unexpected output %q", out.String())
+			}
+		})
+	}
+
+	// uncached engines render the templates on disk
+	fsys := mapFS()
+	engine := New(Options{FS: fsys, DisableCache: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	fsys["pages/row.html"] = &fstest.MapFile{Data: []byte(`{{extend "layouts/row.html"}}{{define "cell"}}[{{.Name}}]{{end}}`)}
+	var out strings.Builder
+	if err := engine.RenderEach("pages/row.html", items, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "<li>Row: [a]</li><li>Row: [b]</li>" {
+		t.Errorf("Expected the edited template, got %q", out.String())
+	}
+}
+
+func BenchmarkRenderEach(b *testing.B) {
+	engine := New(Options{Loader: MapLoader{"row.html": `<li>{{.Name}}</li>`}})
+	if err := engine.Load(); err != nil {
+		b.Fatal(err)
+	}
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = H{"Name": "item"}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var out strings.Builder
+		if err := engine.RenderEach("row.html", items, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
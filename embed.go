@@ -0,0 +1,105 @@
+package tmplx
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Option configures the engine built by NewFromEmbed
+type Option func(*Options)
+
+// WithFuncMap adds template funcs, as Options.FuncMap
+func WithFuncMap(funcs map[string]interface{}) Option {
+	return func(o *Options) {
+		if o.FuncMap == nil {
+			o.FuncMap = make(map[string]interface{}, len(funcs))
+		}
+		for name, fn := range funcs {
+			o.FuncMap[name] = fn
+		}
+	}
+}
+
+// WithLogger sets Options.Logger
+func WithLogger(l Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithDevMode sets Options.DevMode
+func WithDevMode(on bool) Option {
+	return func(o *Options) {
+		o.DevMode = on
+	}
+}
+
+// NewFromEmbed creates and loads an engine reading templates from root
+// inside efs. Paths in an embed.FS keep the directory they were embedded
+// from, so with
+//
+//	//go:embed templates
+//	var templateFS embed.FS
+//
+// root is "templates", and templates are then named relative to it, e.g.
+// "pages/home.html". An empty root uses the whole FS. It is an error for
+// root to be missing or to hold no templates, which usually means the root
+// doesn't match the embed directive.
+func NewFromEmbed(efs embed.FS, root string, opts ...Option) (*TemplateEngine, error) {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	root = strings.Trim(path.Clean("/"+root), "/")
+	if root == "" {
+		root = "."
+	}
+
+	if err := checkEmbedRoot(efs, root); err != nil {
+		return nil, err
+	}
+	o.FS, o.Dir = efs, root
+
+	e := New(o)
+	if err := e.Load(); err != nil {
+		return nil, err
+	}
+	if len(e.cache) == 0 {
+		return nil, fmt.Errorf("no templates found under %q in embedded FS%s", root, embedHint(efs, root))
+	}
+	return e, nil
+}
+
+func checkEmbedRoot(efs embed.FS, root string) error {
+	info, err := fs.Stat(efs, root)
+	if err != nil {
+		return fmt.Errorf("template root %q not found in embedded FS%s", root, embedHint(efs, root))
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("template root %q in embedded FS is not a directory", root)
+	}
+	return nil
+}
+
+// embedHint suggests the directories at the top of efs as the root, since a
+// wrong root almost always means the embed prefix was left out or doubled.
+func embedHint(efs embed.FS, root string) string {
+	entries, err := fs.ReadDir(efs, ".")
+	if err != nil {
+		return ""
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != root {
+			dirs = append(dirs, fmt.Sprintf("%q", entry.Name()))
+		}
+	}
+	if len(dirs) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (embedded paths include the directory prefix; did you mean root %s?)", strings.Join(dirs, " or "))
+}
@@ -0,0 +1,35 @@
+package tmplx
+
+import (
+	"embed"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata/embed
+var embedFS embed.FS
+
+func TestNewFromEmbed(t *testing.T) {
+	engine, err := NewFromEmbed(embedFS, "testdata/embed/templates/", WithFuncMap(map[string]interface{}{
+		"upper": strings.ToUpper,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("pages/home.html", H{"Name": "World"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "<body>Hello, World!</body>" {
+		t.Errorf("Unexpected output %q", result)
+	}
+
+	_, err = NewFromEmbed(embedFS, "templates")
+	if err == nil || !strings.Contains(err.Error(), `did you mean root "testdata"`) {
+		t.Errorf("Expected a hint about the embed prefix, got %v", err)
+	}
+
+	if _, err := NewFromEmbed(embedFS, "testdata/embed/templates/pages/home.html"); err == nil {
+		t.Error("Expected error for a file root")
+	}
+}
@@ -0,0 +1,111 @@
+package tmplx
+
+import (
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// EmojiFuncs is the opt-in emojify func, added with
+// engine.AddFuncs(tmplx.EmojiFuncs) or merged into Options.FuncMap:
+//
+//	{{emojify .Comment.Body}}
+var EmojiFuncs = template.FuncMap{
+	"emojify": Emojify,
+}
+
+// EmojiShortcodes maps the shortcodes emojify knows, without colons, to
+// their emoji. Applications may add their own before rendering.
+var EmojiShortcodes = map[string]string{
+	"+1": "👍", "-1": "👎", "100": "💯", "airplane": "✈️", "alarm_clock": "⏰",
+	"angry": "😠", "apple": "🍎", "art": "🎨", "astonished": "😲", "baby": "👶",
+	"balloon": "🎈", "bangbang": "‼️", "beer": "🍺", "beers": "🍻", "bell": "🔔",
+	"bike": "🚲", "blush": "😊", "bomb": "💣", "book": "📖", "books": "📚",
+	"boom": "💥", "bowtie": "🎀", "broken_heart": "💔", "bug": "🐛", "bulb": "💡",
+	"bust_in_silhouette": "👤", "cake": "🍰", "calendar": "📆", "camera": "📷", "car": "🚗",
+	"cat": "🐱", "chart_with_upwards_trend": "📈", "check": "✔️", "checkered_flag": "🏁",
+	"clap": "👏", "clipboard": "📋", "clock3": "🕒", "cloud": "☁️", "coffee": "☕",
+	"computer": "💻", "confused": "😕", "construction": "🚧", "cookie": "🍪", "cool": "🆒",
+	"crown": "👑", "cry": "😢", "crystal_ball": "🔮", "dart": "🎯", "dash": "💨",
+	"dog": "🐶", "dollar": "💵", "door": "🚪", "earth_africa": "🌍", "email": "📧",
+	"exclamation": "❗", "eyes": "👀", "facepunch": "👊", "fire": "🔥", "fireworks": "🎆",
+	"fist": "✊", "flushed": "😳", "gear": "⚙️", "gem": "💎", "ghost": "👻",
+	"gift": "🎁", "globe_with_meridians": "🌐", "grin": "😁", "grinning": "😀", "hammer": "🔨",
+	"hand": "✋", "heart": "❤️", "heart_eyes": "😍", "heavy_check_mark": "✔️", "heavy_plus_sign": "➕",
+	"hourglass": "⌛", "house": "🏠", "hugs": "🤗", "hushed": "😯", "information_source": "ℹ️",
+	"innocent": "😇", "joy": "😂", "key": "🔑", "kiss": "💋", "laughing": "😆",
+	"link": "🔗", "lipstick": "💄", "lock": "🔒", "loudspeaker": "📢", "mag": "🔍",
+	"mailbox": "📫", "medal_sports": "🏅", "memo": "📝", "metal": "🤘", "money_with_wings": "💸",
+	"moon": "🌔", "muscle": "💪", "mute": "🔇", "neutral_face": "😐", "new": "🆕",
+	"no_entry": "⛔", "ok": "🆗", "ok_hand": "👌", "open_mouth": "😮", "package": "📦",
+	"pencil": "📝", "pencil2": "✏️", "pensive": "😔", "point_down": "👇", "point_left": "👈",
+	"point_right": "👉", "point_up": "☝️", "pray": "🙏", "pushpin": "📌", "question": "❓",
+	"rage": "😡", "rainbow": "🌈", "raised_hands": "🙌", "recycle": "♻️", "relaxed": "☺️",
+	"relieved": "😌", "rocket": "🚀", "rofl": "🤣", "rose": "🌹", "rotating_light": "🚨",
+	"scream": "😱", "see_no_evil": "🙈", "shield": "🛡️", "shipit": "🐿️", "shrug": "🤷",
+	"skull": "💀", "sleeping": "😴", "slightly_smiling_face": "🙂", "smile": "😄", "smiley": "😃",
+	"smirk": "😏", "snail": "🐌", "snowflake": "❄️", "sob": "😭", "sparkles": "✨",
+	"speech_balloon": "💬", "star": "⭐", "star2": "🌟", "stop_sign": "🛑", "sun_with_face": "🌞",
+	"sunglasses": "😎", "sunny": "☀️", "sweat_smile": "😅", "tada": "🎉", "thinking": "🤔",
+	"thumbsdown": "👎", "thumbsup": "👍", "tired_face": "😫", "trophy": "🏆", "tulip": "🌷",
+	"turtle": "🐢", "umbrella": "☂️", "unamused": "😒", "unlock": "🔓", "upside_down_face": "🙃",
+	"v": "✌️", "warning": "⚠️", "wave": "👋", "white_check_mark": "✅", "wink": "😉",
+	"worried": "😟", "wrench": "🔧", "x": "❌", "yum": "😋", "zap": "⚡", "zzz": "💤",
+}
+
+var shortcode = regexp.MustCompile(`:([a-z0-9_+\-]+):`)
+
+// Emojify replaces :shortcode: in rich text with the emoji, each in a
+// <span class="emoji" role="img" aria-label="shortcode">. Unknown shortcodes
+// and those inside tags, <code>, <pre>, <script> and <style> are left as
+// they are. Like truncateHTML, only template.HTML values are treated as
+// markup; strings are escaped first.
+func Emojify(v interface{}) (template.HTML, error) {
+	s, err := richText(v)
+	if err != nil {
+		return "", fmt.Errorf("emojify: %v", err)
+	}
+
+	var b strings.Builder
+	var open []string
+	for i := 0; i < len(s); {
+		if s[i] == '<' {
+			end := tagEnd(s[i:])
+			b.WriteString(s[i : i+end])
+			trackElement(&open, s[i:i+end])
+			i += end
+			continue
+		}
+		next := strings.IndexByte(s[i:], '<')
+		if next < 0 {
+			next = len(s) - i
+		}
+		text := s[i : i+next]
+		if !insideCode(open) {
+			text = shortcode.ReplaceAllStringFunc(text, emojiSpan)
+		}
+		b.WriteString(text)
+		i += next
+	}
+	return template.HTML(b.String()), nil
+}
+
+func insideCode(open []string) bool {
+	for _, name := range open {
+		switch name {
+		case "code", "pre", "script", "style":
+			return true
+		}
+	}
+	return false
+}
+
+func emojiSpan(code string) string {
+	name := code[1 : len(code)-1]
+	emoji, ok := EmojiShortcodes[name]
+	if !ok {
+		return code
+	}
+	return `<span class="emoji" role="img" aria-label="` + template.HTMLEscapeString(name) + `">` + emoji + `</span>`
+}
@@ -0,0 +1,29 @@
+package tmplx
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestEmojify(t *testing.T) {
+	engine := New(Options{
+		Loader:  MapLoader{"comment.html": `<p>{{emojify .Plain}}</p>{{emojify .Rich}}`},
+		FuncMap: EmojiFuncs,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("comment.html", H{
+		"Plain": "Shipped :tada: <b>:nope:</b>",
+		"Rich":  template.HTML(`<p title=":fire:">:+1: at 10:30:45</p><pre><code>:tada:</code></pre>`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<p>Shipped <span class="emoji" role="img" aria-label="tada">🎉</span> &lt;b&gt;:nope:&lt;/b&gt;</p>` +
+		`<p title=":fire:"><span class="emoji" role="img" aria-label="+1">👍</span> at 10:30:45</p><pre><code>:tada:</code></pre>`
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
@@ -0,0 +1,32 @@
+package tmplx
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// readTemplate reads the template file at file in s, or its variant for
+// Options.Env when there is one: pages/home.dev.html for pages/home.html
+// with Env "dev"
+func (e *TemplateEngine) readTemplate(s Source, file string) ([]byte, error) {
+	if e.env != "" && containsString(e.envs, e.env) {
+		ext := path.Ext(file)
+		variant := strings.TrimSuffix(file, ext) + "." + e.env + ext
+		if content, err := fs.ReadFile(s.FS, variant); err == nil {
+			e.logf(LogLoad, LogDebug, "[TMPLX] Using %s for %s", variant, file)
+			return content, nil
+		}
+	}
+	return fs.ReadFile(s.FS, file)
+}
+
+// envVariant reports whether file is an environment variant, named for one
+// of Options.Envs such as pages/home.dev.html. Variants are never loaded
+// under their own names, so those of other environments can't be rendered
+// by mistake, with or without Env set.
+func (e *TemplateEngine) envVariant(file string) bool {
+	stem := strings.TrimSuffix(file, path.Ext(file))
+	suffix := path.Ext(stem)
+	return suffix != "" && containsString(e.envs, suffix[1:])
+}
@@ -0,0 +1,72 @@
+package tmplx
+
+import "testing"
+
+func TestEnvVariants(t *testing.T) {
+	loader := MapLoader{
+		"layouts/base.html":            `{{include "partials/banner.html" .}}<main>{{block "content" .}}{{end}}</main>`,
+		"partials/banner.html":         ``,
+		"partials/banner.dev.html":     `<div class="banner">development</div>`,
+		"partials/banner.staging.html": `<div class="banner">staging</div>`,
+		"pages/home.html":              `{{extend "layouts/base.html"}}{{define "content"}}home{{end}}`,
+		"pages/home.de.html":           `{{extend "layouts/base.html"}}{{define "content"}}Startseite{{end}}`,
+		"pages/post.html":              `{{include "pages/post.card.html" .}}`,
+		"pages/post.card.html":         `<article>card</article>`,
+		"pages/debug.dev.html":         `{{.Missing.Field}}`,
+	}
+
+	tests := map[string]string{
+		"":        `<main>home</main>`,
+		"prod":    `<main>home</main>`,
+		"dev":     `<div class="banner">development</div><main>home</main>`,
+		"staging": `<div class="banner">staging</div><main>home</main>`,
+	}
+	for env, expected := range tests {
+		engine := New(Options{Loader: loader, Env: env, Envs: []string{"dev", "staging", "prod"}, Locales: []string{"en", "de"}})
+		if err := engine.Load(); err != nil {
+			t.Fatal(err)
+		}
+		result, err := engine.Render("pages/home.html", nil)
+		if err != nil {
+			t.Fatalf("%s: %v", env, err)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %s, got %s", env, expected, result)
+		}
+		for _, name := range []string{"partials/banner.staging.html", "pages/debug.dev.html"} {
+			if _, err := engine.GetTemplate(name); err == nil {
+				t.Errorf("%q: expected environment variant %s not to be loaded", env, name)
+			}
+		}
+		for _, name := range []string{"pages/home.de.html", "pages/post.card.html"} {
+			if _, err := engine.GetTemplate(name); err != nil {
+				t.Errorf("%q: expected %s to be loaded: %v", env, name, err)
+			}
+		}
+		if result, err := engine.Render("pages/post.html", nil); err != nil || result != `<article>card</article>` {
+			t.Errorf("%q: expected the card, got %q, %v", env, result, err)
+		}
+	}
+}
+
+func TestEnvVariantsNeedEnvs(t *testing.T) {
+	loader := MapLoader{
+		"pages/home.html":     `home`,
+		"pages/home.dev.html": `dev home`,
+	}
+	engine := New(Options{Loader: loader, Env: "dev"})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("pages/home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "home" {
+		t.Errorf("Expected variants only for listed environments, got %s", result)
+	}
+
+	if err := (Options{Loader: loader, Env: "qa", Envs: []string{"dev", "prod"}}).Validate(); err == nil || err.Error() != `Env: "qa" is not listed in Envs` {
+		t.Errorf("Expected an error for an unlisted Env, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package tmplx
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// errorContext is how many lines around the failing one the error page shows
+const errorContext = 5
+
+// sourceLine is a line of template source shown on the error page
+type sourceLine struct {
+	Number  int
+	Text    string
+	Failing bool
+}
+
+// RenderErrorPage answers with err, the error of a failed render, e.g. from
+// a middleware. In DevMode the page shows the error, the source of the file
+// it is in with the failing line highlighted, and the layouts and includes
+// that led there. Otherwise it is a bare status page that gives nothing
+// away. The status is 404 Not Found for ErrTemplateNotFound and 500
+// Internal Server Error for anything else.
+func (e *TemplateEngine) RenderErrorPage(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrTemplateNotFound) {
+		status = http.StatusNotFound
+	}
+	// RenderHTTP may have tagged the response before the render failed
+	w.Header().Del("ETag")
+	if !e.devMode {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	page := struct {
+		Status  int
+		Message string
+		Error   *TemplateError
+		Source  []sourceLine
+	}{Status: status, Message: err.Error()}
+	var te *TemplateError
+	if errors.As(err, &te) {
+		page.Error = te
+		page.Source = e.sourceAround(te.File, te.Line)
+	}
+
+	var buf bytes.Buffer
+	if err := errorPage.Execute(&buf, page); err != nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+}
+
+// sourceAround returns the lines of file around line, the whole file if
+// line is unknown or past its end, or nil if file can't be read. The file
+// is read as the engine reads it, from its variant for Options.Env if it
+// has one.
+func (e *TemplateEngine) sourceAround(file string, line int) []sourceLine {
+	e.mu.RLock()
+	s, ok := e.findSource(file)
+	e.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	content, err := e.readTemplate(s, path.Join(s.Dir, file))
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if line > len(lines) {
+		line = 0
+	}
+	first, last := 1, len(lines)
+	if line > 0 {
+		first, last = max(line-errorContext, 1), min(line+errorContext, len(lines))
+	}
+	var out []sourceLine
+	for n := first; n <= last; n++ {
+		out = append(out, sourceLine{Number: n, Text: lines[n-1], Failing: n == line})
+	}
+	return out
+}
+
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} - tmplx error</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+code, pre { background: #f4f4f4; }
+pre { padding: .5em 0; overflow-x: auto; }
+.line { display: block; padding: 0 1em; }
+.line span { display: inline-block; width: 3em; color: #999; user-select: none; }
+.failing { background: #fdd; }
+.message { color: #b00; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Status}}</h1>
+<p class="message">{{.Message}}</p>
+{{- with .Error}}
+<h2><code>{{.File}}{{if .Line}}:{{.Line}}{{if .Column}}:{{.Column}}{{end}}{{end}}</code></h2>
+{{- if gt (len .Chain) 1}}
+<p>{{range $i, $f := .Chain}}{{if $i}} &rarr; {{end}}<code>{{$f}}</code>{{end}}</p>
+{{- end}}
+{{- end}}
+{{- if .Source}}
+<pre>{{range .Source}}<code class="line{{if .Failing}} failing{{end}}"><span>{{.Number}}</span>{{.Text}}</code>{{end}}</pre>
+{{- end}}
+</body>
+</html>
+`))
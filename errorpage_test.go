@@ -0,0 +1,97 @@
+package tmplx
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRenderErrorPage(t *testing.T) {
+	loader := MapLoader{
+		"layouts/base.html": "<main>\n  {{fail}}\n  {{block \"content\" .}}{{end}}\n</main>",
+		"pages/home.html":   `{{extend "layouts/base.html"}}{{define "content"}}home{{end}}`,
+	}
+	funcs := template.FuncMap{"fail": func() (string, error) { return "", errors.New("boom <b>") }}
+
+	engine := New(Options{Loader: loader, FuncMap: funcs, DevMode: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := engine.Render("pages/home.html", nil)
+	if err == nil {
+		t.Fatal("Expected the render to fail")
+	}
+	rec := httptest.NewRecorder()
+	engine.RenderErrorPage(rec, err)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("Expected an HTML page, got %s", rec.Header().Get("Content-Type"))
+	}
+	containsAll(t, []string{
+		"boom &lt;b&gt;",
+		"<code>layouts/base.html:2:",
+		"<code>pages/home.html</code> &rarr; <code>layouts/base.html</code>",
+		`<code class="line failing"><span>2</span>  {{fail}}</code>`,
+		`<code class="line"><span>1</span>&lt;main&gt;</code>`,
+	}, rec.Body.String())
+
+	engine = New(Options{Loader: loader, FuncMap: funcs})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = engine.Render("pages/home.html", nil)
+	rec = httptest.NewRecorder()
+	engine.RenderErrorPage(rec, err)
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "fail") {
+		t.Errorf("Expected a bare 500 outside dev mode, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	_, err = engine.Render("missing.html", nil)
+	rec = httptest.NewRecorder()
+	engine.RenderErrorPage(rec, err)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing template, got %d", rec.Code)
+	}
+}
+
+func TestRenderErrorPageEnvVariant(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"pages/home.html":     "<main>home</main>",
+			"pages/home.dev.html": "<main>\n<p>dev</p>\n{{fail}}\n</main>",
+		},
+		FuncMap: template.FuncMap{"fail": func() (string, error) { return "", errors.New("boom") }},
+		Env:     "dev",
+		Envs:    []string{"dev"},
+		DevMode: true,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := engine.Render("pages/home.html", nil)
+	if err == nil {
+		t.Fatal("Expected the render to fail")
+	}
+	rec := httptest.NewRecorder()
+	engine.RenderErrorPage(rec, err)
+	containsAll(t, []string{
+		`<code class="line failing"><span>3</span>{{fail}}</code>`,
+		`<code class="line"><span>2</span>&lt;p&gt;dev&lt;/p&gt;</code>`,
+	}, rec.Body.String())
+
+	// a line past the end of the file highlights nothing
+	source := engine.sourceAround("pages/home.html", 40)
+	if len(source) != 4 {
+		t.Fatalf("Expected the whole file, got %v", source)
+	}
+	for _, line := range source {
+		if line.Failing {
+			t.Errorf("Expected no failing line, got %v", line)
+		}
+	}
+}
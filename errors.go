@@ -0,0 +1,263 @@
+package tmplx
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Errors that failures of the engine wrap, for callers to tell kinds of
+// failure apart with errors.Is, e.g. to answer 404 rather than 500
+var (
+	// ErrTemplateNotFound is wrapped by the error of rendering or looking up
+	// a template that isn't loaded, and of extending a layout that doesn't
+	// exist
+	ErrTemplateNotFound = errors.New("template not found")
+
+	// ErrCircularInheritance is wrapped by the error of loading templates
+	// that extend or include themselves, directly or through others
+	ErrCircularInheritance = errors.New("circular template inheritance")
+
+	// ErrIncludeNotFound is wrapped by the error of loading a template that
+	// includes a file that doesn't exist
+	ErrIncludeNotFound = errors.New("include not found")
+)
+
+// kindError is an error of one of the kinds above, keeping its own message
+type kindError struct {
+	kind error
+	err  error
+}
+
+// withKind returns err marked as an error of kind
+func withKind(kind, err error) error {
+	return &kindError{kind: kind, err: err}
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// notFound returns the error of a template name that isn't loaded
+func notFound(name string) error {
+	return withKind(ErrTemplateNotFound, fmt.Errorf("template %s not found", name))
+}
+
+// TemplateError is the error of a template that failed to load or render.
+// Its message is that of Err; the fields locate the failure, e.g. for an
+// editor or an error page:
+//
+//	var te *tmplx.TemplateError
+//	if errors.As(err, &te) {
+//		log.Printf("%s:%d:%d", te.File, te.Line, te.Column)
+//	}
+type TemplateError struct {
+	// Name is the template that was loaded or rendered
+	Name string
+
+	// File is the file the failure is in: Name, or one of the layouts or
+	// includes it uses
+	File string
+
+	// Line and Column locate the failure in File, zero if unknown. Parse
+	// errors carry a line only.
+	Line   int
+	Column int
+
+	// Chain lists the files that led from Name to File, Name first: the
+	// layouts it extends and the includes that include File
+	Chain []string
+
+	// Err is the failure, with the context it was reported in
+	Err error
+}
+
+func (e *TemplateError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// errorLocation matches the location html/template puts in its errors,
+// "template: name:line:" or "template: name:line:column:"
+var errorLocation = regexp.MustCompile(`template: ([^:\s]*):(\d+)(?::(\d+))?:`)
+
+// errorPosition returns the template, line and column cause, an error of
+// html/template, reports
+func errorPosition(cause error) (name string, line, column int) {
+	m := errorLocation.FindStringSubmatch(cause.Error())
+	if m == nil {
+		return "", 0, 0
+	}
+	line, _ = strconv.Atoi(m[2])
+	column, _ = strconv.Atoi(m[3])
+	return m[1], line, column
+}
+
+// newTemplateError returns err, a failure to parse file reported with its
+// context, as a TemplateError located by cause, the error of html/template
+func newTemplateError(file string, cause, err error) *TemplateError {
+	_, line, column := errorPosition(cause)
+	return &TemplateError{Name: file, File: file, Line: line, Column: column, Chain: []string{file}, Err: err}
+}
+
+// lineSegment maps lines start to end of a file's content, with its
+// includes spliced in, to the lines from line on of file. A shared start or
+// end line also holds spliced text, so columns on it have moved.
+type lineSegment struct {
+	file        string
+	start, end  int
+	line        int
+	sharedStart bool
+	sharedEnd   bool
+}
+
+// lineMap maps the lines of a file's content, with its includes spliced
+// in, back to the files they came from
+type lineMap []lineSegment
+
+// newLineMap maps content, read from file, to itself
+func newLineMap(file, content string) lineMap {
+	return lineMap{{file: file, start: 1, end: strings.Count(content, "\n") + 1, line: 1}}
+}
+
+// splice returns the map of the content m maps once the text at line p,
+// spanning oldLines line breaks, is replaced by inserted text spanning
+// newLines, whose own lines are mapped by sub
+func (m lineMap) splice(p, oldLines, newLines int, sub lineMap) lineMap {
+	shift := newLines - oldLines
+	var out lineMap
+	for _, seg := range m {
+		switch {
+		case seg.end < p:
+			out = append(out, seg)
+		case seg.start > p+oldLines:
+			seg.start += shift
+			seg.end += shift
+			out = append(out, seg)
+		default:
+			if seg.start <= p {
+				left := seg
+				left.end, left.sharedEnd = p, true
+				out = append(out, left)
+			}
+			if seg.end >= p+oldLines {
+				right := seg
+				right.line = seg.line + p + oldLines - seg.start
+				right.start, right.end = p+newLines, seg.end+shift
+				right.sharedStart = true
+				out = append(out, right)
+			}
+		}
+	}
+	for _, seg := range sub {
+		seg.start += p - 1
+		seg.end += p - 1
+		seg.sharedStart = seg.sharedStart || seg.start == p
+		seg.sharedEnd = seg.sharedEnd || seg.end == p+newLines
+		out = append(out, seg)
+	}
+	return out
+}
+
+// position returns the line of file that line of the mapped content came
+// from, or 0 if it came from elsewhere, and whether columns on it are those
+// of file
+func (m lineMap) position(file string, line int) (int, bool) {
+	for _, seg := range m {
+		if seg.file == file && seg.start <= line && line <= seg.end {
+			exact := !(line == seg.start && seg.sharedStart) && !(line == seg.end && seg.sharedEnd)
+			return seg.line + line - seg.start, exact
+		}
+	}
+	return 0, false
+}
+
+// locate maps the position of te, reported in content lines maps, back to
+// te.File, leaving it zero if the line came from elsewhere
+func (te *TemplateError) locate(lines lineMap) *TemplateError {
+	if te.Line == 0 {
+		return te
+	}
+	line, exact := lines.position(te.File, te.Line)
+	te.Line = line
+	if !exact {
+		te.Column = 0
+	}
+	return te
+}
+
+// leadsTo records that name led to the failure err reports, adding it to
+// the front of the chain of a TemplateError, and returns err
+func leadsTo(name string, err error) error {
+	var te *TemplateError
+	if errors.As(err, &te) && (len(te.Chain) == 0 || te.Chain[0] != name) {
+		te.Chain = append([]string{name}, te.Chain...)
+		te.Name = name
+	}
+	return err
+}
+
+// renderError returns err, reporting cause, the failure of a render of
+// name, as a TemplateError chained through the layouts leading to the
+// failing file
+func (e *TemplateEngine) renderError(name string, cause, err error) error {
+	te := newTemplateError(name, cause, err)
+	// execution errors name the file the failing action was parsed from
+	if file, _, _ := errorPosition(cause); file != "" && file != name && e.isTemplateFile(file) {
+		te.File = file
+		te.Chain = append(te.Chain, file)
+		if chain := e.layoutChain(name, file); chain != nil {
+			te.Chain = chain
+		}
+	}
+	return te.locate(e.renderLines(name, te.File))
+}
+
+// renderLines returns the line map of the content the trees of file
+// rendering name were parsed from: that of name, or of the nearest layout
+// it extends, with file spliced in
+func (e *TemplateEngine) renderLines(name, file string) lineMap {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for current, seen := name, 0; seen <= len(e.parents); seen++ {
+		if cached, ok := e.inclCache[current]; ok {
+			for _, seg := range cached.lines {
+				if seg.file == file {
+					return cached.lines
+				}
+			}
+		}
+		parent, ok := e.parents[current]
+		if !ok {
+			break
+		}
+		current = parent
+	}
+	return nil
+}
+
+// layoutChain returns name and the layouts it extends up to file, or nil if
+// file isn't one of them
+func (e *TemplateEngine) layoutChain(name, file string) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	chain := []string{name}
+	for current := name; current != file; {
+		parent, ok := e.parents[current]
+		if !ok || len(chain) > len(e.parents) {
+			return nil
+		}
+		chain = append(chain, parent)
+		current = parent
+	}
+	return chain
+}
@@ -0,0 +1,193 @@
+package tmplx
+
+import (
+	"errors"
+	"html/template"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateErrorLoad(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{
+		"a.html":            `{{extend "layouts/base.html"}}{{define "content"}}a{{end}}`,
+		"layouts/base.html": "<main>\n{{if}}\n</main>",
+	}})
+	err := engine.Load()
+	var te *TemplateError
+	if !errors.As(err, &te) {
+		t.Fatalf("Expected a TemplateError, got %T: %v", err, err)
+	}
+	if te.Name != "a.html" || te.File != "layouts/base.html" || te.Line != 2 {
+		t.Errorf("Expected a.html failing in layouts/base.html:2, got %s in %s:%d", te.Name, te.File, te.Line)
+	}
+	if want := []string{"a.html", "layouts/base.html"}; !reflect.DeepEqual(te.Chain, want) {
+		t.Errorf("Expected chain %v, got %v", want, te.Chain)
+	}
+	if !strings.HasPrefix(err.Error(), "failed to load templates:") {
+		t.Errorf("Expected the message to be unchanged, got %v", err)
+	}
+
+	engine = New(Options{Loader: MapLoader{
+		"a.html":             `{{include "partials/nav.html" .}}a`,
+		"partials/nav.html":  `{{include "partials/menu.html" .}}`,
+		"partials/menu.html": "<ul>\n\n{{end}}",
+	}})
+	if err := engine.Load(); !errors.As(err, &te) {
+		t.Fatalf("Expected a TemplateError, got %v", err)
+	}
+	if want := []string{"a.html", "partials/nav.html", "partials/menu.html"}; !reflect.DeepEqual(te.Chain, want) {
+		t.Errorf("Expected chain %v, got %v", want, te.Chain)
+	}
+	if te.File != "partials/menu.html" || te.Line != 3 {
+		t.Errorf("Expected the failure at partials/menu.html:3, got %s:%d", te.File, te.Line)
+	}
+}
+
+func TestTemplateErrorRender(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html": "<main>\n  {{fail}}{{block \"content\" .}}{{end}}\n</main>",
+			"pages/home.html":   `{{extend "layouts/base.html"}}{{define "content"}}home{{end}}`,
+		},
+		FuncMap: template.FuncMap{
+			"fail": func() (string, error) { return "", errors.New("boom") },
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := engine.Render("pages/home.html", nil)
+	var te *TemplateError
+	if !errors.As(err, &te) {
+		t.Fatalf("Expected a TemplateError, got %T: %v", err, err)
+	}
+	if te.Name != "pages/home.html" || te.File != "layouts/base.html" || te.Line != 2 || te.Column == 0 {
+		t.Errorf("Expected the failure located in the layout, got %s in %s:%d:%d", te.Name, te.File, te.Line, te.Column)
+	}
+	if want := []string{"pages/home.html", "layouts/base.html"}; !reflect.DeepEqual(te.Chain, want) {
+		t.Errorf("Expected chain %v, got %v", want, te.Chain)
+	}
+	if !strings.HasPrefix(err.Error(), "error rendering template pages/home.html:") || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Unexpected message %v", err)
+	}
+}
+
+func TestTemplateErrorLineAfterInclude(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"partials/head.html": "<head>\n<title>x</title>\n{{define \"badge\"}}\n<b>\n{{fail}}</b>{{end}}\n</head>",
+			"pages/home.html":    "{{include \"partials/head.html\" .}}\n<p>\n{{if .Badge}}{{template \"badge\"}}{{else}}{{fail}}{{end}}</p>",
+		},
+		FuncMap: template.FuncMap{
+			"fail": func() (string, error) { return "", errors.New("boom") },
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		data   H
+		file   string
+		line   int
+		column bool
+	}{
+		{H{}, "pages/home.html", 3, true},
+		{H{"Badge": true}, "partials/head.html", 5, true},
+	}
+	for _, c := range cases {
+		_, err := engine.Render("pages/home.html", c.data)
+		var te *TemplateError
+		if !errors.As(err, &te) {
+			t.Fatalf("Expected a TemplateError, got %T: %v", err, err)
+		}
+		if te.File != c.file || te.Line != c.line || (te.Column != 0) != c.column {
+			t.Errorf("Expected the failure at %s:%d, got %s:%d:%d", c.file, c.line, te.File, te.Line, te.Column)
+		}
+	}
+}
+
+func TestLineMap(t *testing.T) {
+	// "b" spliced into the middle of line 2 of "a"
+	lines := newLineMap("a", "1\n2\n3").splice(2, 0, 2, newLineMap("b", "x\ny\nz"))
+	cases := []struct {
+		file        string
+		line, want  int
+		wantColumns bool
+	}{
+		{"a", 1, 1, true},
+		{"a", 2, 2, false},
+		{"b", 2, 1, false},
+		{"b", 3, 2, true},
+		{"b", 4, 3, false},
+		{"a", 4, 2, false},
+		{"a", 5, 3, true},
+		{"a", 3, 0, false},
+		{"c", 1, 0, false},
+	}
+	for _, c := range cases {
+		if got, columns := lines.position(c.file, c.line); got != c.want || columns != c.wantColumns {
+			t.Errorf("%s at line %d: expected line %d (columns %v), got %d (%v)", c.file, c.line, c.want, c.wantColumns, got, columns)
+		}
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{
+		"pages/home.html": `home`,
+	}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Render("pages/missing.html", nil); !errors.Is(err, ErrTemplateNotFound) || err.Error() != "template pages/missing.html not found" {
+		t.Errorf("Expected ErrTemplateNotFound with the usual message, got %v", err)
+	}
+	if _, err := engine.GetTemplate("pages/missing.html"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Expected ErrTemplateNotFound from GetTemplate, got %v", err)
+	}
+	if _, err := engine.Render("pages/home.html", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		files MapLoader
+		want  error
+	}{
+		{"missing layout", MapLoader{"a.html": `{{extend "layouts/none.html"}}`}, ErrTemplateNotFound},
+		{"missing include", MapLoader{"a.html": `{{include "partials/none.html" .}}`}, ErrIncludeNotFound},
+		{"circular extends", MapLoader{"a.html": `{{extend "b.html"}}`, "b.html": `{{extend "a.html"}}`}, ErrCircularInheritance},
+		{"circular includes", MapLoader{"a.html": `{{include "b.html" .}}`, "b.html": `{{include "a.html" .}}`}, ErrCircularInheritance},
+	}
+	for _, tt := range tests {
+		err := New(Options{Loader: tt.files}).Load()
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestCircularChain(t *testing.T) {
+	tests := []struct {
+		name  string
+		files MapLoader
+		want  string
+	}{
+		{"extends", MapLoader{"a.html": `{{extend "b.html"}}`, "b.html": `{{extend "c.html"}}`, "c.html": `{{extend "a.html"}}`}, "a.html -> b.html -> c.html -> a.html"},
+		{"includes", MapLoader{"a.html": `{{include "b.html" .}}`, "b.html": `{{include "c.html" .}}`, "c.html": `{{include "b.html" .}}`}, "b.html -> c.html -> b.html"},
+	}
+	for _, tt := range tests {
+		for _, lazy := range []bool{false, true} {
+			engine := New(Options{Loader: tt.files, LazyLoad: lazy})
+			err := engine.Load()
+			if lazy {
+				_, err = engine.Render("a.html", nil)
+			}
+			if !errors.Is(err, ErrCircularInheritance) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s (lazy %v): expected the chain %s, got %v", tt.name, lazy, tt.want, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package tmplx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ETagMode controls how RenderHTTP computes ETags
+type ETagMode int
+
+const (
+	// ETagNone disables ETag generation
+	ETagNone ETagMode = iota
+
+	// ETagOutput hashes the rendered output. The page is always rendered, but
+	// unchanged pages are answered with 304 Not Modified and no body.
+	ETagOutput
+
+	// ETagInputs hashes the resolved template together with the JSON encoding
+	// of the data, so matching requests skip rendering entirely. Data that
+	// can't be encoded as JSON falls back to ETagOutput, and so do engines
+	// whose output also depends on feature flags, services or the render
+	// store, which the hash can't cover.
+	ETagInputs
+)
+
+// digestTemplate returns a stable hash of a resolved template and all of its
+// associated templates. It must be computed before the template executes,
+// since html/template rewrites the trees when escaping.
+func digestTemplate(tmpl *template.Template) string {
+	templates := tmpl.Templates()
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name() < templates[j].Name() })
+
+	h := sha256.New()
+	for _, t := range templates {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		h.Write([]byte(t.Name()))
+		h.Write([]byte{0})
+		h.Write([]byte(t.Tree.Root.String()))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Hash returns a stable hash of the resolved template, covering its parents,
+// includes and blocks. It only changes when the template's effective content
+// does, which makes it suitable for cache keys, ETags and detecting whether a
+// deploy actually changed a page. Funcs are not part of the hash.
+func (e *TemplateEngine) Hash(name string) (string, error) {
+	e.mu.RLock()
+	digest, ok := e.digests[name]
+	e.mu.RUnlock()
+	if !ok {
+		return "", notFound(name)
+	}
+	return digest, nil
+}
+
+// inputsCover reports whether the template, locale and data are all a render
+// of e with cfg depends on, so ETagInputs can tag it without rendering
+func (e *TemplateEngine) inputsCover(cfg renderConfig) bool {
+	return e.flags == nil && !e.contextual.Load() && len(e.services) == 0 && len(cfg.services) == 0
+}
+
+func strongETag(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// etagMatches reports whether the request's If-None-Match header matches tag,
+// using the weak comparison RFC 9110 prescribes for If-None-Match.
+func etagMatches(r *http.Request, tag string) bool {
+	if r == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *TemplateEngine) renderWithOutputETag(w http.ResponseWriter, r *http.Request, name string, data interface{}, cfg renderConfig) error {
+	var buf bytes.Buffer
+	if err := e.renderTo(&buf, name, data, cfg); err != nil {
+		return err
+	}
+
+	tag := strongETag(buf.Bytes())
+	w.Header().Set("ETag", tag)
+	if etagMatches(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	e.setContentType(w, name)
+	w.WriteHeader(http.StatusOK)
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,147 @@
+package tmplx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderHTTPETag(t *testing.T) {
+	for _, mode := range []ETagMode{ETagOutput, ETagInputs} {
+		engine := newHTTPTestEngine(t, Options{ETag: mode})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if err := engine.RenderHTTP(rec, req, "pages/home.html", H{"Title": "Hi"}); err != nil {
+			t.Fatal(err)
+		}
+		tag := rec.Header().Get("ETag")
+		if rec.Code != http.StatusOK || tag == "" {
+			t.Fatalf("mode %d: expected 200 with ETag, got %d %q", mode, rec.Code, tag)
+		}
+		containsAll(t, []string{"<h1>Hi</h1>"}, rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", `"other", W/`+tag)
+		if err := engine.RenderHTTP(rec, req, "pages/home.html", H{"Title": "Hi"}); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("mode %d: expected empty 304, got %d %q", mode, rec.Code, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", tag)
+		if err := engine.RenderHTTP(rec, req, "pages/home.html", H{"Title": "Changed"}); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusOK || rec.Header().Get("ETag") == tag {
+			t.Errorf("mode %d: expected new ETag for changed data, got %d %q", mode, rec.Code, rec.Header().Get("ETag"))
+		}
+	}
+}
+
+func TestRenderHTTPETagInputsOutsideData(t *testing.T) {
+	enabled := false
+	user := "ada"
+	tests := []struct {
+		name   string
+		opts   Options
+		change func()
+	}{
+		{"flags", Options{
+			Loader:       MapLoader{"page.html": `{{if feature "x"}}on{{else}}off{{end}}`},
+			FlagProvider: FlagFunc(func(ctx context.Context, flag string) bool { return enabled }),
+		}, func() { enabled = true }},
+		{"services", Options{
+			Loader:       MapLoader{"page.html": `{{user}}`},
+			ServiceFuncs: map[string]any{"user": func(ctx context.Context) string { return user }},
+		}, func() { user = "grace" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.ETag = ETagInputs
+			engine := New(tt.opts)
+			if err := engine.Load(); err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			if err := engine.RenderHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil), "page.html", nil); err != nil {
+				t.Fatal(err)
+			}
+			tag, body := rec.Header().Get("ETag"), rec.Body.String()
+
+			// the hash can't see the change, so the page is rendered to tag it
+			tt.change()
+			rec = httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("If-None-Match", tag)
+			if err := engine.RenderHTTP(rec, req, "page.html", nil); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != http.StatusOK || rec.Body.String() == body {
+				t.Errorf("Expected the changed page, got %d %q", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRenderHTTPWithoutETag(t *testing.T) {
+	engine := newHTTPTestEngine(t, Options{})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if err := engine.RenderHTTP(rec, req, "pages/home.html", H{"Title": "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Header().Get("ETag") != "" {
+		t.Errorf("Expected no ETag by default, got %q", rec.Header().Get("ETag"))
+	}
+}
+
+func TestHash(t *testing.T) {
+	files := func(nav string) fstest.MapFS {
+		return fstest.MapFS{
+			"layouts/base.html": &fstest.MapFile{Data: []byte(`<body>{{include "partials/nav.html" .}}{{block "content" .}}{{end}}</body>`)},
+			"partials/nav.html": &fstest.MapFile{Data: []byte(nav)},
+			"pages/home.html":   &fstest.MapFile{Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}Home{{end}}`)},
+			"pages/about.html":  &fstest.MapFile{Data: []byte(`{{define "content"}}About{{end}}`)},
+		}
+	}
+	hashes := func(fsys fstest.MapFS) map[string]string {
+		engine := New(Options{FS: fsys})
+		if err := engine.Load(); err != nil {
+			t.Fatal(err)
+		}
+		m := make(map[string]string)
+		for _, name := range []string{"pages/home.html", "pages/about.html"} {
+			hash, err := engine.Hash(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m[name] = hash
+		}
+		if _, err := engine.Hash("missing.html"); err == nil {
+			t.Error("Expected an error for a missing template")
+		}
+		return m
+	}
+
+	first, second := hashes(files("<nav></nav>")), hashes(files("<nav></nav>"))
+	if first["pages/home.html"] != second["pages/home.html"] {
+		t.Error("Expected hash to be stable across loads")
+	}
+
+	changed := hashes(files("<nav>new</nav>"))
+	if changed["pages/home.html"] == first["pages/home.html"] {
+		t.Error("Expected hash to change when an included partial changes")
+	}
+	if changed["pages/about.html"] != first["pages/about.html"] {
+		t.Error("Expected hash of an unrelated template to stay the same")
+	}
+}
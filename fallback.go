@@ -0,0 +1,33 @@
+package tmplx
+
+// WithFallback renders the named template in place of the page if executing
+// the page fails, overriding Options.FallbackTemplate
+func WithFallback(name string) RenderOption {
+	return func(cfg *renderConfig) {
+		cfg.fallback = name
+	}
+}
+
+// fallbackFor returns the template to render if rendering name fails, in
+// the render's locale, or "" for none
+func (e *TemplateEngine) fallbackFor(name string, cfg renderConfig) string {
+	fallback := cfg.fallback
+	if fallback == "" {
+		fallback = e.fallback
+	}
+	if fallback == "" {
+		return ""
+	}
+	fallback = e.localeVariant(fallback, cfg.locale)
+	if fallback == name {
+		return ""
+	}
+	return fallback
+}
+
+// renderFailed reports a failed render to Options.OnRenderError
+func (e *TemplateEngine) renderFailed(name string, err error) {
+	if e.onRenderError != nil {
+		e.onRenderError(name, err)
+	}
+}
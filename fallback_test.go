@@ -0,0 +1,59 @@
+package tmplx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type failingData struct{}
+
+func (failingData) Items() ([]string, error) { return nil, errors.New("database is down") }
+
+func TestFallbackTemplate(t *testing.T) {
+	var failed []string
+	engine := New(Options{
+		Loader: MapLoader{
+			"pages/list.html":      `<ul>{{range .Items}}<li>{{.}}</li>{{end}}</ul>`,
+			"pages/ok.html":        `ok`,
+			"errors/fallback.html": `<p>We'll be right back.</p>`,
+			"errors/simple.html":   `<p>Simple list</p>`,
+		},
+		FallbackTemplate: "errors/fallback.html",
+		OnRenderError:    func(name string, err error) { failed = append(failed, name+": "+err.Error()) },
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("pages/list.html", failingData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<p>We'll be right back.</p>`; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+	if len(failed) != 1 || !strings.Contains(failed[0], "pages/list.html: ") || !strings.Contains(failed[0], "database is down") {
+		t.Errorf("Expected the original error to be reported, got %v", failed)
+	}
+
+	result, err = engine.Render("pages/list.html", failingData{}, WithFallback("errors/simple.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<p>Simple list</p>`; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	if result, err := engine.Render("pages/ok.html", nil); err != nil || result != "ok" {
+		t.Errorf("Expected ok, got %q, %v", result, err)
+	}
+	if _, err := engine.Render("pages/missing.html", nil); err == nil {
+		t.Error("Expected missing templates to fail rather than fall back")
+	}
+
+	engine = New(Options{Loader: MapLoader{"page.html": "page"}, FallbackTemplate: "errors/fallback.html"})
+	if err := engine.Load(); err == nil || !strings.Contains(err.Error(), "fallback template errors/fallback.html not found") {
+		t.Errorf("Expected a missing fallback to fail loading, got %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package tmplx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// templateKeywords are words with a meaning of their own at the start of an action
+var templateKeywords = map[string]bool{
+	"if": true, "else": true, "end": true, "range": true, "with": true,
+	"define": true, "block": true, "template": true, "break": true,
+	"continue": true, "nil": true, "true": true, "false": true,
+}
+
+// builtinTemplateFuncs are the funcs text/template predefines
+var builtinTemplateFuncs = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "slice": true,
+	"js": true, "len": true, "not": true, "or": true, "print": true,
+	"printf": true, "println": true, "urlquery": true, "eq": true, "ge": true,
+	"gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// filters translates the filter syntax in content when FilterSyntax is set
+func (e *TemplateEngine) filters(file, content string) (string, error) {
+	if !e.filterSyntax {
+		return content, nil
+	}
+	translated, err := translateFilters(content, func(name string) bool {
+		_, ok := e.funcMap[name]
+		return ok
+	})
+	if err != nil {
+		return "", fmt.Errorf("error translating filters in %s: %v", file, err)
+	}
+	return translated, nil
+}
+
+// translateFilters rewrites Jinja-style pipelines in every action of content
+// into Go template syntax:
+//
+//	{{ title | upper | truncate(80) }}  =>  {{ .title | upper | truncate 80 }}
+//	{{ join(tags, ', ') }}              =>  {{ join .tags ", " }}
+//
+// Call syntax f(a, b) becomes f a b, or (f a b) where it is an argument;
+// single quoted strings become double quoted; and a bare name that isn't a
+// func or keyword becomes a field of dot, unless it follows a pipe, where
+// it is left for the parser to report as an undefined func. Actions already
+// in Go syntax pass through unchanged. As with Go pipelines, the piped value
+// is the last argument of a filter.
+func translateFilters(content string, isFunc func(string) bool) (string, error) {
+	var out strings.Builder
+	for {
+		start := strings.Index(content, "{{")
+		if start < 0 {
+			out.WriteString(content)
+			return out.String(), nil
+		}
+		out.WriteString(content[:start+2])
+		content = content[start+2:]
+
+		if strings.HasPrefix(strings.TrimLeft(content, "- "), "/*") {
+			end := strings.Index(content, "*/")
+			if end < 0 {
+				return "", fmt.Errorf("unclosed comment")
+			}
+			out.WriteString(content[:end+2])
+			content = content[end+2:]
+			continue
+		}
+
+		end, err := actionEnd(content)
+		if err != nil {
+			return "", err
+		}
+		body, err := translateAction(content[:end], isFunc)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(body)
+		content = content[end:]
+	}
+}
+
+// actionEnd returns the offset of the "}}" closing the action body s begins
+func actionEnd(s string) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'', '`':
+			n, err := quotedLen(s[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n - 1
+		case '}':
+			if strings.HasPrefix(s[i:], "}}") {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed action")
+}
+
+// quotedLen returns the length of the quoted string s starts with
+func quotedLen(s string) (int, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote != '`':
+			i++
+		case s[i] == quote:
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated string %s", s)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '.' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func translateAction(body string, isFunc func(string) bool) (string, error) {
+	const (
+		group = iota // plain parentheses
+		call         // f(...) at the head of a command
+		inner        // f(...) as an argument, wrapped in parentheses
+	)
+
+	var out strings.Builder
+	var frames []int
+	head := true
+
+	for i := 0; i < len(body); {
+		c := body[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '-':
+			out.WriteByte(c)
+			i++
+
+		case c == '"' || c == '`':
+			n, err := quotedLen(body[i:])
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(body[i : i+n])
+			i += n
+			head = false
+
+		case c == '\'':
+			n, err := quotedLen(body[i:])
+			if err != nil {
+				return "", err
+			}
+			if n == 3 || (n == 4 && body[i+1] == '\\') {
+				// a char constant such as 'a' is valid Go template syntax
+				out.WriteString(body[i : i+n])
+			} else {
+				text := strings.ReplaceAll(body[i+1:i+n-1], `\'`, `'`)
+				out.WriteString(strconv.Quote(text))
+			}
+			i += n
+			head = false
+
+		case isWordByte(c):
+			j := i
+			for j < len(body) && isWordByte(body[j]) {
+				j++
+			}
+			word := body[i:j]
+			i = j
+
+			root, _, _ := strings.Cut(word, ".")
+			bare := word[0] != '.' && word[0] != '$' && !(word[0] >= '0' && word[0] <= '9')
+			switch {
+			case bare && root == word && i < len(body) && body[i] == '(':
+				i++
+				if head {
+					frames = append(frames, call)
+					out.WriteString(word + " ")
+				} else {
+					frames = append(frames, inner)
+					out.WriteString("(" + word + " ")
+				}
+				head = false
+			case bare && templateKeywords[word]:
+				out.WriteString(word)
+				// the pipeline of if, range, with and else if starts a new head
+				head = word == "if" || word == "range" || word == "with" || word == "else"
+			case bare && root == word && afterPipe(out.String()):
+				// a filter, so a misspelled one fails instead of reading a field
+				out.WriteString(word)
+				head = false
+			case bare && !isFunc(root) && !builtinTemplateFuncs[root] && !templateKeywords[root]:
+				out.WriteString("." + word)
+				head = false
+			default:
+				out.WriteString(word)
+				head = false
+			}
+
+		case c == '(':
+			frames = append(frames, group)
+			out.WriteByte(c)
+			i++
+			head = true
+
+		case c == ')':
+			if len(frames) == 0 {
+				return "", fmt.Errorf("unbalanced ) in %q", body)
+			}
+			if frames[len(frames)-1] != call {
+				out.WriteByte(')')
+			}
+			frames = frames[:len(frames)-1]
+			i++
+			head = false
+
+		case c == ',':
+			i++
+			if len(frames) == 0 || frames[len(frames)-1] == group {
+				out.WriteByte(c)
+				break
+			}
+			out.WriteByte(' ')
+			for i < len(body) && (body[i] == ' ' || body[i] == '\t') {
+				i++
+			}
+
+		case c == '|':
+			out.WriteByte(c)
+			i++
+			head = true
+
+		case c == ':' && strings.HasPrefix(body[i:], ":="), c == '=':
+			n := 1
+			if c == ':' {
+				n = 2
+			}
+			out.WriteString(body[i : i+n])
+			i += n
+			head = true
+
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+
+	if len(frames) > 0 {
+		return "", fmt.Errorf("unbalanced ( in %q", body)
+	}
+	return out.String(), nil
+}
+
+// afterPipe reports whether translated output ends in a pipe, so the next
+// word is a filter
+func afterPipe(out string) bool {
+	return strings.HasSuffix(strings.TrimRight(out, " \t\r\n"), "|")
+}
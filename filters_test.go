@@ -0,0 +1,95 @@
+package tmplx
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestTranslateFilters(t *testing.T) {
+	isFunc := func(name string) bool {
+		return name == "upper" || name == "truncate" || name == "join" || name == "default"
+	}
+
+	cases := map[string]string{
+		`{{ title | upper | truncate(80) }}`:          `{{ .title | upper | truncate 80 }}`,
+		`{{ .Title | upper | truncate 80 }}`:          `{{ .Title | upper | truncate 80 }}`,
+		`{{ join(tags, ', ') }}`:                      `{{ join .tags ", " }}`,
+		`{{ truncate(80, upper(name)) }}`:             `{{ truncate 80 (upper .name) }}`,
+		`{{ name | default('it\'s empty') }}`:         `{{ .name | default "it's empty" }}`,
+		`{{- if user.Admin -}}x{{ else }}y{{ end }}`:  `{{- if .user.Admin -}}x{{ else }}y{{ end }}`,
+		`{{ range $i, $t := tags }}{{ $t }}{{ end }}`: `{{ range $i, $t := .tags }}{{ $t }}{{ end }}`,
+		`{{ printf "%d" (len items) }}`:               `{{ printf "%d" (len .items) }}`,
+		`{{/* title | upper */}}`:                     `{{/* title | upper */}}`,
+		`{{ "}}" | upper }} {{ eq 'a' 97 }}`:          `{{ "}}" | upper }} {{ eq 'a' 97 }}`,
+		`{{ title | uper }}`:                          `{{ .title | uper }}`,
+	}
+	for in, expected := range cases {
+		got, err := translateFilters(in, isFunc)
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("%s: expected %s, got %s", in, expected, got)
+		}
+	}
+
+	for _, in := range []string{`{{ upper(title }}`, `{{ title) }}`, `{{ title`} {
+		if _, err := translateFilters(in, isFunc); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+	}
+}
+
+func TestFilterSyntax(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html":  `<h1>{{ block "title" . }}{{ end }}</h1>`,
+			"partials/tags.html": `{{ join(Tags, ', ') }}`,
+			"post.html":          `{{ extend("layouts/base.html") }}{{ define "title" }}{{ Title | upper | truncate(5) }}{{ end }}`,
+			"list.html":          `<p>{{include("partials/tags.html", .)}}</p>`,
+		},
+		FilterSyntax: true,
+		FuncMap: template.FuncMap{
+			"upper": strings.ToUpper,
+			"join": func(items []string, sep string) string {
+				return strings.Join(items, sep)
+			},
+			"truncate": func(n int, s string) string {
+				if len(s) > n {
+					return s[:n]
+				}
+				return s
+			},
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := H{"Title": "hello world", "Tags": []string{"go", "web"}}
+	for name, expected := range map[string]string{
+		"post.html": "<h1>HELLO</h1>",
+		"list.html": "<p>go, web</p>",
+	} {
+		result, err := engine.Render(name, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, result)
+		}
+	}
+}
+
+func TestFilterSyntaxUnknownFilter(t *testing.T) {
+	engine := New(Options{
+		Loader:       MapLoader{"post.html": `{{ Title | uper }}`},
+		FilterSyntax: true,
+		FuncMap:      template.FuncMap{"upper": strings.ToUpper},
+	})
+	if err := engine.Load(); err == nil || !strings.Contains(err.Error(), `function "uper" not defined`) {
+		t.Errorf("Expected the misspelled filter to fail, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package tmplx
+
+import "context"
+
+// FlagProvider tells whether a feature flag is on, typically by asking a
+// flag service. ctx is that of the render, such as the request's with
+// RenderHTTP, so providers can target users or tenants found in it.
+type FlagProvider interface {
+	Enabled(ctx context.Context, flag string) bool
+}
+
+// FlagFunc adapts a function to a FlagProvider
+type FlagFunc func(ctx context.Context, flag string) bool
+
+// Enabled calls f(ctx, flag)
+func (f FlagFunc) Enabled(ctx context.Context, flag string) bool {
+	return f(ctx, flag)
+}
+
+// StaticFlags is a FlagProvider with a fixed set of flags, for development
+// and tests. Flags missing from it are off.
+type StaticFlags map[string]bool
+
+// Enabled reports whether flag is on
+func (f StaticFlags) Enabled(_ context.Context, flag string) bool {
+	return f[flag]
+}
+
+// setupFlags registers the feature func unless the user defined one
+func (e *TemplateEngine) setupFlags(opts Options) {
+	if _, userDefined := opts.FuncMap["feature"]; userDefined {
+		return
+	}
+	flags := opts.FlagProvider
+	e.registerRenderFunc("feature", func(st *renderState) any {
+		return func(flag string) bool {
+			if flags == nil {
+				return false
+			}
+			ctx := st.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			return flags.Enabled(ctx, flag)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package tmplx
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+type betaKey struct{}
+
+func TestFeatureFlags(t *testing.T) {
+	loader := MapLoader{"nav.html": `{{if feature "new-nav"}}new{{else}}old{{end}}`}
+
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("nav.html", nil); result != "old" {
+		t.Errorf("Expected flags to be off without a provider, got %s", result)
+	}
+
+	engine = New(Options{Loader: loader, FlagProvider: StaticFlags{"new-nav": true}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("nav.html", nil); result != "new" {
+		t.Errorf("Expected new, got %s", result)
+	}
+
+	// flags targeting users in the render context
+	engine = New(Options{Loader: loader, FlagProvider: FlagFunc(func(ctx context.Context, flag string) bool {
+		return flag == "new-nav" && ctx.Value(betaKey{}) == true
+	})})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	beta := context.WithValue(context.Background(), betaKey{}, true)
+	tests := []struct {
+		ctx      context.Context
+		expected string
+	}{
+		{context.Background(), "old"},
+		{beta, "new"},
+	}
+	for _, tt := range tests {
+		result, err := engine.Render("nav.html", nil, WithContext(tt.ctx))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, result)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/", nil).WithContext(beta)
+	rec := httptest.NewRecorder()
+	if err := engine.RenderHTTP(rec, r, "nav.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Body.String() != "new" {
+		t.Errorf("Expected RenderHTTP to pass the request context, got %s", rec.Body.String())
+	}
+}
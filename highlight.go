@@ -0,0 +1,89 @@
+package tmplx
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Highlighter renders a block of source code as highlighted HTML for the
+// highlight func. lang is the language name given in the template, such as
+// "go", and may be empty.
+type Highlighter interface {
+	Highlight(lang, code string) (template.HTML, error)
+}
+
+// HighlighterFunc adapts a function to a Highlighter
+type HighlighterFunc func(lang, code string) (template.HTML, error)
+
+// Highlight calls f(lang, code)
+func (f HighlighterFunc) Highlight(lang, code string) (template.HTML, error) {
+	return f(lang, code)
+}
+
+// PlainCode is the highlighter used without Options.Highlighter: the code is
+// escaped into <pre><code class="language-x">, ready for a client-side
+// highlighter or plain styling
+var PlainCode Highlighter = HighlighterFunc(plainCode)
+
+func plainCode(lang, code string) (template.HTML, error) {
+	class := ""
+	if lang != "" {
+		class = ` class="language-` + template.HTMLEscapeString(lang) + `"`
+	}
+	return template.HTML("<pre><code" + class + ">" + template.HTMLEscapeString(code) + "</code></pre>"), nil
+}
+
+// ChromaHighlighter adapts chroma's quick.Highlight to a Highlighter, keeping
+// tmplx free of the dependency:
+//
+//	Highlighter: tmplx.ChromaHighlighter(quick.Highlight)
+//
+// Code is rendered with chroma's "html" formatter, which uses CSS classes;
+// the stylesheet of a style comes from html.New(html.WithClasses(true)).WriteCSS.
+// Unknown languages are guessed from the code.
+func ChromaHighlighter(highlight func(w io.Writer, source, lexer, formatter, style string) error) Highlighter {
+	return HighlighterFunc(func(lang, code string) (template.HTML, error) {
+		var buf bytes.Buffer
+		if err := highlight(&buf, code, lang, "html", ""); err != nil {
+			return "", err
+		}
+		// the html formatter writes a standalone page; keep its <pre>
+		out := buf.String()
+		start, end := strings.Index(out, "<pre"), strings.LastIndex(out, "</pre>")
+		if start < 0 || end < start {
+			return "", fmt.Errorf("chroma output has no <pre> element")
+		}
+		return template.HTML(out[start : end+len("</pre>")]), nil
+	})
+}
+
+// setupHighlight registers the highlight func unless the user defined one
+func (e *TemplateEngine) setupHighlight(opts Options) {
+	if _, userDefined := opts.FuncMap["highlight"]; userDefined {
+		return
+	}
+	h := opts.Highlighter
+	if h == nil {
+		h = PlainCode
+	}
+	e.funcMap["highlight"] = func(lang string, code interface{}) (template.HTML, error) {
+		var src string
+		switch c := code.(type) {
+		case string:
+			src = c
+		case template.HTML:
+			src = string(c)
+		case nil:
+		default:
+			return "", fmt.Errorf("highlight: expected code as a string, got %T", code)
+		}
+		out, err := h.Highlight(lang, src)
+		if err != nil {
+			return "", fmt.Errorf("highlight: %v", err)
+		}
+		return out, nil
+	}
+}
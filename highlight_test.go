@@ -0,0 +1,55 @@
+package tmplx
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	loader := MapLoader{"doc.html": `{{highlight "go" .Code}}|{{highlight "" "a < b"}}`}
+
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("doc.html", H{"Code": `fmt.Println("<hi>")`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<pre><code class="language-go">fmt.Println(&#34;&lt;hi&gt;&#34;)</code></pre>|<pre><code>a &lt; b</code></pre>`
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	// a stand-in for chroma's quick.Highlight and its standalone html output
+	quick := func(w io.Writer, source, lexer, formatter, style string) error {
+		if lexer == "" {
+			return fmt.Errorf("no lexer")
+		}
+		_, err := fmt.Fprintf(w, "<html>\n<style type=\"text/css\">.chroma{}</style><body class=\"bg\">\n"+
+			"<pre class=\"chroma\"><code><span class=\"%s\">%s</span></code></pre>\n</body>\n</html>\n", lexer, strings.ToUpper(source))
+		return err
+	}
+	engine = New(Options{Loader: loader, Highlighter: ChromaHighlighter(quick)})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Render("doc.html", H{"Code": "x := 1"}); err == nil || !strings.Contains(err.Error(), "highlight: no lexer") {
+		t.Errorf("Expected the highlighter error, got %v", err)
+	}
+
+	engine = New(Options{Loader: MapLoader{"doc.html": `{{highlight "go" .Code}}`}, Highlighter: ChromaHighlighter(quick)})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err = engine.Render("doc.html", H{"Code": "x := 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `<pre class="chroma"><code><span class="go">X := 1</span></code></pre>`
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
@@ -0,0 +1,51 @@
+package tmplx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ReloadHook runs around the reloads done by Watch
+type ReloadHook func(ctx context.Context) error
+
+// AssetCommand returns a ReloadHook running an asset build, such as
+// tailwind or esbuild, so stylesheets and scripts are rebuilt before the
+// templates using them are reloaded:
+//
+//	tmplx.AssetCommand("npx", "tailwindcss", "-i", "app.css", "-o", "static/app.css")
+func AssetCommand(name string, args ...string) ReloadHook {
+	return func(ctx context.Context) error {
+		cmd := exec.CommandContext(ctx, name, args...)
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(output.String()); msg != "" {
+				return fmt.Errorf("%s: %v: %s", name, err, msg)
+			}
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		return nil
+	}
+}
+
+// reloadHooks returns the hooks to run before and after a reload. LiveReload
+// adds to them under e.mu while Watch may be running.
+func (e *TemplateEngine) reloadHooks() (before, after []ReloadHook) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.beforeReload, e.afterReload
+}
+
+// runReloadHooks runs hooks in order, logging failures. A failing hook
+// doesn't stop the others or the reload.
+func (e *TemplateEngine) runReloadHooks(ctx context.Context, when string, hooks []ReloadHook) {
+	for i, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			e.logf(LogLoad, LogError, "[TMPLX] Error in %s reload hook %d: %v", when, i, err)
+		}
+	}
+}
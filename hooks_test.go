@@ -0,0 +1,70 @@
+package tmplx
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReloadHooks(t *testing.T) {
+	loader := signalLoader{MapLoader: MapLoader{"page.html": "v1"}, changes: make(chan struct{})}
+
+	var steps []string
+	var engine *TemplateEngine
+	engine = New(Options{
+		Loader: loader,
+		BeforeReload: []ReloadHook{
+			func(ctx context.Context) error {
+				steps = append(steps, "failing")
+				return errors.New("assets broke")
+			},
+			func(ctx context.Context) error {
+				result, _ := engine.Render("page.html", nil)
+				steps = append(steps, "before:"+result)
+				return nil
+			},
+		},
+		AfterReload: []ReloadHook{func(ctx context.Context) error {
+			result, _ := engine.Render("page.html", nil)
+			steps = append(steps, "after:"+result)
+			return nil
+		}},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- engine.Watch(ctx) }()
+
+	loader.MapLoader["page.html"] = "v2"
+	loader.changes <- struct{}{}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not return after cancel")
+	}
+
+	if got := strings.Join(steps, " "); got != "failing before:v1 after:v2" {
+		t.Errorf("Expected hooks around the reload, got %q", got)
+	}
+}
+
+func TestAssetCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	if err := AssetCommand("sh", "-c", "exit 0")(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err := AssetCommand("sh", "-c", "echo missing input.css >&2; exit 1")(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "missing input.css") {
+		t.Errorf("Expected error with command output, got %v", err)
+	}
+}
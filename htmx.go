@@ -0,0 +1,167 @@
+package tmplx
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// IsHTMX reports whether r was made by htmx and expects a fragment rather
+// than a full page. Boosted links and forms get full pages.
+func IsHTMX(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true" && r.Header.Get("HX-Boosted") != "true"
+}
+
+// Fragment is a block of a template rendered on its own for htmx
+type Fragment struct {
+	// Block is the name of the block to render
+	Block string
+
+	// Target makes the fragment an out-of-band swap of the element matching
+	// this CSS selector, e.g. "#cart-count". The block's output is wrapped in
+	// a div with hx-swap-oob, which htmx removes when swapping.
+	Target string
+
+	// Swap is how an out-of-band fragment is swapped into its target,
+	// defaulting to "innerHTML"
+	Swap string
+}
+
+// RenderBlock renders a single block of the named template, as resolved
+// after inheritance, to w, without the layout around it, e.g. a table body
+// or a modal. The block sees the same data, page variables and funcs as in
+// a full render.
+func (e *TemplateEngine) RenderBlock(w io.Writer, name, block string, data interface{}, opts ...RenderOption) error {
+	return e.RenderFragments(w, name, data, []Fragment{{Block: block}}, opts...)
+}
+
+// RenderFragments renders blocks of the named template one after another,
+// wrapping those with a Target for out-of-band swaps, so one response can
+// update the main content along with e.g. a nav or badge. The fragments go
+// through the post-processors together, as one output.
+func (e *TemplateEngine) RenderFragments(w io.Writer, name string, data interface{}, fragments []Fragment, opts ...RenderOption) error {
+	cfg := newRenderConfig(opts)
+	e, err := e.versioned(&cfg)
+	if err != nil {
+		return err
+	}
+	name = e.localeVariant(name, cfg.locale)
+	if err := e.maybeRefresh(name); err != nil {
+		return err
+	}
+	tmpl, err := e.GetTemplate(name)
+	if err != nil {
+		return err
+	}
+	for _, f := range fragments {
+		if tmpl.Lookup(f.Block) == nil {
+			return fmt.Errorf("template %s has no block %s", name, f.Block)
+		}
+	}
+
+	dst := w
+	processors := e.postProcessors(cfg)
+	var buf bytes.Buffer
+	if len(processors) > 0 {
+		w = &buf
+	}
+	w, cfg = e.prepare(w, cfg)
+	if e.instrument {
+		w = newMarkWriter(w, e, name, cfg.trace)
+	}
+	exec, done, err := e.bind(name, cfg)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	data = e.withPageVars(name, data)
+	for _, f := range fragments {
+		if f.Target != "" {
+			swap := f.Swap
+			if swap == "" {
+				swap = "innerHTML"
+			}
+			if _, err := fmt.Fprintf(w, `<div hx-swap-oob="%s">`, template.HTMLEscapeString(swap+":"+f.Target)); err != nil {
+				return err
+			}
+		}
+		if err := exec.ExecuteTemplate(w, f.Block, data); err != nil {
+			return e.renderError(name, err, fmt.Errorf("error rendering block %s of %s: %w", f.Block, name, err))
+		}
+		if f.Target != "" {
+			if _, err := io.WriteString(w, "</div>"); err != nil {
+				return err
+			}
+		}
+	}
+	if len(processors) == 0 {
+		return nil
+	}
+	out, err := postProcess(processors, name, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = dst.Write(out)
+	return err
+}
+
+// RenderHTMX answers r with the fragments of the named template if it is an
+// htmx request, and with the full page otherwise, so one handler serves
+// both:
+//
+//	engine.RenderHTMX(w, r, "pages/cart.html", data,
+//		tmplx.Fragment{Block: "items"},
+//		tmplx.Fragment{Block: "cart-count", Target: "#cart-count"})
+//
+// Without fragments, the block named after the id of the element htmx
+// swaps, sent in the HX-Target header, is rendered, so hx-target="#results"
+// gets the "results" block. Requests targeting an element with no block of
+// its name get the full page. Fragments are rendered in full before
+// anything is written.
+func (e *TemplateEngine) RenderHTMX(w http.ResponseWriter, r *http.Request, name string, data interface{}, fragments ...Fragment) error {
+	w.Header().Add("Vary", "HX-Request")
+	if len(fragments) == 0 {
+		w.Header().Add("Vary", "HX-Target")
+	}
+	if !IsHTMX(r) {
+		return e.RenderHTTP(w, r, name, data)
+	}
+	if len(fragments) == 0 {
+		block := e.targetBlock(r, name)
+		if block == "" {
+			return e.RenderHTTP(w, r, name, data)
+		}
+		fragments = []Fragment{{Block: block}}
+	}
+
+	opts := []RenderOption{WithLocale(e.requestLocale(r)), WithContext(r.Context())}
+	var buf bytes.Buffer
+	if err := e.RenderFragments(&buf, name, data, fragments, opts...); err != nil {
+		return err
+	}
+	e.setContentType(w, name)
+	w.WriteHeader(http.StatusOK)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// targetBlock returns the block of the named template the HX-Target header
+// of r names, or "" if there is none
+func (e *TemplateEngine) targetBlock(r *http.Request, name string) string {
+	target := r.Header.Get("HX-Target")
+	if target == "" {
+		return ""
+	}
+	name = e.localeVariant(name, e.requestLocale(r))
+	if err := e.maybeRefresh(name); err != nil {
+		return ""
+	}
+	tmpl, err := e.GetTemplate(name)
+	if err != nil || tmpl.Lookup(target) == nil || target == tmpl.Name() {
+		return ""
+	}
+	return target
+}
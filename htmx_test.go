@@ -0,0 +1,163 @@
+package tmplx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRenderHTMX(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html": `<nav><span id="count">{{block "count" .}}{{end}}</span></nav><main>{{block "content" .}}{{end}}</main>`,
+			"cart.html":         `{{extend "layouts/base.html"}}{{define "count"}}{{len .Items}}{{end}}{{define "content"}}<ul>{{range .Items}}<li>{{.}}</li>{{end}}</ul>{{end}}`,
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	data := H{"Items": []string{"a", "b"}}
+	fragments := []Fragment{{Block: "content"}, {Block: "count", Target: "#count"}}
+
+	req := httptest.NewRequest("GET", "/cart", nil)
+	rec := httptest.NewRecorder()
+	if err := engine.RenderHTMX(rec, req, "cart.html", data, fragments...); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != `<nav><span id="count">2</span></nav><main><ul><li>a</li><li>b</li></ul></main>` {
+		t.Errorf("Expected full page for plain request, got %s", got)
+	}
+	if rec.Header().Get("Vary") != "HX-Request" {
+		t.Errorf("Expected Vary: HX-Request, got %q", rec.Header().Get("Vary"))
+	}
+
+	req.Header.Set("HX-Request", "true")
+	rec = httptest.NewRecorder()
+	if err := engine.RenderHTMX(rec, req, "cart.html", data, fragments...); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != `<ul><li>a</li><li>b</li></ul><div hx-swap-oob="innerHTML:#count">2</div>` {
+		t.Errorf("Expected fragments for htmx request, got %s", got)
+	}
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("Expected HTML 200 response, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	req.Header.Set("HX-Boosted", "true")
+	if IsHTMX(req) {
+		t.Error("Expected boosted requests to get full pages")
+	}
+
+	var b strings.Builder
+	if err := engine.RenderBlock(&b, "cart.html", "missing", data); err == nil {
+		t.Error("Expected error for missing block, got nil")
+	}
+	rec = httptest.NewRecorder()
+	req.Header.Del("HX-Boosted")
+	if err := engine.RenderHTMX(rec, req, "cart.html", data, Fragment{Block: "missing"}); err == nil || rec.Body.Len() != 0 {
+		t.Errorf("Expected error and nothing written for missing block, got %v %q", err, rec.Body.String())
+	}
+}
+
+func TestRenderBlock(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html": `<title>{{.Page.Title}}</title><main>{{block "content" .}}{{end}}</main>`,
+			"users.html":        `{{extend "layouts/base.html"}}{{var "title" "Users"}}{{define "content"}}<h1>{{.Page.Title}}</h1>{{template "rows" .}}{{end}}{{define "rows"}}{{range .Users}}<tr><td>{{.}}</td></tr>{{end}}{{end}}`,
+			"users.de.html":     `{{extend "layouts/base.html"}}{{var "title" "Benutzer"}}{{define "content"}}<h1>{{.Page.Title}}</h1>{{end}}`,
+		},
+		Locales: []string{"en", "de"},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	data := H{"Users": []string{"ada", "bob"}}
+
+	var b strings.Builder
+	if err := engine.RenderBlock(&b, "users.html", "rows", data); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != `<tr><td>ada</td></tr><tr><td>bob</td></tr>` {
+		t.Errorf("Expected the rows alone, got %s", got)
+	}
+
+	// page variables and locale variants apply as in a full render
+	b.Reset()
+	if err := engine.RenderBlock(&b, "users.html", "content", data, WithLocale("de")); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != `<h1>Benutzer</h1>` {
+		t.Errorf("Expected the German block with its page title, got %s", got)
+	}
+
+	b.Reset()
+	if err := engine.RenderBlock(&b, "users.html", "modal", data); err == nil || !strings.Contains(err.Error(), "has no block modal") {
+		t.Errorf("Expected a missing block error, got %v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("Expected nothing written for a missing block, got %q", b.String())
+	}
+}
+
+func TestRenderHTMXTarget(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html": `<main>{{block "content" .}}{{end}}</main>`,
+			"search.html":       `{{extend "layouts/base.html"}}{{define "content"}}<input><ul id="results">{{block "results" .}}{{range .}}<li>{{.}}</li>{{end}}{{end}}</ul>{{end}}`,
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	data := []string{"a", "b"}
+	render := func(target string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/search", nil)
+		req.Header.Set("HX-Request", "true")
+		if target != "" {
+			req.Header.Set("HX-Target", target)
+		}
+		rec := httptest.NewRecorder()
+		if err := engine.RenderHTMX(rec, req, "search.html", data); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	rec := render("results")
+	if got := rec.Body.String(); got != `<li>a</li><li>b</li>` {
+		t.Errorf("Expected the targeted block, got %s", got)
+	}
+	if vary := rec.Header().Values("Vary"); !containsString(vary, "HX-Target") {
+		t.Errorf("Expected Vary: HX-Target, got %q", vary)
+	}
+	if got := render("sidebar").Body.String(); !strings.HasPrefix(got, "<main>") {
+		t.Errorf("Expected the full page for a target without a block, got %s", got)
+	}
+	if got := render("").Body.String(); !strings.HasPrefix(got, "<main>") {
+		t.Errorf("Expected the full page without a target, got %s", got)
+	}
+}
+
+func TestRenderHTMXContext(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{
+		"page.html": `<main>{{block "user" .}}{{ctx "user"}}{{end}}</main>`,
+	}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ContextWithStore(context.Background())
+	Set(ctx, "user", "ada")
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	if err := engine.RenderHTMX(rec, req, "page.html", nil, Fragment{Block: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != "ada" {
+		t.Errorf("Expected the fragment to see the request's context, got %q", got)
+	}
+}
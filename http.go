@@ -0,0 +1,197 @@
+package tmplx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Write renders the named template as an HTTP response with the given status
+// code. Output is streamed to w, or rendered in full first when the engine
+// was created with BufferOutput. If the template does not exist, or a
+// buffered render fails, nothing is written so the caller can still respond
+// with an error page.
+func (e *TemplateEngine) Write(w http.ResponseWriter, status int, name string, data interface{}, opts ...RenderOption) error {
+	return e.write(w, status, name, data, newRenderConfig(opts))
+}
+
+func (e *TemplateEngine) write(w http.ResponseWriter, status int, name string, data interface{}, cfg renderConfig) error {
+	e, err := e.versioned(&cfg)
+	if err != nil {
+		return err
+	}
+	if err := e.maybeRefresh(name); err != nil {
+		return err
+	}
+	if _, ok := e.executor(name); !ok {
+		return notFound(name)
+	}
+
+	if e.buffer {
+		var buf bytes.Buffer
+		if err := e.renderTo(&buf, name, data, cfg); err != nil {
+			return err
+		}
+		e.setContentType(w, name)
+		w.WriteHeader(status)
+		_, err := buf.WriteTo(w)
+		return err
+	}
+
+	e.setContentType(w, name)
+	w.WriteHeader(status)
+	return e.renderTo(w, name, data, cfg)
+}
+
+// RenderHTTP renders the named template as a 200 response to r. With an
+// ETagMode configured it sets an ETag header and answers matching
+// If-None-Match requests with 304 Not Modified. Unless a locale is passed
+// with WithLocale, the render uses the locale from the request context or
+// one negotiated from the request.
+func (e *TemplateEngine) RenderHTTP(w http.ResponseWriter, r *http.Request, name string, data interface{}, opts ...RenderOption) error {
+	cfg := newRenderConfig(opts)
+	if cfg.locale == "" {
+		cfg.locale = e.requestLocale(r)
+	}
+	if cfg.ctx == nil && r != nil {
+		cfg.ctx = r.Context()
+	}
+	e, err := e.versioned(&cfg)
+	if err != nil {
+		return err
+	}
+	name = e.localeVariant(name, cfg.locale)
+
+	switch e.etag {
+	case ETagInputs:
+		if encoded, err := json.Marshal(data); err == nil && e.inputsCover(cfg) {
+			if digest, err := e.Hash(name); err == nil {
+				tag := strongETag([]byte(name), []byte(digest), []byte(cfg.locale), encoded)
+				w.Header().Set("ETag", tag)
+				if etagMatches(r, tag) {
+					w.WriteHeader(http.StatusNotModified)
+					return nil
+				}
+			}
+			return e.write(w, http.StatusOK, name, data, cfg)
+		}
+		return e.renderWithOutputETag(w, r, name, data, cfg)
+	case ETagOutput:
+		return e.renderWithOutputETag(w, r, name, data, cfg)
+	default:
+		return e.write(w, http.StatusOK, name, data, cfg)
+	}
+}
+
+// RenderJSON writes data, exactly as the named template would receive it, as
+// a JSON 200 response instead of rendering the template. It lets one handler
+// serve both a page and its JSON variant. The template must exist, so both
+// variants fail alike for unknown pages, and nothing is written if data
+// cannot be marshaled.
+func (e *TemplateEngine) RenderJSON(w http.ResponseWriter, name string, data interface{}, opts ...RenderOption) error {
+	cfg := newRenderConfig(opts)
+	e, err := e.versioned(&cfg)
+	if err != nil {
+		return err
+	}
+	if _, ok := e.executor(name); !ok {
+		return notFound(name)
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error encoding data for %s: %v", name, err)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(append(encoded, '\n'))
+	return err
+}
+
+func defaultContentType(b Backend) string {
+	if b == TextBackend {
+		return "text/plain; charset=utf-8"
+	}
+	return "text/html; charset=utf-8"
+}
+
+// setContentType sets the content type of the named template unless the
+// handler already chose one, so browsers never have to sniff rendered
+// responses.
+func (e *TemplateEngine) setContentType(w http.ResponseWriter, name string) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", e.contentType(name))
+	}
+}
+
+// Handler returns an http.Handler that renders the named template for GET
+// requests. HEAD requests get the same headers, including Content-Length,
+// without a body, and any other method is answered with 405 Method Not
+// Allowed. data, if not nil, builds the template data for each request.
+//
+// The response is buffered so that a failing render can still be answered
+// with a clean 500 Internal Server Error.
+func (e *TemplateEngine) Handler(name string, data func(r *http.Request) interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var d interface{}
+		if data != nil {
+			d = data(r)
+		}
+
+		buf := &responseBuffer{header: w.Header()}
+		if err := e.RenderHTTP(buf, r, name, d); err != nil {
+			e.logf(LogRender, LogError, "[TMPLX] Error rendering %s: %v", name, err)
+			w.Header().Del("ETag")
+			w.Header().Del("Content-Type")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		status := buf.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status != http.StatusNotModified {
+			w.Header().Set("Content-Length", strconv.Itoa(buf.body.Len()))
+		}
+		w.WriteHeader(status)
+
+		if r.Method != http.MethodHead {
+			_, _ = buf.body.WriteTo(w)
+		}
+	})
+}
+
+// responseBuffer captures a response in memory so it can be inspected or
+// discarded before anything reaches the client. Headers are written straight
+// to the header map of the real response.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
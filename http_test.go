@@ -0,0 +1,215 @@
+package tmplx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newHTTPTestEngine(t *testing.T, opts Options) *TemplateEngine {
+	t.Helper()
+
+	if opts.FS == nil {
+		opts.FS = fstest.MapFS{
+			"layouts/base.html": &fstest.MapFile{
+				Data: []byte(`<title>{{block "title" .}}Site{{end}}</title><main>{{block "content" .}}{{end}}</main>`),
+			},
+			"pages/home.html": &fstest.MapFile{
+				Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}<h1>{{.Title}}</h1>{{end}}`),
+			},
+			"pages/broken.html": &fstest.MapFile{
+				Data: []byte(`<p>partial</p>{{index .Items 3}}`),
+			},
+		}
+	}
+
+	engine := New(opts)
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+func TestWrite(t *testing.T) {
+	engine := newHTTPTestEngine(t, Options{})
+
+	prev := DefaultEngine
+	DefaultEngine = engine
+	defer func() { DefaultEngine = prev }()
+
+	rec := httptest.NewRecorder()
+	if err := Write(rec, http.StatusCreated, "pages/home.html", H{"Title": "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, rec.Code)
+	}
+	containsAll(t, []string{"<main><h1>Hi</h1></main>"}, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	if err := Write(rec, http.StatusOK, "pages/missing.html", nil); err == nil {
+		t.Fatal("Expected error for missing template, got nil")
+	}
+	if rec.Body.Len() != 0 || rec.Code != http.StatusOK {
+		t.Errorf("Expected nothing written for missing template, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWriteContentType(t *testing.T) {
+	engine := newHTTPTestEngine(t, Options{})
+
+	rec := httptest.NewRecorder()
+	if err := engine.Write(rec, http.StatusOK, "pages/home.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Expected default content type, got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/xhtml+xml")
+	if err := engine.Write(rec, http.StatusOK, "pages/home.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/xhtml+xml" {
+		t.Errorf("Expected existing content type to be kept, got %q", got)
+	}
+
+	engine = newHTTPTestEngine(t, Options{ContentType: "text/html; charset=iso-8859-1"})
+	rec = httptest.NewRecorder()
+	if err := engine.Write(rec, http.StatusOK, "pages/home.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/html; charset=iso-8859-1" {
+		t.Errorf("Expected configured content type, got %q", got)
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	engine := newHTTPTestEngine(t, Options{})
+
+	rec := httptest.NewRecorder()
+	if err := engine.RenderJSON(rec, "pages/home.html", H{"Title": "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+		t.Errorf("Expected JSON content type, got %q", got)
+	}
+	if got := rec.Body.String(); got != "{\"Title\":\"Hi\"}\n" {
+		t.Errorf("Expected data as JSON, got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	if err := engine.RenderJSON(rec, "pages/missing.html", H{}); err == nil {
+		t.Error("Expected error for missing template, got nil")
+	}
+	if err := engine.RenderJSON(rec, "pages/home.html", H{"Fn": func() {}}); err == nil {
+		t.Error("Expected error for unencodable data, got nil")
+	}
+	if rec.Body.Len() != 0 || len(rec.Header()) != 0 {
+		t.Errorf("Expected nothing written on errors, got %q", rec.Body.String())
+	}
+}
+
+func TestHandler(t *testing.T) {
+	engine := newHTTPTestEngine(t, Options{})
+	handler := engine.Handler("pages/home.html", func(r *http.Request) interface{} {
+		return H{"Title": r.URL.Query().Get("title")}
+	})
+
+	t.Run("GET renders the page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?title=Docs", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		containsAll(t, []string{"<h1>Docs</h1>"}, rec.Body.String())
+		if rec.Header().Get("Content-Length") != strconv.Itoa(rec.Body.Len()) {
+			t.Errorf("Expected Content-Length %d, got %q", rec.Body.Len(), rec.Header().Get("Content-Length"))
+		}
+	})
+
+	t.Run("HEAD sends headers without a body", func(t *testing.T) {
+		get := httptest.NewRecorder()
+		handler.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/?title=Docs", nil))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/?title=Docs", nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+			t.Fatalf("Expected empty 200, got %d %q", rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("Content-Length") != get.Header().Get("Content-Length") {
+			t.Errorf("Expected HEAD Content-Length %q, got %q", get.Header().Get("Content-Length"), rec.Header().Get("Content-Length"))
+		}
+		if rec.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+			t.Errorf("Expected Content-Type on HEAD, got %q", rec.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("Other methods are not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("Expected 405, got %d", rec.Code)
+		}
+		if rec.Header().Get("Allow") != "GET, HEAD" {
+			t.Errorf("Expected Allow header, got %q", rec.Header().Get("Allow"))
+		}
+	})
+
+	t.Run("Render errors become 500", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		engine.Handler("pages/broken.html", nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected 500, got %d", rec.Code)
+		}
+		if strings.Contains(rec.Body.String(), "partial") {
+			t.Errorf("Expected no partial output, got %q", rec.Body.String())
+		}
+	})
+}
+
+func TestBufferOutput(t *testing.T) {
+	data := H{"Items": []string{"a"}}
+
+	t.Run("Streaming writes partial output", func(t *testing.T) {
+		engine := newHTTPTestEngine(t, Options{})
+		rec := httptest.NewRecorder()
+		if err := engine.Write(rec, http.StatusOK, "pages/broken.html", data); err == nil {
+			t.Fatal("Expected render error, got nil")
+		}
+		containsAll(t, []string{"<p>partial</p>"}, rec.Body.String())
+	})
+
+	t.Run("Buffered writes nothing on error", func(t *testing.T) {
+		engine := newHTTPTestEngine(t, Options{BufferOutput: true})
+
+		rec := httptest.NewRecorder()
+		if err := engine.Write(rec, http.StatusOK, "pages/broken.html", data); err == nil {
+			t.Fatal("Expected render error, got nil")
+		}
+		if rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "" {
+			t.Errorf("Expected nothing written, got %q %v", rec.Body.String(), rec.Header())
+		}
+
+		var out strings.Builder
+		if err := engine.RenderResponse(&out, "pages/broken.html", data); err == nil {
+			t.Fatal("Expected render error, got nil")
+		}
+		if out.Len() != 0 {
+			t.Errorf("Expected nothing written, got %q", out.String())
+		}
+
+		rec = httptest.NewRecorder()
+		if err := engine.Write(rec, http.StatusAccepted, "pages/home.html", H{"Title": "Ok"}); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusAccepted {
+			t.Errorf("Expected 202, got %d", rec.Code)
+		}
+		containsAll(t, []string{"<h1>Ok</h1>"}, rec.Body.String())
+	})
+}
@@ -0,0 +1,184 @@
+package tmplx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPLoader is a Loader fetching templates from a URL prefix such as an S3
+// bucket or CDN, so templates can be published independently of deploys.
+// Template name is fetched from BaseURL + name.
+//
+// Responses are cached in memory and revalidated with If-None-Match, so
+// unchanged templates cost a 304. If a fetch fails, the cached copy is served
+// instead. As a Watcher it revalidates every template each Refresh and
+// reports a change when any of them, or the manifest, differs.
+type HTTPLoader struct {
+	// BaseURL is the prefix template names are appended to
+	BaseURL string
+
+	// Names lists the templates to fetch. If empty, they are read from
+	// Manifest instead.
+	Names []string
+
+	// Manifest is the path below BaseURL of a JSON array of template names,
+	// "manifest.json" if empty. It is only used when Names is empty.
+	Manifest string
+
+	// Client makes the requests, http.DefaultClient if nil
+	Client *http.Client
+
+	// Refresh is how often Watch revalidates, 1 minute if zero
+	Refresh time.Duration
+
+	mu    sync.Mutex
+	cache map[string]httpEntry
+}
+
+type httpEntry struct {
+	etag    string
+	content []byte
+}
+
+func (l *HTTPLoader) client() *http.Client {
+	if l.Client == nil {
+		return http.DefaultClient
+	}
+	return l.Client
+}
+
+func (l *HTTPLoader) manifest() string {
+	if l.Manifest == "" {
+		return "manifest.json"
+	}
+	return l.Manifest
+}
+
+// fetch returns the content of path below BaseURL, revalidating the cached
+// copy if there is one. changed reports whether the content differs from the
+// cached copy.
+func (l *HTTPLoader) fetch(ctx context.Context, path string) (content []byte, changed bool, err error) {
+	l.mu.Lock()
+	cached, ok := l.cache[path]
+	l.mu.Unlock()
+
+	content, etag, err := l.get(ctx, path, cached.etag)
+	if err != nil {
+		if ok {
+			return cached.content, false, nil
+		}
+		return nil, false, err
+	}
+	if content == nil {
+		return cached.content, false, nil
+	}
+
+	l.mu.Lock()
+	if l.cache == nil {
+		l.cache = make(map[string]httpEntry)
+	}
+	l.cache[path] = httpEntry{etag: etag, content: content}
+	l.mu.Unlock()
+	return content, ok && string(content) != string(cached.content), nil
+}
+
+// get requests path, returning nil content if the server answered 304
+func (l *HTTPLoader) get(ctx context.Context, path, etag string) ([]byte, string, error) {
+	url := strings.TrimSuffix(l.BaseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := l.client().Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, nil
+	case http.StatusOK:
+		content, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, "", fmt.Errorf("error fetching %s: %v", url, err)
+		}
+		return content, resp.Header.Get("ETag"), nil
+	}
+	return nil, "", fmt.Errorf("error fetching %s: %s", url, resp.Status)
+}
+
+func (l *HTTPLoader) names(ctx context.Context) ([]string, bool, error) {
+	if len(l.Names) > 0 {
+		return l.Names, false, nil
+	}
+	content, changed, err := l.fetch(ctx, l.manifest())
+	if err != nil {
+		return nil, false, err
+	}
+	var names []string
+	if err := json.Unmarshal(content, &names); err != nil {
+		return nil, false, fmt.Errorf("error parsing manifest %s: %v", l.manifest(), err)
+	}
+	return names, changed, nil
+}
+
+func (l *HTTPLoader) List() ([]string, error) {
+	names, _, err := l.names(context.Background())
+	return names, err
+}
+
+func (l *HTTPLoader) Read(name string) ([]byte, error) {
+	content, _, err := l.fetch(context.Background(), name)
+	return content, err
+}
+
+func (l *HTTPLoader) Watch(ctx context.Context, changed func()) error {
+	refresh := l.Refresh
+	if refresh <= 0 {
+		refresh = time.Minute
+	}
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if l.revalidate(ctx) {
+				changed()
+			}
+		}
+	}
+}
+
+// revalidate refreshes the manifest and every listed template, reporting
+// whether any of them changed
+func (l *HTTPLoader) revalidate(ctx context.Context) bool {
+	names, updated, err := l.names(ctx)
+	if err != nil {
+		return false
+	}
+	for _, name := range names {
+		l.mu.Lock()
+		_, known := l.cache[name]
+		l.mu.Unlock()
+
+		_, changed, err := l.fetch(ctx, name)
+		if err == nil && (changed || !known) {
+			updated = true
+		}
+	}
+	return updated
+}
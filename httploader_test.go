@@ -0,0 +1,126 @@
+package tmplx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type templateServer struct {
+	mu          sync.Mutex
+	files       map[string]string
+	notModified int
+}
+
+func (s *templateServer) set(name, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[name] = content
+}
+
+func (s *templateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := strings.TrimPrefix(r.URL.Path, "/tmpl/")
+	content, ok := s.files[name]
+	if name == "manifest.json" {
+		var names []string
+		for n := range s.files {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		data, _ := json.Marshal(names)
+		content, ok = string(data), true
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(content))
+	etag := fmt.Sprintf(`"%x"`, h.Sum64())
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	fmt.Fprint(w, content)
+}
+
+func TestHTTPLoader(t *testing.T) {
+	files := &templateServer{files: map[string]string{
+		"layouts/base.html": `<body>{{block "content" .}}{{end}}</body>`,
+		"pages/home.html":   `{{extend "layouts/base.html"}}{{define "content"}}v1{{end}}`,
+	}}
+	srv := httptest.NewServer(files)
+	defer srv.Close()
+
+	loader := &HTTPLoader{BaseURL: srv.URL + "/tmpl/", Refresh: 10 * time.Millisecond}
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "<body>v1</body>" {
+		t.Errorf("Unexpected output %q", result)
+	}
+
+	// unchanged templates are revalidated, not downloaded again
+	if err := engine.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if files.notModified != 3 {
+		t.Errorf("Expected 3 not modified responses, got %d", files.notModified)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan struct{}, 1)
+	go loader.Watch(ctx, func() {
+		if err := engine.Reload(); err != nil {
+			t.Error(err)
+		}
+		reloaded <- struct{}{}
+	})
+
+	files.set("pages/home.html", `{{extend "layouts/base.html"}}{{define "content"}}v2{{end}}`)
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("Expected refresh to detect the update")
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "<body>v2</body>" {
+		t.Errorf("Expected updated output, got %q", result)
+	}
+
+	// the cached copy is served while the server is unreachable
+	srv.Close()
+	cancel()
+	if content, err := loader.Read("layouts/base.html"); err != nil || !strings.Contains(string(content), "<body>") {
+		t.Errorf("Expected cached content, got %q, %v", content, err)
+	}
+}
+
+func TestHTTPLoaderNames(t *testing.T) {
+	srv := httptest.NewServer(&templateServer{files: map[string]string{"a.html": "A", "b.html": "B"}})
+	defer srv.Close()
+
+	loader := &HTTPLoader{BaseURL: srv.URL + "/tmpl", Names: []string{"a.html"}}
+	names, err := loader.List()
+	if err != nil || len(names) != 1 || names[0] != "a.html" {
+		t.Errorf("Expected only the configured name, got %v, %v", names, err)
+	}
+	if _, err := loader.Read("missing.html"); err == nil {
+		t.Error("Expected error fetching a missing template")
+	}
+}
@@ -0,0 +1,195 @@
+package tmplx
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeNow is the clock of timeago, replaced in tests
+var timeNow = time.Now
+
+// localeFormat holds the number format and words of a language for the
+// humanize and money funcs
+type localeFormat struct {
+	decimal string
+	group   string
+
+	// currencyAfter places currency symbols after amounts, as in "5,00 €"
+	currencyAfter bool
+
+	justNow string
+	past    string // e.g. "%s ago"
+	future  string // e.g. "in %s"
+
+	// units are the singular and plural of second, minute, hour, day,
+	// month and year, as used in past and future
+	units [6][2]string
+
+	// bytes are the byte unit symbols from B to TB
+	bytes [5]string
+}
+
+var localeFormats = map[string]*localeFormat{
+	"en": {
+		decimal: ".", group: ",",
+		justNow: "just now", past: "%s ago", future: "in %s",
+		units: [6][2]string{{"second", "seconds"}, {"minute", "minutes"}, {"hour", "hours"}, {"day", "days"}, {"month", "months"}, {"year", "years"}},
+		bytes: [5]string{"B", "kB", "MB", "GB", "TB"},
+	},
+	"de": {
+		decimal: ",", group: ".", currencyAfter: true,
+		justNow: "gerade eben", past: "vor %s", future: "in %s",
+		units: [6][2]string{{"Sekunde", "Sekunden"}, {"Minute", "Minuten"}, {"Stunde", "Stunden"}, {"Tag", "Tagen"}, {"Monat", "Monaten"}, {"Jahr", "Jahren"}},
+		bytes: [5]string{"B", "kB", "MB", "GB", "TB"},
+	},
+	"fr": {
+		decimal: ",", group: "\u202f", currencyAfter: true,
+		justNow: "à l’instant", past: "il y a %s", future: "dans %s",
+		units: [6][2]string{{"seconde", "secondes"}, {"minute", "minutes"}, {"heure", "heures"}, {"jour", "jours"}, {"mois", "mois"}, {"an", "ans"}},
+		bytes: [5]string{"o", "ko", "Mo", "Go", "To"},
+	},
+	"es": {
+		decimal: ",", group: ".", currencyAfter: true,
+		justNow: "justo ahora", past: "hace %s", future: "dentro de %s",
+		units: [6][2]string{{"segundo", "segundos"}, {"minuto", "minutos"}, {"hora", "horas"}, {"día", "días"}, {"mes", "meses"}, {"año", "años"}},
+		bytes: [5]string{"B", "kB", "MB", "GB", "TB"},
+	},
+}
+
+// lookupLocaleFormat returns the format of a locale, falling back to
+// English
+func lookupLocaleFormat(locale string) *localeFormat {
+	if l, ok := localeFormats[strings.ToLower(primaryLanguage(locale))]; ok {
+		return l
+	}
+	return localeFormats["en"]
+}
+
+// setupHumanize registers the timeago, duration and humanizeBytes funcs
+// unless the user defined their own. timeago and humanizeBytes follow the
+// render locale.
+func (e *TemplateEngine) setupHumanize(opts Options) {
+	if _, userDefined := opts.FuncMap["timeago"]; !userDefined {
+		e.registerRenderFunc("timeago", func(st *renderState) any {
+			return func(t time.Time) string { return timeAgo(t, timeNow(), lookupLocaleFormat(st.locale)) }
+		})
+	}
+	if _, userDefined := opts.FuncMap["duration"]; !userDefined {
+		e.funcMap["duration"] = humanizeDuration
+	}
+	if _, userDefined := opts.FuncMap["humanizeBytes"]; !userDefined {
+		e.registerRenderFunc("humanizeBytes", func(st *renderState) any {
+			return func(n interface{}) (string, error) { return humanizeBytes(n, lookupLocaleFormat(st.locale)) }
+		})
+	}
+}
+
+// timeAgo describes t relative to now: "just now", "3 minutes ago" or
+// "in 2 days"
+func timeAgo(t, now time.Time, l *localeFormat) string {
+	d := now.Sub(t)
+	format := l.past
+	if d < 0 {
+		d, format = -d, l.future
+	}
+	if d < 45*time.Second {
+		return l.justNow
+	}
+
+	steps := []struct {
+		limit, unit time.Duration
+	}{
+		{45 * time.Minute, time.Minute},
+		{22 * time.Hour, time.Hour},
+		{26 * 24 * time.Hour, 24 * time.Hour},
+		{320 * 24 * time.Hour, 30 * 24 * time.Hour},
+		{math.MaxInt64, 365 * 24 * time.Hour},
+	}
+	unit, n := 0, int64(0)
+	for i, s := range steps {
+		if d < s.limit {
+			unit, n = i+1, int64(math.Round(float64(d)/float64(s.unit)))
+			break
+		}
+	}
+	if n < 1 {
+		n = 1
+	}
+	word := l.units[unit][1]
+	if n == 1 {
+		word = l.units[unit][0]
+	}
+	return fmt.Sprintf(format, strconv.FormatInt(n, 10)+" "+word)
+}
+
+// humanizeDuration writes a duration with its two largest units, such as
+// "2h 5m" or "3m 20s", or in milliseconds when under a second
+func humanizeDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + humanizeDuration(-d)
+	}
+	if d < time.Second {
+		return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+	}
+
+	units := []struct {
+		size   time.Duration
+		suffix string
+	}{{24 * time.Hour, "d"}, {time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}}
+	for i, u := range units {
+		if d < u.size {
+			continue
+		}
+		s := strconv.FormatInt(int64(d/u.size), 10) + u.suffix
+		if i+1 < len(units) {
+			if rest := d % u.size / units[i+1].size; rest > 0 {
+				s += " " + strconv.FormatInt(int64(rest), 10) + units[i+1].suffix
+			}
+		}
+		return s
+	}
+	return ""
+}
+
+// humanizeBytes writes a byte count in decimal units, with one decimal
+// below 10: "512 B", "1.5 MB", "24 GB"
+func humanizeBytes(v interface{}, l *localeFormat) (string, error) {
+	n, err := toFloat(v)
+	if err != nil {
+		return "", fmt.Errorf("humanizeBytes: %v", err)
+	}
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	unit := 0
+	for n >= 999.5 && unit < len(l.bytes)-1 {
+		n /= 1000
+		unit++
+	}
+	var s string
+	if unit == 0 || n >= 9.95 {
+		s = strconv.FormatFloat(math.Round(n), 'f', 0, 64)
+	} else {
+		s = strings.Replace(strconv.FormatFloat(n, 'f', 1, 64), ".", l.decimal, 1)
+	}
+	return sign + s + " " + l.bytes[unit], nil
+}
+
+// toFloat converts any integer or float value to a float64
+func toFloat(v interface{}) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", v)
+}
@@ -0,0 +1,79 @@
+package tmplx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanize(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	defer func(clock func() time.Time) { timeNow = clock }(timeNow)
+	timeNow = func() time.Time { return base }
+
+	engine := New(Options{
+		Loader: MapLoader{
+			"page.html": `{{timeago .Posted}}|{{timeago .Due}}|{{duration .Took}}|{{humanizeBytes .Size}}`,
+		},
+		Locales: []string{"en", "de", "fr"},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := H{
+		"Posted": base.Add(-3 * time.Minute),
+		"Due":    base.Add(49 * time.Hour),
+		"Took":   2*time.Hour + 5*time.Minute + 3*time.Second,
+		"Size":   1500000,
+	}
+	for locale, expected := range map[string]string{
+		"en": "3 minutes ago|in 2 days|2h 5m|1.5 MB",
+		"de": "vor 3 Minuten|in 2 Tagen|2h 5m|1,5 MB",
+		"fr": "il y a 3 minutes|dans 2 jours|2h 5m|1,5 Mo",
+	} {
+		result, err := engine.Render("page.html", data, WithLocale(locale))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %q, got %q", locale, expected, result)
+		}
+	}
+
+	en := lookupLocaleFormat("en-US")
+	for d, expected := range map[time.Duration]string{
+		10 * time.Second:     "just now",
+		-time.Hour:           "in 1 hour",
+		23 * time.Hour:       "1 day ago",
+		40 * 24 * time.Hour:  "1 month ago",
+		400 * 24 * time.Hour: "1 year ago",
+	} {
+		if got := timeAgo(base.Add(-d), base, en); got != expected {
+			t.Errorf("timeAgo(%v): expected %q, got %q", d, expected, got)
+		}
+	}
+	for d, expected := range map[time.Duration]string{
+		250 * time.Millisecond:      "250ms",
+		45 * time.Second:            "45s",
+		3*time.Minute + time.Second: "3m 1s",
+		26 * time.Hour:              "1d 2h",
+		-90 * time.Second:           "-1m 30s",
+	} {
+		if got := humanizeDuration(d); got != expected {
+			t.Errorf("duration(%v): expected %q, got %q", d, expected, got)
+		}
+	}
+	for n, expected := range map[interface{}]string{
+		512:           "512 B",
+		int64(999999): "1.0 MB",
+		uint(24e9):    "24 GB",
+		12345.0:       "12 kB",
+	} {
+		if got, _ := humanizeBytes(n, en); got != expected {
+			t.Errorf("humanizeBytes(%v): expected %q, got %q", n, expected, got)
+		}
+	}
+	if _, err := humanizeBytes("1kb", en); err == nil {
+		t.Error("Expected error for non-numeric size, got nil")
+	}
+}
@@ -0,0 +1,93 @@
+package tmplx
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// imageVariant returns the name of the variant of an image resized to
+// width, by default "img/hero.jpg" to "img/hero-480w.jpg"
+func (a *Assets) imageVariant(name string, width int) string {
+	if a.ImageVariant != nil {
+		return a.ImageVariant(name, width)
+	}
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + strconv.Itoa(width) + "w" + ext
+}
+
+// Image returns an <img> element for the named image asset. Int arguments
+// are the widths its variants were built at, listed in srcset; string
+// arguments are attributes written "name=value", such as "alt=A dog",
+// "sizes=(min-width: 800px) 50vw, 100vw" or "class=hero", or "lazy" for
+// loading="lazy" and decoding="async". Alt text is required; decorative
+// images pass "alt=". It backs the img func:
+//
+//	{{img "hero.jpg" "alt=Mountains at dawn" "sizes=100vw" "lazy" 480 960 1440}}
+func (a *Assets) Image(name string, args ...interface{}) (template.HTML, error) {
+	var widths []int
+	var attrs []htmlAttribute
+	lazy, hasAlt := false, false
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case int:
+			if v <= 0 {
+				return "", fmt.Errorf("img %s: invalid width %d", name, v)
+			}
+			widths = append(widths, v)
+		case string:
+			if v == "lazy" {
+				lazy = true
+				continue
+			}
+			attr, value, ok := strings.Cut(v, "=")
+			attr = strings.ToLower(strings.TrimSpace(attr))
+			if !ok || !isAttrName(attr) || attr == "src" || attr == "srcset" {
+				return "", fmt.Errorf("img %s: invalid attribute %q", name, v)
+			}
+			hasAlt = hasAlt || attr == "alt"
+			attrs = append(attrs, htmlAttribute{attr, value})
+		default:
+			return "", fmt.Errorf("img %s: unexpected argument %v of type %T", name, arg, arg)
+		}
+	}
+	if !hasAlt {
+		return "", fmt.Errorf("img %s: alt is required, use \"alt=\" for decorative images", name)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<img src="%s"`, template.HTMLEscapeString(a.URL(name)))
+	if len(widths) > 0 {
+		sort.Ints(widths)
+		candidates := make([]string, len(widths))
+		for i, w := range widths {
+			candidates[i] = a.URL(a.imageVariant(name, w)) + " " + strconv.Itoa(w) + "w"
+		}
+		fmt.Fprintf(&b, ` srcset="%s"`, template.HTMLEscapeString(strings.Join(candidates, ", ")))
+	}
+	for _, attr := range attrs {
+		fmt.Fprintf(&b, ` %s="%s"`, attr.name, template.HTMLEscapeString(attr.value))
+	}
+	if lazy {
+		b.WriteString(` loading="lazy" decoding="async"`)
+	}
+	b.WriteString(">")
+	return template.HTML(b.String()), nil
+}
+
+// isAttrName reports whether s is a safe attribute name to write, rejecting
+// event handlers
+func isAttrName(s string) bool {
+	if s == "" || strings.HasPrefix(s, "on") {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImage(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"page.html":   `{{img "img/hero.jpg" "alt=Mountains & lakes" "sizes=(min-width: 800px) 50vw, 100vw" "lazy" 960 480}}`,
+			"plain.html":  `{{img "logo.png" "alt=" "class=logo"}}`,
+			"noalt.html":  `{{img "logo.png" 480}}`,
+			"onload.html": `{{img "logo.png" "alt=" "onload=alert(1)"}}`,
+		},
+		Assets: &Assets{
+			Prefix:   "/static/",
+			Manifest: map[string]string{"img/hero-480w.jpg": "img/hero-480w.a1b2.jpg"},
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("page.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<img src="/static/img/hero.jpg" srcset="/static/img/hero-480w.a1b2.jpg 480w, /static/img/hero-960w.jpg 960w"` +
+		` alt="Mountains &amp; lakes" sizes="(min-width: 800px) 50vw, 100vw" loading="lazy" decoding="async">`
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	result, err = engine.Render("plain.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != `<img src="/static/logo.png" alt="" class="logo">` {
+		t.Errorf("Unexpected output %s", result)
+	}
+
+	if _, err := engine.Render("noalt.html", nil); err == nil || !strings.Contains(err.Error(), "alt is required") {
+		t.Errorf("Expected missing alt error, got %v", err)
+	}
+	if _, err := engine.Render("onload.html", nil); err == nil || !strings.Contains(err.Error(), "invalid attribute") {
+		t.Errorf("Expected invalid attribute error, got %v", err)
+	}
+}
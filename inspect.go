@@ -0,0 +1,262 @@
+package tmplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"text/template/parse"
+)
+
+// TemplateInfo describes how a template was assembled from its files
+type TemplateInfo struct {
+	// Name is the template name
+	Name string
+
+	// Chain is the template followed by each layout it extends, outermost last
+	Chain []string
+
+	// Blocks lists the blocks of the resolved template by name
+	Blocks []BlockInfo
+
+	// Includes lists every file included by the chain, directly or nested
+	Includes []string
+}
+
+// BlockInfo describes where a block of a resolved template comes from
+type BlockInfo struct {
+	// Name is the block name
+	Name string
+
+	// Source is the file whose definition of the block is used
+	Source string
+
+	// DefinedIn lists every file in the chain (and its includes) that defines
+	// the block, outermost layout first. The last one overrides the others.
+	DefinedIn []string
+}
+
+// Inspect reports the extends chain, blocks and includes of a loaded template.
+func (e *TemplateEngine) Inspect(name string) (*TemplateInfo, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	tmpl, err := e.getTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &TemplateInfo{Name: name}
+	definedIn := make(map[string][]string)
+	seenInclude := make(map[string]bool)
+
+	var visitIncludes func(s Source, file string) error
+	visitIncludes = func(s Source, file string) error {
+		tree, err := e.parseTemplateFile(s, filepath.Join(s.Dir, file))
+		if err != nil {
+			return err
+		}
+		for _, inc := range tree.includes {
+			if seenInclude[inc] {
+				continue
+			}
+			seenInclude[inc] = true
+			info.Includes = append(info.Includes, inc)
+			if err := visitIncludes(s, inc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Walk up the chain, then record definitions from the outermost layout in
+	var files [][]string
+	for current, seen := name, map[string]bool{}; current != "" && !seen[current]; {
+		seen[current] = true
+		info.Chain = append(info.Chain, current)
+
+		s, ok := e.findSource(current)
+		if !ok {
+			return nil, withKind(ErrTemplateNotFound, fmt.Errorf("template %s not found in any source", current))
+		}
+		tree, err := e.parseTemplateFile(s, filepath.Join(s.Dir, current))
+		if err != nil {
+			return nil, err
+		}
+
+		before := len(info.Includes)
+		if err := visitIncludes(s, current); err != nil {
+			return nil, err
+		}
+		group := append([]string{current}, info.Includes[before:]...)
+		files = append(files, group)
+		current = tree.extends
+	}
+
+	for i := len(files) - 1; i >= 0; i-- {
+		for _, file := range files[i] {
+			s, ok := e.findSource(file)
+			if !ok {
+				continue
+			}
+			for _, block := range definedBlocks(s, file) {
+				definedIn[block] = append(definedIn[block], file)
+			}
+		}
+	}
+
+	for _, t := range tmpl.Templates() {
+		if t.Name() == tmpl.Name() || t.Name() == "" || t.Tree == nil {
+			continue
+		}
+		info.Blocks = append(info.Blocks, BlockInfo{
+			Name:      t.Name(),
+			Source:    t.Tree.ParseName,
+			DefinedIn: definedIn[t.Name()],
+		})
+	}
+	sort.Slice(info.Blocks, func(i, j int) bool { return info.Blocks[i].Name < info.Blocks[j].Name })
+
+	return info, nil
+}
+
+// findSource returns the first source containing the named template file
+func (e *TemplateEngine) findSource(name string) (Source, bool) {
+	for _, s := range e.srcs {
+		if _, err := fs.Stat(s.FS, filepath.Join(s.Dir, name)); err == nil {
+			return s, true
+		}
+	}
+	return Source{}, false
+}
+
+// definedBlocks lists the blocks a file defines itself, without resolving
+// includes or layouts. Func calls aren't checked, so it works for any file
+// that parses.
+func definedBlocks(s Source, file string) []string {
+	content, err := fs.ReadFile(s.FS, filepath.Join(s.Dir, file))
+	if err != nil {
+		return nil
+	}
+
+	trees := make(map[string]*parse.Tree)
+	t := parse.New(file)
+	t.Mode = parse.SkipFuncCheck
+	if _, err := t.Parse(string(content), "", "", trees); err != nil {
+		return nil
+	}
+
+	var blocks []string
+	for name := range trees {
+		if name != file {
+			blocks = append(blocks, name)
+		}
+	}
+	sort.Strings(blocks)
+	return blocks
+}
+
+// templateNames returns the names of all loaded templates, sorted. The
+// caller holds e.mu.
+func (e *TemplateEngine) templateNames() []string {
+	names := make([]string, 0, len(e.cache))
+	for name := range e.cache {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var inspectPage = template.Must(template.New("inspect").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if .Info}}{{.Info.Name}} - {{end}}tmplx inspector</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: .25em 1em .25em 0; border-bottom: 1px solid #ddd; }
+code { background: #f4f4f4; padding: 0 .2em; }
+.overridden { color: #999; text-decoration: line-through; }
+</style>
+</head>
+<body>
+{{- if .Info}}
+{{- with .Info}}
+<p><a href="?">All templates</a></p>
+<h1><code>{{.Name}}</code></h1>
+<h2>Extends</h2>
+<ol>{{range .Chain}}<li><code>{{.}}</code></li>{{end}}</ol>
+<h2>Blocks</h2>
+{{- if .Blocks}}
+<table>
+<tr><th>Block</th><th>Source</th><th>Defined in</th></tr>
+{{- range .Blocks}}
+<tr><td><code>{{.Name}}</code></td><td><code>{{.Source}}</code></td><td>{{$src := .Source}}{{range $i, $f := .DefinedIn}}{{if $i}} &rarr; {{end}}<code{{if ne $f $src}} class="overridden"{{end}}>{{$f}}</code>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No blocks.</p>
+{{- end}}
+<h2>Includes</h2>
+{{- if .Includes}}
+<ul>{{range .Includes}}<li><code>{{.}}</code></li>{{end}}</ul>
+{{- else}}
+<p>No includes.</p>
+{{- end}}
+{{- end}}
+{{.Debug}}
+{{- else}}
+<h1>Templates</h1>
+<ul>{{range .Names}}<li><a href="?name={{.}}"><code>{{.}}</code></a></li>{{end}}</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// InspectHandler serves an HTML view of a template's extends chain, the
+// effective source of each of its blocks, its includes and its parse trees.
+// The template is picked with the name query parameter; without one the
+// handler lists all loaded templates. It is a debugging aid and should not
+// be exposed publicly.
+func (e *TemplateEngine) InspectHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		e.compileAll()
+		e.mu.RLock()
+		page := struct {
+			Info  *TemplateInfo
+			Debug template.HTML
+			Names []string
+		}{Names: e.templateNames()}
+		e.mu.RUnlock()
+
+		if name := r.URL.Query().Get("name"); name != "" {
+			info, err := e.Inspect(name)
+			if err != nil {
+				status := http.StatusInternalServerError
+				if errors.Is(err, ErrTemplateNotFound) {
+					status = http.StatusNotFound
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			page.Info = info
+			if debug, err := e.DebugInfo(name); err == nil {
+				page.Debug = debug.HTML()
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := inspectPage.Execute(w, page); err != nil {
+			e.logf(LogRender, LogError, "[TMPLX] Error rendering inspector: %v", err)
+		}
+	})
+}
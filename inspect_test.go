@@ -0,0 +1,74 @@
+package tmplx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestInspect(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{
+			Data: []byte(`<title>{{block "title" .}}Site{{end}}</title>{{include "partials/nav.html" .}}{{block "content" .}}{{end}}`),
+		},
+		"layouts/page.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}<main>{{block "main" .}}{{end}}</main>{{end}}`),
+		},
+		"partials/nav.html": &fstest.MapFile{
+			Data: []byte(`<nav>{{include "partials/link.html" .}}</nav>`),
+		},
+		"partials/link.html": &fstest.MapFile{
+			Data: []byte(`<a href="/">Home</a>`),
+		},
+		"pages/home.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/page.html"}}{{define "title"}}Home{{end}}{{define "main"}}Hello{{end}}`),
+		},
+	}
+
+	engine := New(Options{FS: fsys})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := engine.Inspect("pages/home.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"pages/home.html", "layouts/page.html", "layouts/base.html"}; !reflect.DeepEqual(info.Chain, want) {
+		t.Errorf("Expected chain %v, got %v", want, info.Chain)
+	}
+	if want := []string{"partials/nav.html", "partials/link.html"}; !reflect.DeepEqual(info.Includes, want) {
+		t.Errorf("Expected includes %v, got %v", want, info.Includes)
+	}
+
+	blocks := make(map[string]BlockInfo)
+	for _, b := range info.Blocks {
+		blocks[b.Name] = b
+	}
+	want := map[string]BlockInfo{
+		"title":   {Name: "title", Source: "pages/home.html", DefinedIn: []string{"layouts/base.html", "pages/home.html"}},
+		"content": {Name: "content", Source: "layouts/page.html", DefinedIn: []string{"layouts/base.html", "layouts/page.html"}},
+		"main":    {Name: "main", Source: "pages/home.html", DefinedIn: []string{"layouts/page.html", "pages/home.html"}},
+	}
+	for name, w := range want {
+		if !reflect.DeepEqual(blocks[name], w) {
+			t.Errorf("Expected block %s to be %+v, got %+v", name, w, blocks[name])
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	engine.InspectHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?name=pages/home.html", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	containsAll(t, []string{"<code>layouts/page.html</code>", `class="overridden">layouts/base.html</code>`, "<code>partials/link.html</code>"}, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	engine.InspectHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?name=missing.html", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing template, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,68 @@
+package tmplx
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// Island is a kind of client component rendered by the island func. The
+// server renders a placeholder element carrying the component name and its
+// props as JSON, and Script hydrates every placeholder of its kind:
+//
+//	{{island "react" "CommentList" .Props}}
+//	<div data-island="react" data-component="CommentList" data-props="{...}"></div>
+//
+// {{islandScripts}}, placed after the islands in a layout, writes the
+// scripts of the kinds the page used.
+type Island struct {
+	// Script is the URL of the module script that hydrates islands
+	Script string
+
+	// Tag is the placeholder element, defaulting to "div"
+	Tag string
+}
+
+// setupIslands registers the island and islandScripts funcs. Islands used by
+// a render are tracked in its state, so every render gets its own.
+func (e *TemplateEngine) setupIslands(islands map[string]Island) {
+	if len(islands) == 0 {
+		return
+	}
+	e.islands = islands
+
+	e.registerRenderFunc("island", func(st *renderState) any {
+		return func(kind, component string, props interface{}) (template.HTML, error) {
+			isl, ok := islands[kind]
+			if !ok {
+				return "", fmt.Errorf("unknown island kind %s", kind)
+			}
+			encoded, err := json.Marshal(props)
+			if err != nil {
+				return "", fmt.Errorf("error encoding props of island %s: %v", component, err)
+			}
+
+			if !containsString(st.islands, kind) {
+				st.islands = append(st.islands, kind)
+			}
+			tag := isl.Tag
+			if tag == "" {
+				tag = "div"
+			}
+			return template.HTML(fmt.Sprintf(`<%s data-island="%s" data-component="%s" data-props="%s"></%s>`,
+				tag, template.HTMLEscapeString(kind), template.HTMLEscapeString(component),
+				template.HTMLEscapeString(string(encoded)), tag)), nil
+		}
+	})
+
+	e.registerRenderFunc("islandScripts", func(st *renderState) any {
+		return func() template.HTML {
+			var b strings.Builder
+			for _, kind := range st.islands {
+				fmt.Fprintf(&b, `<script type="module" src="%s"></script>`, template.HTMLEscapeString(islands[kind].Script))
+			}
+			return template.HTML(b.String())
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIslands(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html": `<body>{{block "content" .}}{{end}}{{islandScripts}}</body>`,
+			"post.html":         `{{extend "layouts/base.html"}}{{define "content"}}{{island "react" "CommentList" .Comments}}{{island "react" "Likes" 3}}{{island "svelte" "Share" nil}}{{end}}`,
+			"plain.html":        `{{extend "layouts/base.html"}}{{define "content"}}<p>static</p>{{end}}`,
+			"bad.html":          `{{island "vue" "X" nil}}`,
+		},
+		Islands: map[string]Island{
+			"react":  {Script: "/static/react-islands.js"},
+			"svelte": {Script: "/static/svelte-islands.js", Tag: "section"},
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("post.html", H{"Comments": []string{`<b>"hi"</b>`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{
+		`<div data-island="react" data-component="CommentList" data-props="[&#34;\u003cb\u003e\&#34;hi\&#34;\u003c/b\u003e&#34;]"></div>`,
+		`<div data-island="react" data-component="Likes" data-props="3"></div>`,
+		`<section data-island="svelte" data-component="Share" data-props="null"></section>`,
+		`<script type="module" src="/static/react-islands.js"></script><script type="module" src="/static/svelte-islands.js"></script></body>`,
+	}, result)
+
+	// scripts are tracked per render
+	result, err = engine.Render("plain.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result, "<script") {
+		t.Errorf("Expected no island scripts for a page without islands, got %s", result)
+	}
+
+	if _, err := engine.Render("bad.html", nil); err == nil || !strings.Contains(err.Error(), "unknown island kind vue") {
+		t.Errorf("Expected unknown kind error, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package tmplx
+
+import (
+	"path"
+	"strings"
+)
+
+// defaultLayout returns the layout of the named template, which has no
+// extend directive: that of its directory config, or else the one from
+// Options.LayoutFor. A layout is never made to extend itself.
+func (e *TemplateEngine) defaultLayout(name string, cfg *dirConfig) string {
+	layout := cfg.layout
+	if layout == "" && e.layoutFor != nil {
+		layout = e.layoutFor(name)
+	}
+	if layout == name {
+		return ""
+	}
+	return layout
+}
+
+// LayoutsByDir returns a LayoutFor giving every template under a directory
+// the layout it maps to; the deepest matching directory wins and "" matches
+// every template:
+//
+//	LayoutFor: tmplx.LayoutsByDir(map[string]string{
+//		"pages":       "layouts/base.html",
+//		"pages/admin": "layouts/admin/base.html",
+//	}),
+//
+// Templates can still extend another layout, or none with {{extend ""}}.
+func LayoutsByDir(layouts map[string]string) func(name string) string {
+	dirs := make(map[string]string, len(layouts))
+	for dir, layout := range layouts {
+		dirs[strings.Trim(dir, "/")] = layout
+	}
+	return func(name string) string {
+		for dir := path.Dir(name); ; dir = path.Dir(dir) {
+			if dir == "." {
+				dir = ""
+			}
+			if layout, ok := dirs[dir]; ok {
+				return layout
+			}
+			if dir == "" {
+				return ""
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package tmplx
+
+import "testing"
+
+func TestLayoutFor(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html":       `<main>{{block "content" .}}{{end}}</main>`,
+			"layouts/admin/base.html": `<div class="admin">{{block "content" .}}{{end}}</div>`,
+			"layouts/print.html":      `<article>{{block "content" .}}{{end}}</article>`,
+			"pages/home.html":         `{{define "content"}}home{{end}}`,
+			"pages/admin/users.html":  `{{define "content"}}users{{end}}`,
+			"pages/admin/print.html":  `{{extend "layouts/print.html"}}{{define "content"}}print{{end}}`,
+			"pages/admin/raw.html":    `{{extend ""}}raw`,
+			"partials/nav.html":       `nav`,
+		},
+		LayoutFor: LayoutsByDir(map[string]string{
+			"pages/":      "layouts/base.html",
+			"pages/admin": "layouts/admin/base.html",
+		}),
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"pages/home.html":        `<main>home</main>`,
+		"pages/admin/users.html": `<div class="admin">users</div>`,
+		"pages/admin/print.html": `<article>print</article>`,
+		"pages/admin/raw.html":   `raw`,
+		"partials/nav.html":      `nav`,
+	}
+	for name, expected := range tests {
+		result, err := engine.Render(name, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, result)
+		}
+	}
+}
+
+func TestLayoutForSelf(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"base.html": `<main>{{block "content" .}}{{end}}</main>`,
+			"page.html": `{{define "content"}}page{{end}}`,
+		},
+		LayoutFor: func(string) string { return "base.html" },
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("page.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<main>page</main>`; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
@@ -0,0 +1,95 @@
+package tmplx
+
+import (
+	"html/template"
+	"path/filepath"
+	"sort"
+	"sync"
+	texttemplate "text/template"
+)
+
+// loadLazily resolves the named template if it isn't loaded yet, for engines
+// created with Options.LazyLoad. A template that doesn't exist is no error;
+// the lookup that follows reports it. Names found missing are remembered
+// until the next reload, so probing for variants, as localeVariant does,
+// doesn't search the sources under the write lock on every render.
+func (e *TemplateEngine) loadLazily(name string) error {
+	e.mu.RLock()
+	_, ok := e.lookup(name)
+	missing := e.missing[name]
+	e.mu.RUnlock()
+	if ok || missing {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.compile(name)
+}
+
+// compile resolves and stores the named template unless it is loaded
+// already. The caller holds e.mu.
+func (e *TemplateEngine) compile(name string) error {
+	if _, ok := e.lookup(name); ok {
+		return nil
+	}
+	s, found, err := e.sourceOf(name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		e.missing[name] = true
+		return nil
+	}
+	return e.resolveFile(s, name)
+}
+
+// compileAll resolves every template not loaded yet, for the methods
+// listing templates, which would otherwise only see those used so far.
+// Templates that fail are logged and left out.
+func (e *TemplateEngine) compileAll() {
+	if !e.lazy {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, s := range e.srcs {
+		names, err := e.templateFiles(s)
+		if err != nil {
+			e.logf(LogLoad, LogError, "[TMPLX] %v", err)
+			continue
+		}
+		for _, name := range names {
+			seen[filepath.ToSlash(name)] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := e.compile(name); err != nil {
+			e.logf(LogLoad, LogError, "[TMPLX] %v", err)
+		}
+	}
+}
+
+// forget drops every resolved template, so each is resolved again the next
+// time it is used
+func (e *TemplateEngine) forget() {
+	e.cacheInvalidated(e.cache, e.loadCache, e.inclCache)
+	e.cache = make(map[string]*template.Template)
+	e.loadCache = make(map[string]*template.Template)
+	e.pageVars = make(map[string]PageVars)
+	e.parents = make(map[string]string)
+	e.deps = make(dependencyGraph)
+	e.inclCache = make(map[string]*inclCache)
+	e.text = make(map[string]*texttemplate.Template)
+	e.digests = make(map[string]string)
+	e.bases = make(map[string]executor)
+	e.pools = make(map[string]*sync.Pool)
+	e.missing = make(map[string]bool)
+}
@@ -0,0 +1,155 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLazyLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`<main>{{block "content" .}}{{end}}</main>`)},
+		"pages/home.html":   {Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}home{{end}}`)},
+		"pages/about.html":  {Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}about{{end}}`)},
+		"pages/broken.html": {Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}{{if}}{{end}}`)},
+	}
+	engine := New(Options{FS: fsys, LazyLoad: true})
+	if err := engine.Load(); err != nil {
+		t.Fatalf("Expected Load to skip the broken template, got %v", err)
+	}
+	resolved := func() []string {
+		engine.mu.RLock()
+		defer engine.mu.RUnlock()
+		return engine.templateNames()
+	}
+	if names := resolved(); len(names) != 0 {
+		t.Errorf("Expected nothing resolved before rendering, got %v", names)
+	}
+
+	result, err := engine.Render("pages/home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "<main>home</main>" {
+		t.Errorf("Unexpected output %q", result)
+	}
+	if names := resolved(); strings.Join(names, ",") != "pages/home.html" {
+		t.Errorf("Expected only the rendered template resolved, got %v", names)
+	}
+
+	// cached once resolved
+	fsys["pages/home.html"] = &fstest.MapFile{Data: []byte(`changed`)}
+	if result, _ := engine.Render("pages/home.html", nil); result != "<main>home</main>" {
+		t.Errorf("Expected the cached template, got %q", result)
+	}
+
+	if _, err := engine.GetTemplate("pages/about.html"); err != nil {
+		t.Errorf("Expected GetTemplate to resolve the template, got %v", err)
+	}
+	if _, err := engine.Render("pages/broken.html", nil); err == nil || !strings.Contains(err.Error(), "pages/broken.html") {
+		t.Errorf("Expected the parse error on first render, got %v", err)
+	}
+	if _, err := engine.Render("pages/missing.html", nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+
+	// reloading drops the resolved templates
+	if err := engine.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "changed" {
+		t.Errorf("Expected the template resolved again after a reload, got %q", result)
+	}
+}
+
+func TestLazyLoadListing(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`<main>{{block "content" .}}{{end}}</main>`)},
+		"pages/home.html":   {Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}home{{end}}`)},
+		"pages/about.html":  {Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}about{{end}}`)},
+		"pages/broken.html": {Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}{{if}}{{end}}`)},
+	}
+	engine := New(Options{FS: fsys, LazyLoad: true, Logger: &recordingLogger{}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `; defined templates are: "layouts/base.html", "pages/about.html", "pages/home.html"`
+	if names := engine.DefinedTemplates(); names != expected {
+		t.Errorf("Expected every template that resolves, got %s", names)
+	}
+	sitemap, err := engine.Sitemap("https://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{"https://example.com/pages/about", "https://example.com/pages/home"}, string(sitemap))
+	if strings.Contains(string(sitemap), "base") {
+		t.Errorf("Expected the layout left out of the sitemap, got %s", sitemap)
+	}
+}
+
+func TestLazyLoadMissing(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pages/home.html": {Data: []byte(`home`)},
+	}
+	engine := New(Options{FS: fsys, LazyLoad: true, Locales: []string{"en", "de"}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := engine.Render("pages/home.html", nil, WithLocale("de")); err != nil || result != "home" {
+		t.Fatalf("Expected home, got %q, %v", result, err)
+	}
+
+	// the missing variant is remembered until the next reload
+	fsys["pages/home.de.html"] = &fstest.MapFile{Data: []byte(`Startseite`)}
+	if result, _ := engine.Render("pages/home.html", nil, WithLocale("de")); result != "home" {
+		t.Errorf("Expected the variant to stay missing, got %q", result)
+	}
+	if err := engine.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("pages/home.html", nil, WithLocale("de")); result != "Startseite" {
+		t.Errorf("Expected the variant after a reload, got %q", result)
+	}
+}
+
+func TestLazyLoadLayoutRenderedFirst(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`<title>{{block "title" .}}Site{{end}}</title><main>{{block "content" .}}{{end}}</main>`)},
+		"pages/home.html":   {Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}<p>{{.}}</p>{{end}}`)},
+	}
+	engine := New(Options{FS: fsys, LazyLoad: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Render("layouts/base.html", "x"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("pages/home.html", "<home>")
+	if err != nil {
+		t.Fatalf("Expected the page to extend the rendered layout, got %v", err)
+	}
+	if result != "<title>Site</title><main><p>&lt;home&gt;</p></main>" {
+		t.Errorf("Unexpected output %q", result)
+	}
+}
+
+func TestLazyLoadStatefulRenders(t *testing.T) {
+	fsys := fstest.MapFS{
+		"row.html":  {Data: []byte(`<li>{{.}}</li>`)},
+		"cart.html": {Data: []byte(`{{define "count"}}{{.}}{{end}}`)},
+	}
+	engine := New(Options{FS: fsys, LazyLoad: true, Limits: Limits{MaxCalls: 100}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := engine.RenderEach("row.html", []interface{}{1, 2}, &out); err != nil || out.String() != "<li>1</li><li>2</li>" {
+		t.Errorf("Expected the rows, got %q, %v", out.String(), err)
+	}
+	out.Reset()
+	if err := engine.RenderBlock(&out, "cart.html", "count", 3); err != nil || out.String() != "3" {
+		t.Errorf("Expected the block, got %q, %v", out.String(), err)
+	}
+}
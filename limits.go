@@ -0,0 +1,160 @@
+package tmplx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// Limits bound the resources a single render may use, to protect systems
+// rendering templates written by semi-trusted users. Zero fields are
+// unlimited.
+type Limits struct {
+	// MaxOutput is the most bytes a render may write
+	MaxOutput int64
+
+	// MaxCalls is the most calls a render may make to funcs registered with
+	// the engine, built-in ones like include and printf excluded
+	MaxCalls int
+
+	// Timeout is the wall-clock budget of a render. It is checked whenever
+	// the template writes output or calls a func.
+	Timeout time.Duration
+}
+
+func (l Limits) set() bool {
+	return l.MaxOutput > 0 || l.MaxCalls > 0 || l.Timeout > 0
+}
+
+// WithLimits renders with limits instead of Options.Limits, e.g. those of
+// the tenant the template belongs to. Func calls are only counted on engines
+// created with Options.Limits, whose funcs are wrapped to count them.
+func WithLimits(limits Limits) RenderOption {
+	return func(cfg *renderConfig) {
+		cfg.limits = &limits
+	}
+}
+
+// limitsFor returns the limits of a render
+func (e *TemplateEngine) limitsFor(cfg renderConfig) Limits {
+	if cfg.limits != nil {
+		return *cfg.limits
+	}
+	return e.limits
+}
+
+// budget is what is left of the limits of a render, and the context that
+// can cut it short
+type budget struct {
+	limits   Limits
+	calls    int
+	written  int64
+	deadline time.Time
+	ctx      context.Context
+}
+
+func newBudget(limits Limits, ctx context.Context) *budget {
+	b := &budget{limits: limits, ctx: ctx}
+	if limits.Timeout > 0 {
+		b.deadline = time.Now().Add(limits.Timeout)
+	}
+	return b
+}
+
+func (b *budget) checkTime() error {
+	if b.ctx != nil {
+		if err := b.ctx.Err(); err != nil {
+			return fmt.Errorf("render aborted: %w", err)
+		}
+	}
+	if !b.deadline.IsZero() && time.Now().After(b.deadline) {
+		return fmt.Errorf("render exceeded its time limit of %v", b.limits.Timeout)
+	}
+	return nil
+}
+
+// call spends a func call
+func (b *budget) call(name string) error {
+	b.calls++
+	if b.limits.MaxCalls > 0 && b.calls > b.limits.MaxCalls {
+		return fmt.Errorf("render exceeded its limit of %d func calls calling %s", b.limits.MaxCalls, name)
+	}
+	return b.checkTime()
+}
+
+// budgetWriter fails writes once the render is over its output or time
+// limit
+type budgetWriter struct {
+	w      io.Writer
+	budget *budget
+}
+
+func (bw *budgetWriter) Write(p []byte) (int, error) {
+	if err := bw.budget.checkTime(); err != nil {
+		return 0, err
+	}
+	max := bw.budget.limits.MaxOutput
+	if max > 0 && bw.budget.written+int64(len(p)) > max {
+		return 0, fmt.Errorf("render exceeded its output limit of %d bytes", max)
+	}
+	n, err := bw.w.Write(p)
+	bw.budget.written += int64(n)
+	return n, err
+}
+
+// setupLimits wraps every func registered so far to spend the render's
+// budget, if the engine has limits. It runs after every other setup; funcs
+// added later go through addFunc.
+func (e *TemplateEngine) setupLimits() {
+	if !e.limits.set() {
+		return
+	}
+	for name, fn := range e.funcMap {
+		if !limitable(name) {
+			continue
+		}
+		rf, ok := e.renderFuncs[name]
+		if !ok {
+			fn := fn
+			rf = func(*renderState) any { return fn }
+		}
+		e.registerRenderFunc(name, limitedFunc(name, rf))
+	}
+}
+
+// addFunc registers a func after New, e.g. by AddFuncs, wrapped to spend
+// the render's budget if the engine has limits
+func (e *TemplateEngine) addFunc(name string, fn any) {
+	e.funcMap[name] = fn
+	if e.limits.set() && limitable(name) {
+		e.registerRenderFunc(name, limitedFunc(name, func(*renderState) any { return fn }))
+	}
+}
+
+// limitable reports whether calls to the named func count against
+// Limits.MaxCalls; those to the engine's own funcs don't
+func limitable(name string) bool {
+	return name != markFunc && name != prologFunc && name != "extend" && name != "include" && name != varDirective
+}
+
+// limitedFunc wraps a render func to spend a func call of the render's
+// budget each time it is called. Funcs that can't return an error panic
+// with it, which template execution reports as the func's error.
+func limitedFunc(name string, rf renderFunc) renderFunc {
+	return func(st *renderState) any {
+		fn := reflect.ValueOf(rf(st))
+		return reflect.MakeFunc(fn.Type(), func(args []reflect.Value) []reflect.Value {
+			if st.budget != nil {
+				if err := st.budget.call(name); err != nil {
+					panic(err)
+				}
+			}
+			if fn.Type().IsVariadic() {
+				return fn.CallSlice(args)
+			}
+			return fn.Call(args)
+		}).Interface()
+	}
+}
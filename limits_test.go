@@ -0,0 +1,79 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimits(t *testing.T) {
+	loader := MapLoader{
+		"pages/list.html":  `{{range .}}<li>{{upper .}}</li>{{end}}`,
+		"pages/plain.html": `{{range .}}<li>{{.}}</li>{{end}}`,
+		"pages/slow.html":  `{{range .}}{{nap}}{{end}}`,
+	}
+	funcs := map[string]interface{}{
+		"upper": strings.ToUpper,
+		"nap":   func() string { time.Sleep(5 * time.Millisecond); return "" },
+	}
+	engine := New(Options{Loader: loader, FuncMap: funcs, Limits: Limits{MaxCalls: 3, MaxOutput: 40}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("pages/list.html", []string{"a", "b", "c"})
+	if err != nil || result != "<li>A</li><li>B</li><li>C</li>" {
+		t.Errorf("Expected a render within its limits to succeed, got %q, %v", result, err)
+	}
+
+	_, err = engine.Render("pages/list.html", []string{"a", "b", "c", "d"})
+	if err == nil || !strings.Contains(err.Error(), "limit of 3 func calls calling upper") {
+		t.Errorf("Expected the func call limit to be hit, got %v", err)
+	}
+
+	_, err = engine.Render("pages/plain.html", []string{"a", "b", "c", "d", "e"})
+	if err == nil || !strings.Contains(err.Error(), "output limit of 40 bytes") {
+		t.Errorf("Expected the output limit to be hit, got %v", err)
+	}
+
+	// calls are counted per render
+	for i := 0; i < 3; i++ {
+		if _, err := engine.Render("pages/list.html", []string{"a", "b"}); err != nil {
+			t.Errorf("Render %d: %v", i, err)
+		}
+	}
+
+	// overridden for a render
+	_, err = engine.Render("pages/slow.html", make([]int, 20), WithLimits(Limits{Timeout: 20 * time.Millisecond}))
+	if err == nil || !strings.Contains(err.Error(), "time limit of 20ms") {
+		t.Errorf("Expected the time limit to be hit, got %v", err)
+	}
+	if _, err := engine.Render("pages/list.html", []string{"a", "b", "c", "d"}, WithLimits(Limits{})); err != nil {
+		t.Errorf("Expected no limits, got %v", err)
+	}
+
+	// engines without limits still limit output for a render
+	unlimited := New(Options{Loader: loader, FuncMap: funcs})
+	if err := unlimited.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unlimited.Render("pages/plain.html", []string{"a", "b"}, WithLimits(Limits{MaxOutput: 10})); err == nil {
+		t.Error("Expected the output limit to be hit")
+	}
+}
+
+func TestLimitsAddFuncs(t *testing.T) {
+	loader := MapLoader{"pages/list.html": `{{range .}}<li>{{lower .}}</li>{{end}}`}
+	engine := New(Options{Loader: loader, Limits: Limits{MaxCalls: 2}})
+	if err := engine.AddFuncs(map[string]interface{}{"lower": strings.ToLower}); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := engine.Render("pages/list.html", []string{"A", "B"}); err != nil || result != "<li>a</li><li>b</li>" {
+		t.Errorf("Expected a render within its limits to succeed, got %q, %v", result, err)
+	}
+	_, err := engine.Render("pages/list.html", []string{"A", "B", "C"})
+	if err == nil || !strings.Contains(err.Error(), "limit of 2 func calls calling lower") {
+		t.Errorf("Expected calls to funcs added later to be counted, got %v", err)
+	}
+}
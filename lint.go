@@ -0,0 +1,373 @@
+package tmplx
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// LintSeverity is how serious a LintIssue is
+type LintSeverity int
+
+const (
+	// LintWarning marks templates that load but likely don't do what was intended
+	LintWarning LintSeverity = iota
+
+	// LintError marks templates that fail to load or render
+	LintError
+)
+
+func (s LintSeverity) String() string {
+	if s == LintError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Lint issue codes
+const (
+	LintParseError      = "parse-error"         // the file doesn't parse
+	LintUndefinedFunc   = "undefined-func"      // a func that isn't registered is called
+	LintMissingTemplate = "missing-template"    // an extend or include target doesn't exist
+	LintCircular        = "circular-dependency" // templates extend or include each other
+	LintExtendNotFirst  = "extend-not-first"    // content precedes the extend directive
+	LintNestedInclude   = "nested-include"      // an include inside a block, if, range or with
+	LintUnknownBlock    = "unknown-block"       // a child defines a block its layouts never use
+	LintDoubleEscape    = "double-escape"       // output that is likely escaped twice
+	LintReadError       = "read-error"          // a template file or directory can't be read
+)
+
+// LintIssue is a single problem found by Lint
+type LintIssue struct {
+	Code     string
+	Severity LintSeverity
+	File     string
+	Line     int // 0 when the issue isn't tied to a line
+	Message  string
+}
+
+func (i LintIssue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s [%s]", i.File, i.Line, i.Severity, i.Message, i.Code)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", i.File, i.Severity, i.Message, i.Code)
+}
+
+var errorLineRe = regexp.MustCompile(`:(\d+):`)
+
+// lintFile is a template file parsed without checking funcs
+type lintFile struct {
+	trees map[string]*parse.Tree
+	root  *parse.Tree
+}
+
+// Lint checks every template file in every source and returns the issues
+// found, sorted by file and line. It doesn't need Load to have been called,
+// but escaping checks only run for templates that are loaded.
+func (e *TemplateEngine) Lint() []LintIssue {
+	e.compileAll()
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	files, issues := e.scanTemplateFiles()
+	add := func(code string, sev LintSeverity, file string, line int, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{Code: code, Severity: sev, File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	graph := make(dependencyGraph)
+	for _, name := range names {
+		f := files[name]
+		var extends string
+		seenContent := false
+
+		for _, node := range f.root.Root.Nodes {
+			action, ok := node.(*parse.ActionNode)
+			directive, target := directiveOf(action, ok)
+
+			switch directive {
+			case "extend", "include":
+				graph.add(name, target)
+			}
+			switch directive {
+			case "extend":
+				extends = target
+				if seenContent {
+					add(LintExtendNotFirst, LintWarning, name, lineOf(f.root, node), "extend %q should be the first directive", target)
+				}
+				if _, found := e.findSource(target); !found {
+					add(LintMissingTemplate, LintError, name, lineOf(f.root, node), "extended template %s not found", target)
+				}
+			case "include":
+				if _, found := e.findSource(target); !found {
+					add(LintMissingTemplate, LintError, name, lineOf(f.root, node), "included template %s not found", target)
+				}
+			}
+
+			if directive == "" {
+				if text, ok := node.(*parse.TextNode); !ok || strings.TrimSpace(string(text.Text)) != "" {
+					seenContent = true
+				}
+			}
+		}
+
+		for _, tree := range f.trees {
+			walkActions(tree.Root, func(action *parse.ActionNode) {
+				if directive, target := directiveOf(action, true); directive == "include" && !isTopLevel(tree, f.root, action) {
+					add(LintNestedInclude, LintWarning, name, lineOf(tree, action), "include %q is only processed at the top level of a file", target)
+				}
+			})
+		}
+
+		if extends != "" {
+			for _, block := range undeclaredBlocks(files, name, extends) {
+				add(LintUnknownBlock, LintWarning, name, lineOf(f.trees[block], f.trees[block].Root), "block %q is not used by any layout in the chain of %s", block, extends)
+			}
+		}
+	}
+
+	if cycle := graph.findCycle(); cycle != nil {
+		add(LintCircular, LintError, cycle[0], 0, "circular template dependency: %s", strings.Join(cycle, " -> "))
+	}
+
+	if e.backend == HTMLBackend {
+		for _, name := range e.templateNames() {
+			report, err := e.escapeReport(name, e.cache[name])
+			if err != nil {
+				continue
+			}
+			for _, info := range report {
+				if info.Warning == "" {
+					continue
+				}
+				file, line := splitLocation(info.Location)
+				add(LintDoubleEscape, LintWarning, file, line, "%s: %s", info.Action, info.Warning)
+			}
+		}
+	}
+
+	issues = dedupeIssues(issues)
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
+
+// scanTemplateFiles parses every template file in every source, reporting
+// files that can't be read or parsed.
+func (e *TemplateEngine) scanTemplateFiles() (map[string]*lintFile, []LintIssue) {
+	var issues []LintIssue
+	add := func(code string, sev LintSeverity, file string, line int, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{Code: code, Severity: sev, File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	files := make(map[string]*lintFile)
+	for _, s := range e.srcs {
+		err := fs.WalkDir(s.FS, s.Dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				add(LintReadError, LintError, path, 0, "%v", err)
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if ok, err := e.loadable(s, path); err != nil || !ok {
+				if err != nil {
+					add(LintReadError, LintError, path, 0, "%v", err)
+				}
+				return nil
+			}
+			name, err := filepath.Rel(s.Dir, path)
+			if err != nil || files[name] != nil {
+				return nil
+			}
+
+			content, err := fs.ReadFile(s.FS, path)
+			if err != nil {
+				add(LintReadError, LintError, name, 0, "%v", err)
+				return nil
+			}
+			resolved, err := e.conditionals(name, string(content))
+			if err != nil {
+				add(LintParseError, LintError, name, 0, "%v", err)
+				return nil
+			}
+			f, issue := e.lintParse(name, resolved)
+			if issue != nil {
+				issues = append(issues, *issue)
+			}
+			if f != nil && f.root.Root != nil {
+				files[name] = f
+			}
+			return nil
+		})
+		if err != nil {
+			add(LintReadError, LintError, s.Dir, 0, "%v", err)
+		}
+	}
+
+	return files, issues
+}
+
+// undeclaredBlocks lists the blocks a child defines that neither its layout
+// chain nor its own blocks use.
+func undeclaredBlocks(files map[string]*lintFile, name, extends string) []string {
+	f := files[name]
+	available := chainBlocks(files, extends, make(map[string]bool))
+	referenced := make(map[string]bool)
+	for block, tree := range f.trees {
+		if block == name {
+			// a child's own top level is discarded in favour of its layout's
+			continue
+		}
+		for _, ref := range templateRefs(tree.Root) {
+			referenced[ref] = true
+		}
+	}
+
+	var blocks []string
+	for block := range f.trees {
+		if block != name && !available[block] && !referenced[block] {
+			blocks = append(blocks, block)
+		}
+	}
+	sort.Strings(blocks)
+	return blocks
+}
+
+// lintParse parses a file twice: without func checks to get its trees, then
+// with the engine's funcs to report funcs that aren't registered.
+func (e *TemplateEngine) lintParse(name, content string) (*lintFile, *LintIssue) {
+	trees := make(map[string]*parse.Tree)
+	root := parse.New(name)
+	root.Mode = parse.SkipFuncCheck
+	if _, err := root.Parse(content, "", "", trees); err != nil {
+		return nil, &LintIssue{Code: LintParseError, Severity: LintError, File: name, Line: errorLine(err), Message: err.Error()}
+	}
+	if trees[name] != nil {
+		root = trees[name]
+	}
+
+	if e.unknown != UnknownFuncIgnore {
+		if _, err := template.New(name).Funcs(e.funcMap).Parse(content); err != nil {
+			if m := undefinedFuncRe.FindStringSubmatch(err.Error()); m != nil {
+				return &lintFile{trees: trees, root: root}, &LintIssue{Code: LintUndefinedFunc, Severity: LintError, File: name, Line: errorLine(err), Message: fmt.Sprintf("function %q is not registered", m[1])}
+			}
+		}
+	}
+	return &lintFile{trees: trees, root: root}, nil
+}
+
+// chainBlocks collects the blocks defined by a layout, the layouts it extends
+// and their includes.
+func chainBlocks(files map[string]*lintFile, name string, visited map[string]bool) map[string]bool {
+	blocks := make(map[string]bool)
+	if visited[name] {
+		return blocks
+	}
+	visited[name] = true
+
+	f := files[name]
+	if f == nil {
+		return blocks
+	}
+	for block := range f.trees {
+		blocks[block] = true
+	}
+	for _, node := range f.root.Root.Nodes {
+		action, ok := node.(*parse.ActionNode)
+		if directive, target := directiveOf(action, ok); directive != "" {
+			for block := range chainBlocks(files, target, visited) {
+				blocks[block] = true
+			}
+		}
+	}
+	return blocks
+}
+
+// directiveOf returns the directive ("extend" or "include") and its target
+// if action is one.
+func directiveOf(action *parse.ActionNode, ok bool) (string, string) {
+	if !ok || action == nil || len(action.Pipe.Cmds) == 0 {
+		return "", ""
+	}
+	cmd := action.Pipe.Cmds[0]
+	if len(cmd.Args) < 2 {
+		return "", ""
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok || (ident.Ident != "extend" && ident.Ident != "include") {
+		return "", ""
+	}
+	str, ok := cmd.Args[1].(*parse.StringNode)
+	if !ok {
+		return "", ""
+	}
+	return ident.Ident, str.Text
+}
+
+func isTopLevel(tree, root *parse.Tree, node parse.Node) bool {
+	if tree != root {
+		return false
+	}
+	for _, n := range root.Root.Nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+func lineOf(tree *parse.Tree, node parse.Node) int {
+	_, line := splitLocation(locationOf(tree, node))
+	return line
+}
+
+func locationOf(tree *parse.Tree, node parse.Node) string {
+	location, _ := tree.ErrorContext(node)
+	return location
+}
+
+// splitLocation splits a parser location such as "pages/home.html:3:14"
+func splitLocation(location string) (string, int) {
+	parts := strings.Split(location, ":")
+	if len(parts) < 3 {
+		return location, 0
+	}
+	line, _ := strconv.Atoi(parts[len(parts)-2])
+	return strings.Join(parts[:len(parts)-2], ":"), line
+}
+
+func errorLine(err error) int {
+	if m := errorLineRe.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return line
+	}
+	return 0
+}
+
+func dedupeIssues(issues []LintIssue) []LintIssue {
+	seen := make(map[LintIssue]bool)
+	out := issues[:0]
+	for _, issue := range issues {
+		if !seen[issue] {
+			seen[issue] = true
+			out = append(out, issue)
+		}
+	}
+	return out
+}
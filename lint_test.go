@@ -0,0 +1,126 @@
+package tmplx
+
+import (
+	"html/template"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLint(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{
+			Data: []byte(`<title>{{block "title" .}}{{end}}</title>
+{{block "content" .}}{{end}}`),
+		},
+		"pages/home.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/base.html"}}
+{{define "content"}}{{include "partials/nav.html" .}}{{end}}
+{{define "sidebar"}}unused{{end}}`),
+		},
+		"pages/late.html": &fstest.MapFile{
+			Data: []byte(`<p>hi</p>
+{{extend "layouts/missing.html"}}`),
+		},
+		"pages/broken.html": &fstest.MapFile{
+			Data: []byte(`{{if .X}}`),
+		},
+		"pages/funcs.html": &fstest.MapFile{
+			Data: []byte("ok\n{{nope .X}}"),
+		},
+		"partials/nav.html": &fstest.MapFile{
+			Data: []byte(`<nav></nav>`),
+		},
+	}
+
+	engine := New(Options{FS: fsys})
+
+	found := make(map[string]LintIssue)
+	for _, issue := range engine.Lint() {
+		found[issue.Code+" "+issue.File] = issue
+	}
+
+	want := map[string]struct {
+		sev  LintSeverity
+		line int
+	}{
+		LintParseError + " pages/broken.html":    {LintError, 1},
+		LintUndefinedFunc + " pages/funcs.html":  {LintError, 2},
+		LintMissingTemplate + " pages/late.html": {LintError, 2},
+		LintExtendNotFirst + " pages/late.html":  {LintWarning, 2},
+		LintNestedInclude + " pages/home.html":   {LintWarning, 2},
+		LintUnknownBlock + " pages/home.html":    {LintWarning, 3},
+	}
+	for key, w := range want {
+		issue, ok := found[key]
+		if !ok {
+			t.Errorf("Expected issue %s, got %v", key, found)
+			continue
+		}
+		if issue.Severity != w.sev || issue.Line != w.line {
+			t.Errorf("Expected %s to be a %s on line %d, got %s", key, w.sev, w.line, issue)
+		}
+	}
+	if _, ok := found[LintUnknownBlock+" layouts/base.html"]; ok {
+		t.Error("Expected no unknown-block issue for a layout")
+	}
+}
+
+func TestLintDoubleEscape(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pages/home.html": &fstest.MapFile{
+			Data: []byte("<p>ok</p>\n<a title=\"{{bold .Name}}\">x</a>"),
+		},
+	}
+	engine := New(Options{
+		FS: fsys,
+		FuncMap: template.FuncMap{
+			"bold": func(s string) template.HTML { return template.HTML("<b>" + s + "</b>") },
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	issues := engine.Lint()
+	if len(issues) != 1 || issues[0].Code != LintDoubleEscape || issues[0].File != "pages/home.html" || issues[0].Line != 2 {
+		t.Errorf("Expected one double-escape issue on pages/home.html:2, got %v", issues)
+	}
+}
+
+func TestUnused(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{
+			Data: []byte(`{{block "title" .}}Site{{end}}
+{{block "footer" .}}(c){{end}}
+{{block "content" .}}{{end}}`),
+		},
+		"layouts/old.html": &fstest.MapFile{
+			Data: []byte(`{{block "content" .}}{{end}}`),
+		},
+		"partials/nav.html": &fstest.MapFile{
+			Data: []byte(`{{define "title"}}Nav{{end}}<nav></nav>`),
+		},
+		"partials/stale.html": &fstest.MapFile{
+			Data: []byte(`<p>stale</p>`),
+		},
+		"pages/home.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/base.html"}}
+{{include "partials/nav.html" .}}
+{{define "content"}}Home{{end}}
+{{define "sidebar"}}never shown{{end}}`),
+		},
+	}
+
+	report := New(Options{FS: fsys}).Unused()
+
+	if want := []string{"layouts/old.html", "pages/home.html", "partials/stale.html"}; !reflect.DeepEqual(report.Templates, want) {
+		t.Errorf("Expected unreferenced templates %v, got %v", want, report.Templates)
+	}
+	if want := []BlockRef{{File: "layouts/base.html", Block: "footer", Line: 2}}; !reflect.DeepEqual(report.NeverOverridden, want) {
+		t.Errorf("Expected never overridden blocks %v, got %v", want, report.NeverOverridden)
+	}
+	if want := []BlockRef{{File: "pages/home.html", Block: "sidebar", Line: 4}}; !reflect.DeepEqual(report.Undeclared, want) {
+		t.Errorf("Expected undeclared blocks %v, got %v", want, report.Undeclared)
+	}
+}
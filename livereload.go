@@ -0,0 +1,449 @@
+package tmplx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LiveReloadPath is where LiveReload accepts WebSocket connections
+const LiveReloadPath = "/_tmplx/live"
+
+// LiveReload pushes template changes to open pages during development. It
+// wraps the application handler, adding a small script to every HTML page
+// that connects back over a WebSocket. When Watch reloads the engine, each
+// page is rendered again through the application and the output of changed
+// includes is sent to the browser and swapped into place, so forms, scroll
+// position and client state survive. Pages whose markup changed anywhere
+// else are reloaded.
+//
+// Fragments are found by the boundary comments of dev mode, so the engine
+// must have DevMode set.
+type LiveReload struct {
+	engine *TemplateEngine
+	app    http.Handler
+
+	mu      sync.Mutex
+	clients map[*liveClient]bool
+	digests map[string]string
+}
+
+// liveClient is a connected page
+type liveClient struct {
+	conn   net.Conn
+	path   string
+	header http.Header
+	last   string
+	mu     sync.Mutex
+}
+
+// LiveReload returns a handler serving app with live reloading of
+// templates. It adds itself to the engine's AfterReload hooks.
+func (e *TemplateEngine) LiveReload(app http.Handler) *LiveReload {
+	lr := &LiveReload{
+		engine:  e,
+		app:     app,
+		clients: make(map[*liveClient]bool),
+		digests: e.digestsCopy(),
+	}
+	e.mu.Lock()
+	e.afterReload = append(e.afterReload, lr.reloaded)
+	e.mu.Unlock()
+	return lr
+}
+
+// digestsCopy returns a copy of the digests of the loaded templates
+func (e *TemplateEngine) digestsCopy() map[string]string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	cp := make(map[string]string, len(e.digests))
+	for name, digest := range e.digests {
+		cp[name] = digest
+	}
+	return cp
+}
+
+func (lr *LiveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == LiveReloadPath {
+		lr.connect(w, r)
+		return
+	}
+
+	lw := &liveWriter{w: w}
+	lr.app.ServeHTTP(lw, r)
+	lw.finish()
+}
+
+// liveWriter passes a response of the application through, holding back
+// HTML pages to add the live reload script before </body>. Anything else,
+// such as an event stream, is written straight away, and an HTML page the
+// application flushes is streamed from then on, with the script at the end.
+type liveWriter struct {
+	w        http.ResponseWriter
+	status   int
+	html     bool
+	streamed bool
+	buf      bytes.Buffer
+}
+
+func (lw *liveWriter) Header() http.Header {
+	return lw.w.Header()
+}
+
+func (lw *liveWriter) WriteHeader(status int) {
+	if lw.status != 0 {
+		return
+	}
+	lw.status = status
+	lw.html = strings.HasPrefix(lw.w.Header().Get("Content-Type"), "text/html")
+	if !lw.html {
+		lw.w.WriteHeader(status)
+	}
+}
+
+func (lw *liveWriter) Write(p []byte) (int, error) {
+	if lw.status == 0 {
+		lw.WriteHeader(http.StatusOK)
+	}
+	if lw.html && !lw.streamed {
+		return lw.buf.Write(p)
+	}
+	return lw.w.Write(p)
+}
+
+func (lw *liveWriter) Flush() {
+	if lw.html && !lw.streamed {
+		lw.streamed = true
+		lw.w.Header().Del("Content-Length")
+		lw.w.WriteHeader(lw.status)
+		_, _ = lw.buf.WriteTo(lw.w)
+	}
+	_ = http.NewResponseController(lw.w).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the connection
+func (lw *liveWriter) Unwrap() http.ResponseWriter {
+	return lw.w
+}
+
+// finish writes a held back page once the application is done with it
+func (lw *liveWriter) finish() {
+	switch {
+	case !lw.html:
+	case lw.streamed:
+		_, _ = io.WriteString(lw.w, liveScript)
+	default:
+		lw.w.Header().Del("Content-Length")
+		lw.w.WriteHeader(lw.status)
+		_, _ = io.WriteString(lw.w, injectLiveScript(lw.buf.String()))
+	}
+}
+
+func injectLiveScript(body string) string {
+	if i := strings.LastIndex(body, "</body>"); i >= 0 {
+		return body[:i] + liveScript + body[i:]
+	}
+	return body + liveScript
+}
+
+// render renders path through the application as the client would see it
+func (lr *LiveReload) render(path string, header http.Header) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header = header.Clone()
+	buf := &responseBuffer{header: make(http.Header)}
+	lr.app.ServeHTTP(buf, req)
+	if buf.status != 0 && buf.status != http.StatusOK {
+		return "", fmt.Errorf("GET %s: status %d", path, buf.status)
+	}
+	return buf.body.String(), nil
+}
+
+// connect upgrades a page's request to a WebSocket and keeps it until the
+// page goes away
+func (lr *LiveReload) connect(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "expected a WebSocket connection", http.StatusBadRequest)
+		return
+	}
+
+	// render the page before any change so later renders can be compared
+	path := r.URL.Query().Get("path")
+	if !strings.HasPrefix(path, "/") {
+		path = "/"
+	}
+	header := r.Header.Clone()
+	for _, h := range []string{"Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions"} {
+		header.Del(h)
+	}
+	last, err := lr.render(path, header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sum := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+
+	c := &liveClient{conn: conn, path: path, header: header, last: last}
+	lr.mu.Lock()
+	lr.clients[c] = true
+	lr.mu.Unlock()
+
+	// the page never sends anything but a close; wait for it
+	readFrames(rw.Reader)
+	lr.mu.Lock()
+	delete(lr.clients, c)
+	lr.mu.Unlock()
+	conn.Close()
+}
+
+// reloaded is the AfterReload hook, pushing changes to every client
+func (lr *LiveReload) reloaded(ctx context.Context) error {
+	lr.mu.Lock()
+	var changed []string
+	digests := lr.engine.digestsCopy()
+	for name, digest := range digests {
+		if lr.digests[name] != digest {
+			changed = append(changed, name)
+		}
+	}
+	lr.digests = digests
+	clients := make([]*liveClient, 0, len(lr.clients))
+	for c := range lr.clients {
+		clients = append(clients, c)
+	}
+	lr.mu.Unlock()
+
+	if len(changed) == 0 {
+		return nil
+	}
+	var errs []string
+	for _, c := range clients {
+		if err := lr.push(c, changed); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", c.path, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("error pushing to pages: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// liveMessage is sent to a page: either fragments to swap in, by include
+// name and occurrence, or a request to reload
+type liveMessage struct {
+	Reload    bool                `json:"reload,omitempty"`
+	Fragments map[string][]string `json:"fragments,omitempty"`
+}
+
+func (lr *LiveReload) push(c *liveClient, changed []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out, err := lr.render(c.path, c.header)
+	if err != nil {
+		return err
+	}
+
+	msg := liveMessage{Fragments: make(map[string][]string)}
+	prev, next := c.last, out
+	root := rootName(out)
+	for _, name := range changed {
+		if name == root {
+			// the page itself changes whenever an include it uses does
+			continue
+		}
+		if fragments := findRegions(next, name); len(fragments) > 0 {
+			msg.Fragments[name] = fragments
+			prev, next = cutRegions(prev, name), cutRegions(next, name)
+		}
+	}
+	c.last = out
+
+	if prev != next {
+		msg = liveMessage{Reload: true}
+	} else if len(msg.Fragments) == 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return writeTextFrame(c.conn, encoded)
+}
+
+// rootName returns the name of the template whose dev mode comments wrap
+// the whole of output
+func rootName(output string) string {
+	s := strings.TrimSpace(output)
+	if !strings.HasPrefix(s, "<!-- begin ") {
+		return ""
+	}
+	name, _, _ := strings.Cut(s[len("<!-- begin "):], " -->")
+	return name
+}
+
+// findRegions returns the content of every outermost region of output
+// between the dev mode comments of the named include
+func findRegions(output, name string) []string {
+	var regions []string
+	begin, end := "<!-- begin "+name+" -->", "<!-- end "+name+" -->"
+	for {
+		i := strings.Index(output, begin)
+		if i < 0 {
+			return regions
+		}
+		rest := output[i+len(begin):]
+		j := regionEnd(rest, begin, end)
+		if j < 0 {
+			return regions
+		}
+		regions = append(regions, rest[:j])
+		output = rest[j+len(end):]
+	}
+}
+
+// regionEnd returns the offset of the end comment closing a region that
+// starts at s, skipping nested regions of the same name
+func regionEnd(s, begin, end string) int {
+	depth, offset := 0, 0
+	for {
+		e := strings.Index(s[offset:], end)
+		if e < 0 {
+			return -1
+		}
+		b := strings.Index(s[offset:], begin)
+		if b >= 0 && b < e {
+			depth++
+			offset += b + len(begin)
+			continue
+		}
+		if depth == 0 {
+			return offset + e
+		}
+		depth--
+		offset += e + len(end)
+	}
+}
+
+// cutRegions empties every region of the named include in output
+func cutRegions(output, name string) string {
+	for _, region := range findRegions(output, name) {
+		output = strings.Replace(output, "<!-- begin "+name+" -->"+region, "<!-- begin "+name+" -->", 1)
+	}
+	return output
+}
+
+// writeTextFrame writes an unmasked WebSocket text frame
+func writeTextFrame(w io.Writer, payload []byte) error {
+	header := []byte{0x81}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	_, err := w.Write(append(header, payload...))
+	return err
+}
+
+// readFrames reads and discards WebSocket frames until a close frame or
+// an error
+func readFrames(r *bufio.Reader) {
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			return
+		}
+		if head[0]&0x0F == 0x8 {
+			return
+		}
+
+		n := uint64(head[1] & 0x7F)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		if head[1]&0x80 != 0 {
+			n += 4 // mask key
+		}
+		if _, err := io.CopyN(io.Discard, r, int64(n)); err != nil {
+			return
+		}
+	}
+}
+
+var liveScript = `<script>(function () {
+  var proto = location.protocol === "https:" ? "wss://" : "ws://";
+  var ws = new WebSocket(proto + location.host + ` + strconv.Quote(LiveReloadPath) + ` + "?path=" + encodeURIComponent(location.pathname + location.search));
+  ws.onmessage = function (ev) {
+    var msg = JSON.parse(ev.data);
+    if (msg.reload) {
+      location.reload();
+      return;
+    }
+    Object.keys(msg.fragments || {}).forEach(function (name) {
+      var begin = "begin " + name, end = "end " + name, starts = [], node;
+      var walker = document.createTreeWalker(document.documentElement, NodeFilter.SHOW_COMMENT);
+      while ((node = walker.nextNode())) {
+        if (node.data.trim() === begin) starts.push(node);
+      }
+      starts.forEach(function (start, i) {
+        var html = msg.fragments[name][i], depth = 0;
+        if (html === undefined) return;
+        node = start.nextSibling;
+        while (node) {
+          if (node.nodeType === Node.COMMENT_NODE) {
+            var data = node.data.trim();
+            if (data === begin) depth++;
+            if (data === end && depth-- === 0) break;
+          }
+          var next = node.nextSibling;
+          node.remove();
+          node = next;
+        }
+        var tmpl = document.createElement("template");
+        tmpl.innerHTML = html;
+        start.parentNode.insertBefore(tmpl.content, node);
+      });
+    });
+  };
+})();</script>`
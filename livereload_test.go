@@ -0,0 +1,210 @@
+package tmplx
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLiveReload(t *testing.T) {
+	loader := MapLoader{
+		"layouts/base.html": `<body>{{include "partials/nav.html" .}}<main>{{block "content" .}}{{end}}</main></body>`,
+		"partials/nav.html": `<nav>v1</nav>`,
+		"page.html":         `{{extend "layouts/base.html"}}{{define "content"}}<input>{{end}}`,
+	}
+	engine := New(Options{Loader: loader, DevMode: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := engine.RenderHTTP(w, r, "page.html", nil); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	lr := engine.LiveReload(app)
+	srv := httptest.NewServer(lr)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), LiveReloadPath+`"`) || !strings.Contains(string(body), "</script></body>") {
+		t.Errorf("Expected live reload script before </body>, got %s", body)
+	}
+
+	conn := dialLiveReload(t, srv, lr)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	// a changed include is pushed as a fragment
+	loader["partials/nav.html"] = `<nav>v2</nav>`
+	reload(t, engine)
+	var msg liveMessage
+	readMessage(t, r, &msg)
+	if msg.Reload || len(msg.Fragments["partials/nav.html"]) != 1 || !strings.Contains(msg.Fragments["partials/nav.html"][0], "<nav>v2</nav>") {
+		t.Errorf("Expected nav fragment, got %+v", msg)
+	}
+
+	// markup changed outside any include reloads the page
+	loader["page.html"] = `{{extend "layouts/base.html"}}{{define "content"}}<textarea></textarea>{{end}}`
+	reload(t, engine)
+	msg = liveMessage{}
+	readMessage(t, r, &msg)
+	if !msg.Reload {
+		t.Errorf("Expected page reload, got %+v", msg)
+	}
+}
+
+func reload(t *testing.T, engine *TemplateEngine) {
+	t.Helper()
+	if err := engine.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	engine.runReloadHooks(context.Background(), "after", engine.afterReload)
+}
+
+func dialLiveReload(t *testing.T, srv *httptest.Server, lr *LiveReload) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(conn, "GET %s?path=/ HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n", LiveReloadPath)
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Expected WebSocket handshake, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	// wait until the server has registered the client
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		lr.mu.Lock()
+		n := len(lr.clients)
+		lr.mu.Unlock()
+		if n > 0 {
+			return conn
+		}
+	}
+	t.Fatal("client was not registered")
+	return nil
+}
+
+func readMessage(t *testing.T, r *bufio.Reader, v interface{}) {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(head[1] & 0x7F)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		t.Fatalf("%v: %s", err, payload)
+	}
+}
+
+func TestLiveReloadWhileWatching(t *testing.T) {
+	loader := signalLoader{MapLoader: MapLoader{"page.html": "v1"}, changes: make(chan struct{})}
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- engine.Watch(ctx) }()
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := 0; i < 20; i++ {
+			loader.changes <- struct{}{}
+		}
+	}()
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for i := 0; i < 20; i++ {
+		engine.LiveReload(app)
+	}
+	<-sent
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLiveReloadPassThrough(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{"page.html": "<body>hi</body>"}, DevMode: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		http.NewResponseController(w).Flush()
+		<-release
+	})
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": "</body>"}`)
+	})
+	srv := httptest.NewServer(engine.LiveReload(mux))
+	defer srv.Close()
+	defer close(release)
+
+	client := &http.Client{Timeout: time.Second}
+	resp, err := client.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatalf("Expected the event stream to be flushed through: %v", err)
+	}
+	defer resp.Body.Close()
+	line := make(chan string, 1)
+	go func() {
+		s, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		line <- s
+	}()
+	select {
+	case s := <-line:
+		if s != "data: first\n" {
+			t.Errorf("Expected the first event, got %q", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the event stream to be flushed through")
+	}
+
+	resp, err = client.Get(srv.URL + "/data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"body": "</body>"}` {
+		t.Errorf("Expected JSON to pass through unchanged, got %s", body)
+	}
+}
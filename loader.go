@@ -0,0 +1,270 @@
+package tmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+)
+
+// Loader provides template files from any backing store. Names are slash
+// separated paths as used in extend and include directives, e.g.
+// "layouts/base.html".
+type Loader interface {
+	// List returns the names of all available templates
+	List() ([]string, error)
+
+	// Read returns the content of the named template
+	Read(name string) ([]byte, error)
+}
+
+// Watcher is implemented by loaders that can report changes. Watch blocks
+// until ctx is done, calling changed whenever templates may have changed.
+type Watcher interface {
+	Watch(ctx context.Context, changed func()) error
+}
+
+// FSLoader returns a Loader reading every file below dir in fsys. It is what
+// sources configured with Dir and FS use.
+func FSLoader(fsys fs.FS, dir string) Loader {
+	if dir == "" {
+		dir = "."
+	}
+	return &fsLoader{fsys: fsys, dir: dir}
+}
+
+type fsLoader struct {
+	fsys fs.FS
+	dir  string
+}
+
+func (l *fsLoader) List() ([]string, error) {
+	var names []string
+	err := fs.WalkDir(l.fsys, l.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := p
+		if l.dir != "." {
+			name = p[len(l.dir)+1:]
+		}
+		names = append(names, name)
+		return nil
+	})
+	return names, err
+}
+
+func (l *fsLoader) Read(name string) ([]byte, error) {
+	return fs.ReadFile(l.fsys, path.Join(l.dir, name))
+}
+
+// MapLoader is a Loader serving templates from memory, keyed by name
+type MapLoader map[string]string
+
+func (m MapLoader) List() ([]string, error) {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m MapLoader) Read(name string) ([]byte, error) {
+	content, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s: %w", name, fs.ErrNotExist)
+	}
+	return []byte(content), nil
+}
+
+// OverlayLoader layers loaders on top of each other: a template in a later
+// layer replaces the one of the same name in earlier layers. Unlike separate
+// sources, every layer can extend and include templates from any other.
+func OverlayLoader(layers ...Loader) Loader {
+	return overlayLoader(layers)
+}
+
+type overlayLoader []Loader
+
+func (o overlayLoader) List() ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, l := range o {
+		layer, err := l.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range layer {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (o overlayLoader) Read(name string) ([]byte, error) {
+	for i := len(o) - 1; i >= 0; i-- {
+		content, err := o[i].Read(name)
+		if err == nil {
+			return content, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("template %s: %w", name, fs.ErrNotExist)
+}
+
+// sourceLoader returns the Loader reading s
+func sourceLoader(s Source) Loader {
+	if s.Loader != nil {
+		return s.Loader
+	}
+	return FSLoader(s.FS, s.Dir)
+}
+
+// readLoaders snapshots the templates of every loader-backed source into
+// memory, so loading sees a consistent set even if the store changes midway.
+func (e *TemplateEngine) readLoaders() error {
+	for i, s := range e.srcs {
+		if s.Loader == nil {
+			continue
+		}
+		loader, err := e.loaderFor(s)
+		if err != nil {
+			return fmt.Errorf("error reading templates from source %d: %v", i, err)
+		}
+		names, err := loader.List()
+		if err != nil {
+			return fmt.Errorf("error listing templates from source %d: %v", i, err)
+		}
+		snapshot := make(memFS, len(names))
+		for _, name := range names {
+			content, err := loader.Read(name)
+			if err != nil {
+				return fmt.Errorf("error reading template %s from source %d: %v", name, i, err)
+			}
+			snapshot[name] = content
+		}
+		e.srcs[i].FS = snapshot
+	}
+	return nil
+}
+
+// Reload discards every resolved template and loads all sources again, so
+// changes in files or loaders take effect. If loading fails the previously
+// loaded templates stay in use. The new templates are loaded aside and
+// swapped in at once: renders running meanwhile finish with the old ones.
+func (e *TemplateEngine) Reload() error {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	return e.reload()
+}
+
+// reload is Reload with e.loadMu held
+func (e *TemplateEngine) reload() error {
+	fresh := e.derive()
+	if err := fresh.Load(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	cache, loadCache, includes := e.cache, e.loadCache, e.inclCache
+	e.srcs, e.funcMap = fresh.srcs, fresh.funcMap
+	if fresh.contextual.Load() {
+		e.contextual.Store(true)
+	}
+	e.cache, e.loadCache, e.inclCache, e.pageVars = fresh.cache, fresh.loadCache, fresh.inclCache, fresh.pageVars
+	e.parents, e.deps, e.missing = fresh.parents, fresh.deps, fresh.missing
+	e.text, e.digests, e.bases, e.pools, e.marks = fresh.text, fresh.digests, fresh.bases, fresh.pools, fresh.marks
+	e.loaded = true
+	e.mu.Unlock()
+
+	e.dropVersions()
+	e.cacheInvalidated(cache, loadCache, includes)
+	return nil
+}
+
+// loaderWatchers returns the watchers of a loader. Overlays are watched
+// through their layers, and FSLoader layers by polling their directory, so
+// a directory source still reloads when Options.UIPartials layers it.
+func (e *TemplateEngine) loaderWatchers(l Loader) []Watcher {
+	switch l := l.(type) {
+	case overlayLoader:
+		var watchers []Watcher
+		for _, layer := range l {
+			watchers = append(watchers, e.loaderWatchers(layer)...)
+		}
+		return watchers
+	case Watcher:
+		return []Watcher{l}
+	case *fsLoader:
+		if w := e.dirWatcherFor(Source{FS: l.fsys, Dir: l.dir}); w != nil {
+			return []Watcher{w}
+		}
+	}
+	return nil
+}
+
+// Watch reloads the engine whenever a source reports a change, until ctx is
+// done: sources whose Loader implements Watcher, and directories, which are
+// polled every Options.WatchInterval for added, removed and modified files.
+// Reload errors are logged and the previous templates stay in use. The
+// BeforeReload and AfterReload hooks run around every reload; their errors
+// are logged too.
+func (e *TemplateEngine) Watch(ctx context.Context) error {
+	e.mu.RLock()
+	srcs := e.srcs
+	e.mu.RUnlock()
+
+	var watchers []Watcher
+	for _, s := range srcs {
+		if s.Loader != nil {
+			watchers = append(watchers, e.loaderWatchers(s.Loader)...)
+		} else if w := e.dirWatcherFor(s); w != nil {
+			watchers = append(watchers, w)
+		}
+	}
+	if len(watchers) == 0 {
+		return fmt.Errorf("no source supports watching")
+	}
+
+	var mu sync.Mutex
+	changed := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		before, after := e.reloadHooks()
+		e.runReloadHooks(ctx, "before", before)
+		if err := e.Reload(); err != nil {
+			e.logf(LogLoad, LogError, "[TMPLX] Error reloading templates: %v", err)
+			return
+		}
+		e.logf(LogLoad, LogInfo, "[TMPLX] Reloaded templates")
+		e.runReloadHooks(ctx, "after", after)
+	}
+
+	errs := make(chan error, len(watchers))
+	for _, w := range watchers {
+		go func(w Watcher) {
+			errs <- w.Watch(ctx, changed)
+		}(w)
+	}
+
+	var first error
+	for range watchers {
+		if err := <-errs; err != nil && first == nil && ctx.Err() == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,267 @@
+package tmplx
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMapLoader(t *testing.T) {
+	loader := MapLoader{
+		"layouts/base.html": `<body>{{block "content" .}}{{end}}</body>`,
+		"pages/home.html":   `{{extend "layouts/base.html"}}{{define "content"}}v1{{end}}`,
+	}
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("pages/home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "<body>v1</body>" {
+		t.Errorf("Unexpected output %q", result)
+	}
+
+	loader["pages/home.html"] = `{{extend "layouts/base.html"}}{{define "content"}}v2{{end}}`
+	if err := engine.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "<body>v2</body>" {
+		t.Errorf("Expected reloaded output, got %q", result)
+	}
+
+	loader["pages/home.html"] = `{{extend "layouts/base.html"}}{{define "content"}}{{if}}{{end}}`
+	if err := engine.Reload(); err == nil {
+		t.Fatal("Expected reload to fail")
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "<body>v2</body>" {
+		t.Errorf("Expected previous templates after a failed reload, got %q", result)
+	}
+}
+
+func TestConcurrentReload(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{
+		"layouts/base.html": `<body>{{block "content" .}}{{end}}</body>`,
+		"pages/home.html":   `{{extend "layouts/base.html"}}{{define "content"}}{{.}}{{end}}`,
+	}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				// alternate the shared funcs and funcs bound to the render
+				var opts []RenderOption
+				if j%2 == 0 {
+					opts = append(opts, WithLocale("de"))
+				}
+				result, err := engine.Render("pages/home.html", i, opts...)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if want := fmt.Sprintf("<body>%d</body>", i); result != want {
+					errs <- fmt.Errorf("expected %q, got %q", want, result)
+					return
+				}
+			}
+		}(i)
+	}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if err := engine.Reload(); err != nil {
+					errs <- err
+					return
+				}
+				if err := engine.AddFuncs(template.FuncMap{fmt.Sprintf("f%d_%d", i, j): strings.ToUpper}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestConcurrentStoreRenders renders pages using the render store while
+// AddFuncs and Reload store templates again, which the race detector checks
+func TestConcurrentStoreRenders(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{
+		"pages/home.html":  `{{ctxSet "title" .}}{{ctx "title"}}`,
+		"pages/plain.html": `{{.}}`,
+	}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				name := "pages/home.html"
+				if j%2 == 0 {
+					name = "pages/plain.html"
+				}
+				result, err := engine.Render(name, i)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if want := fmt.Sprint(i); result != want {
+					errs <- fmt.Errorf("%s: expected %q, got %q", name, want, result)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 5; j++ {
+			if err := engine.AddFuncs(template.FuncMap{fmt.Sprintf("g%d", j): strings.ToUpper}); err != nil {
+				errs <- err
+				return
+			}
+			if err := engine.Reload(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestConcurrentLimitedRenders renders on an engine with limits, whose
+// funcs are bound per render, while AddFuncs registers more
+func TestConcurrentLimitedRenders(t *testing.T) {
+	engine := New(Options{
+		Loader:  MapLoader{"pages/home.html": `{{upper .}}`},
+		FuncMap: template.FuncMap{"upper": strings.ToUpper},
+		Limits:  Limits{MaxCalls: 10},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if result, err := engine.Render("pages/home.html", "a"); err != nil || result != "A" {
+					errs <- fmt.Errorf("expected A, got %q, %v", result, err)
+					return
+				}
+			}
+		}()
+	}
+	for j := 0; j < 200; j++ {
+		if err := engine.AddFuncs(template.FuncMap{fmt.Sprintf("h%d", j): strings.ToLower}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestFSLoader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/pages/home.html": &fstest.MapFile{Data: []byte(`home`)},
+		"templates/readme.txt":      &fstest.MapFile{Data: []byte(`not a template`)},
+	}
+	engine := New(Options{Loader: FSLoader(fsys, "templates")})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := engine.Render("pages/home.html", nil); err != nil || result != "home" {
+		t.Errorf("Expected home, got %q, %v", result, err)
+	}
+	if _, err := engine.GetTemplate("readme.txt"); err == nil {
+		t.Error("Expected files without a template extension to be skipped")
+	}
+}
+
+type signalLoader struct {
+	MapLoader
+	changes chan struct{}
+}
+
+func (l signalLoader) Watch(ctx context.Context, changed func()) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-l.changes:
+			changed()
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	loader := signalLoader{MapLoader: MapLoader{"page.html": "v1"}, changes: make(chan struct{})}
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- engine.Watch(ctx) }()
+
+	loader.MapLoader["page.html"] = "v2"
+	loader.changes <- struct{}{}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not return after cancel")
+	}
+
+	if result, _ := engine.Render("page.html", nil); result != "v2" {
+		t.Errorf("Expected watched change to reload, got %q", result)
+	}
+
+	if err := New(Options{Loader: MapLoader{}}).Watch(context.Background()); err == nil {
+		t.Error("Expected an error when no source can be watched")
+	}
+}
@@ -0,0 +1,231 @@
+package tmplx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type localeContextKey struct{}
+
+// ContextWithLocale returns a copy of ctx carrying the render locale
+func ContextWithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// LocaleFromContext returns the locale stored by ContextWithLocale or
+// LocaleMiddleware, or "" if there is none.
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeContextKey{}).(string)
+	return locale
+}
+
+func (e *TemplateEngine) setupLocales(opts Options) {
+	if len(e.locales) > 0 {
+		e.defaultLocale = e.locales[0]
+	}
+	if e.localeParam == "" {
+		e.localeParam = "lang"
+	}
+	if e.localeCookie == "" {
+		e.localeCookie = "lang"
+	}
+
+	if _, userDefined := opts.FuncMap["locale"]; !userDefined {
+		e.registerRenderFunc("locale", func(st *renderState) any {
+			return func() string { return st.locale }
+		})
+	}
+
+	for name, factory := range opts.LocaleFuncs {
+		typ, err := localeFuncType(name, factory, opts.Locales)
+		if err != nil {
+			e.logf(LogLoad, LogError, "[TMPLX] %v", err)
+			continue
+		}
+		e.registerRenderFunc(name, localeFunc(factory, typ))
+	}
+}
+
+// localeFuncType returns the type of the funcs a LocaleFuncs factory returns,
+// checking it returns a func of the same type for every locale
+func localeFuncType(name string, factory func(locale string) any, locales []string) (reflect.Type, error) {
+	if factory == nil {
+		return nil, fmt.Errorf("LocaleFuncs: %q is nil", name)
+	}
+	if len(locales) == 0 {
+		locales = []string{""}
+	}
+	var typ reflect.Type
+	for _, locale := range locales {
+		t := reflect.TypeOf(factory(locale))
+		if t == nil || t.Kind() != reflect.Func {
+			return nil, fmt.Errorf("LocaleFuncs: %q returns %v for locale %q, not a func", name, t, locale)
+		}
+		if typ != nil && t != typ {
+			return nil, fmt.Errorf("LocaleFuncs: %q returns %v for locale %q but %v for %q", name, t, locale, typ, locales[0])
+		}
+		typ = t
+	}
+	return typ, nil
+}
+
+// localeFunc adapts a LocaleFuncs factory into a render func. The factory is
+// called with the locale of the render each time the template calls the func.
+func localeFunc(factory func(locale string) any, typ reflect.Type) renderFunc {
+	return func(st *renderState) any {
+		return reflect.MakeFunc(typ, func(args []reflect.Value) []reflect.Value {
+			fn := reflect.ValueOf(factory(st.locale))
+			if typ.IsVariadic() {
+				return fn.CallSlice(args)
+			}
+			return fn.Call(args)
+		}).Interface()
+	}
+}
+
+// NegotiateLocale picks the best of Options.Locales for the request. The
+// locale query parameter wins over the locale cookie, which wins over the
+// Accept-Language header. It returns the default locale if nothing matches.
+func (e *TemplateEngine) NegotiateLocale(r *http.Request) string {
+	if len(e.locales) == 0 {
+		return ""
+	}
+
+	if v := r.URL.Query().Get(e.localeParam); v != "" {
+		if locale := matchLocale(e.locales, v); locale != "" {
+			return locale
+		}
+	}
+
+	if c, err := r.Cookie(e.localeCookie); err == nil && c.Value != "" {
+		if locale := matchLocale(e.locales, c.Value); locale != "" {
+			return locale
+		}
+	}
+
+	for _, tag := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if locale := matchLocale(e.locales, tag); locale != "" {
+			return locale
+		}
+	}
+
+	return e.defaultLocale
+}
+
+// localeVariant returns the variant of the named template for locale, such
+// as pages/home.de-AT.html or pages/home.de.html for "de-AT", or name itself
+// when there is none. Without a locale the default locale's variant is used.
+func (e *TemplateEngine) localeVariant(name, locale string) string {
+	if locale == "" {
+		locale = e.defaultLocale
+	}
+	if locale == "" {
+		return name
+	}
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for _, tag := range []string{locale, primaryLanguage(locale)} {
+		if _, ok := e.executor(base + "." + tag + ext); ok {
+			return base + "." + tag + ext
+		}
+	}
+	return name
+}
+
+// LocaleMiddleware negotiates the locale for each request and stores it in
+// the request context, where RenderHTTP picks it up automatically.
+func (e *TemplateEngine) LocaleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := e.NegotiateLocale(r)
+		next.ServeHTTP(w, r.WithContext(ContextWithLocale(r.Context(), locale)))
+	})
+}
+
+// requestLocale returns the locale for rendering r: one stored in the request
+// context, or one negotiated from the request if locales are configured.
+func (e *TemplateEngine) requestLocale(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if locale := LocaleFromContext(r.Context()); locale != "" {
+		return locale
+	}
+	return e.NegotiateLocale(r)
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language header
+// ordered by descending quality. Tags with q=0 are dropped.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// matchLocale matches a requested language tag against the supported
+// locales: exactly first, then by primary language in either direction
+// ("de-CH" matches "de", and "de" matches "de-DE").
+func matchLocale(supported []string, tag string) string {
+	tag = strings.ReplaceAll(tag, "_", "-")
+	for _, locale := range supported {
+		if strings.EqualFold(locale, tag) {
+			return locale
+		}
+	}
+
+	base := primaryLanguage(tag)
+	for _, locale := range supported {
+		if strings.EqualFold(locale, base) {
+			return locale
+		}
+	}
+	for _, locale := range supported {
+		if strings.EqualFold(primaryLanguage(locale), base) {
+			return locale
+		}
+	}
+	return ""
+}
+
+func primaryLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i != -1 {
+		return tag[:i]
+	}
+	return tag
+}
@@ -0,0 +1,201 @@
+package tmplx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func newLocaleTestEngine(t *testing.T) *TemplateEngine {
+	t.Helper()
+
+	catalogs := map[string]map[string]string{
+		"en":    {"greeting": "Hello"},
+		"de":    {"greeting": "Hallo"},
+		"fr-CA": {"greeting": "Bonjour"},
+	}
+
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{
+			Data: []byte(`<html lang="{{locale}}">{{block "content" .}}{{end}}</html>`),
+		},
+		"pages/home.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}<p>{{t "greeting"}}, {{.Name}}</p>{{end}}`),
+		},
+	}
+
+	engine := New(Options{
+		FS:      fsys,
+		Locales: []string{"en", "de", "fr-CA"},
+		LocaleFuncs: map[string]func(locale string) any{
+			"t": func(locale string) any {
+				return func(key string) string { return catalogs[locale][key] }
+			},
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+func TestRenderWithLocale(t *testing.T) {
+	engine := newLocaleTestEngine(t)
+
+	result, err := engine.Render("pages/home.html", H{"Name": "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{`<html lang="en">`, "<p>Hello, Ada</p>"}, result)
+
+	result, err = engine.Render("pages/home.html", H{"Name": "Ada"}, WithLocale("de"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{`<html lang="de">`, "<p>Hallo, Ada</p>"}, result)
+}
+
+func TestLocaleVariants(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html":     `<html lang="{{locale}}">{{block "content" .}}{{end}}</html>`,
+			"pages/home.html":       `{{extend "layouts/base.html"}}{{define "content"}}home{{end}}`,
+			"pages/home.de.html":    `{{extend "layouts/base.html"}}{{define "content"}}Startseite{{end}}`,
+			"pages/home.fr-CA.html": `{{extend "layouts/base.html"}}{{define "content"}}accueil{{end}}`,
+		},
+		Locales: []string{"en", "de", "de-AT", "fr-CA"},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"":      `<html lang="en">home</html>`,
+		"de":    `<html lang="de">Startseite</html>`,
+		"de-AT": `<html lang="de-AT">Startseite</html>`,
+		"fr-CA": `<html lang="fr-CA">accueil</html>`,
+	}
+	for locale, expected := range tests {
+		result, err := engine.Render("pages/home.html", nil, WithLocale(locale))
+		if err != nil {
+			t.Fatalf("%s: %v", locale, err)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %s, got %s", locale, expected, result)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "de-AT,de;q=0.8")
+	rec := httptest.NewRecorder()
+	if err := engine.RenderHTTP(rec, r, "pages/home.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<html lang="de-AT">Startseite</html>`; rec.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, rec.Body.String())
+	}
+}
+
+func TestRenderWithLocaleConcurrent(t *testing.T) {
+	engine := newLocaleTestEngine(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			locale, expected := "de", "Hallo"
+			if i%2 == 0 {
+				locale, expected = "fr-CA", "Bonjour"
+			}
+			result, err := engine.Render("pages/home.html", H{"Name": "Ada"}, WithLocale(locale))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			containsAll(t, []string{"<p>" + expected + ", Ada</p>"}, result)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	engine := newLocaleTestEngine(t)
+
+	tests := []struct {
+		name     string
+		target   string
+		header   string
+		cookie   string
+		expected string
+	}{
+		{"Default without header", "/", "", "", "en"},
+		{"Exact match", "/", "de", "", "de"},
+		{"Quality ordering", "/", "es;q=0.9, fr-CA;q=0.5, de;q=0.7", "", "de"},
+		{"Region falls back to language", "/", "de-CH", "", "de"},
+		{"Language matches region", "/", "fr", "", "fr-CA"},
+		{"Zero quality is ignored", "/", "de;q=0, fr", "", "fr-CA"},
+		{"Cookie overrides header", "/", "de", "fr-CA", "fr-CA"},
+		{"Query overrides cookie", "/?lang=de", "en", "fr-CA", "de"},
+		{"Unknown query is ignored", "/?lang=xx", "de", "", "de"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("Accept-Language", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+			if got := engine.NegotiateLocale(req); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLocaleMiddleware(t *testing.T) {
+	engine := newLocaleTestEngine(t)
+
+	handler := engine.LocaleMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := engine.RenderHTTP(w, r, "pages/home.html", H{"Name": "Ada"}); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.9,en;q=0.5")
+	handler.ServeHTTP(rec, req)
+
+	containsAll(t, []string{`<html lang="de">`, "<p>Hallo, Ada</p>"}, rec.Body.String())
+}
+
+func TestLocaleFuncsMismatch(t *testing.T) {
+	opts := Options{
+		Loader:  MapLoader{"page.html": `{{t "hello"}}`},
+		Locales: []string{"en", "de"},
+		LocaleFuncs: map[string]func(locale string) any{
+			"t": func(locale string) any {
+				if locale == "de" {
+					return func(key string, n int) string { return key }
+				}
+				return func(key string) string { return key }
+			},
+		},
+	}
+	_, err := NewE(opts)
+	if err == nil || !strings.Contains(err.Error(), `LocaleFuncs: "t" returns func(string, int) string for locale "de"`) {
+		t.Errorf("Expected a mismatch error, got %v", err)
+	}
+
+	// New logs the mismatch instead of panicking, and the func stays undefined
+	if err := New(opts).Load(); err == nil {
+		t.Error("Expected Load to fail on the undefined func")
+	}
+}
@@ -0,0 +1,160 @@
+package tmplx
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+)
+
+// LogCategory identifies the engine subsystem a log line comes from
+type LogCategory string
+
+const (
+	// LogLoad covers walking sources and loading template files
+	LogLoad LogCategory = "load"
+
+	// LogInheritance covers resolving extends chains
+	LogInheritance LogCategory = "inheritance"
+
+	// LogIncludes covers processing include directives
+	LogIncludes LogCategory = "includes"
+
+	// LogRender covers executing templates
+	LogRender LogCategory = "render"
+
+	// LogCache covers hits in the load and include caches
+	LogCache LogCategory = "cache"
+)
+
+// LogLevel sets how much a category logs. Each level includes the ones
+// before it, so LogWarn also logs errors.
+type LogLevel int
+
+const (
+	// LogDefault uses Options.LogLevel, or LogInfo if that is unset too
+	LogDefault LogLevel = iota
+
+	// LogOff disables logging
+	LogOff
+
+	// LogError logs failures only
+	LogError
+
+	// LogWarn logs failures and problems that don't stop the engine
+	LogWarn
+
+	// LogInfo logs major steps such as loading templates
+	LogInfo
+
+	// LogDebug logs every step, including each file resolved and cache hit
+	LogDebug
+)
+
+// logLevel returns the effective level of a category
+func (e *TemplateEngine) logLevel(cat LogCategory) LogLevel {
+	if level := e.logLevels[cat]; level != LogDefault {
+		return level
+	}
+	if e.logDefault != LogDefault {
+		return e.logDefault
+	}
+	return LogInfo
+}
+
+// logf logs a line in the given category if its level is enabled
+func (e *TemplateEngine) logf(cat LogCategory, level LogLevel, format string, args ...interface{}) {
+	if level > e.logLevel(cat) {
+		return
+	}
+	switch level {
+	case LogError:
+		e.logger.Errorf(format, args...)
+	case LogWarn:
+		e.logger.Warnf(format, args...)
+	case LogDebug:
+		e.logger.Debugf(format, args...)
+	default:
+		e.logger.Infof(format, args...)
+	}
+}
+
+// LevelLogger is a Logger with a method per level. It is satisfied as is by
+// zap's SugaredLogger and logrus' Logger and Entry; StdLogger and SlogLogger
+// adapt the standard library loggers.
+type LevelLogger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// AdaptLogger returns l as a LevelLogger. Loggers that only implement Infof
+// get every level sent to Infof.
+func AdaptLogger(l Logger) LevelLogger {
+	if ll, ok := l.(LevelLogger); ok {
+		return ll
+	}
+	return infoLogger{l}
+}
+
+type infoLogger struct {
+	Logger
+}
+
+func (l infoLogger) Debugf(format string, args ...interface{}) { l.Infof(format, args...) }
+func (l infoLogger) Warnf(format string, args ...interface{})  { l.Infof(format, args...) }
+func (l infoLogger) Errorf(format string, args ...interface{}) { l.Infof(format, args...) }
+
+// StdLogger adapts a standard library logger, prefixing each line with its
+// level. A nil logger uses log.Default().
+func StdLogger(l *log.Logger) LevelLogger {
+	if l == nil {
+		l = log.Default()
+	}
+	return stdLogger{l}
+}
+
+type stdLogger struct {
+	l *log.Logger
+}
+
+func (s stdLogger) Debugf(format string, args ...interface{}) { s.l.Printf("DEBUG "+format, args...) }
+func (s stdLogger) Infof(format string, args ...interface{})  { s.l.Printf("INFO "+format, args...) }
+func (s stdLogger) Warnf(format string, args ...interface{})  { s.l.Printf("WARN "+format, args...) }
+func (s stdLogger) Errorf(format string, args ...interface{}) { s.l.Printf("ERROR "+format, args...) }
+
+// SlogLogger adapts a structured logger, logging each line as a message at
+// the matching slog level. A nil logger uses slog.Default().
+func SlogLogger(l *slog.Logger) LevelLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return slogLogger{l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) logf(level slog.Level, format string, args ...interface{}) {
+	if s.l.Enabled(context.Background(), level) {
+		s.l.Log(context.Background(), level, fmt.Sprintf(format, args...))
+	}
+}
+
+func (s slogLogger) Debugf(format string, args ...interface{}) {
+	s.logf(slog.LevelDebug, format, args...)
+}
+
+func (s slogLogger) Infof(format string, args ...interface{}) {
+	s.logf(slog.LevelInfo, format, args...)
+}
+
+func (s slogLogger) Warnf(format string, args ...interface{}) {
+	s.logf(slog.LevelWarn, format, args...)
+}
+
+func (s slogLogger) Errorf(format string, args ...interface{}) {
+	s.logf(slog.LevelError, format, args...)
+}
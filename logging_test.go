@@ -0,0 +1,119 @@
+package tmplx
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLogLevels(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{Data: []byte(`{{include "partials/nav.html" .}}{{block "content" .}}{{end}}`)},
+		"partials/nav.html": &fstest.MapFile{Data: []byte(`<nav></nav>`)},
+		"pages/home.html":   &fstest.MapFile{Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}Home{{end}}`)},
+	}
+
+	count := func(lines []string, substr string) int {
+		n := 0
+		for _, line := range lines {
+			if strings.Contains(line, substr) {
+				n++
+			}
+		}
+		return n
+	}
+
+	tests := []struct {
+		name   string
+		opts   Options
+		expect map[string]bool
+	}{
+		{"default", Options{}, map[string]bool{
+			"Loading templates": true, "Resolving inheritance": false, "Processing include": false, "Rendering": false,
+		}},
+		{"debug", Options{LogLevel: LogDebug}, map[string]bool{
+			"Loading templates": true, "Resolving inheritance": true, "Processing include": true, "Returning cached": true, "Rendering": true,
+		}},
+		{"per category", Options{LogLevel: LogOff, LogLevels: map[LogCategory]LogLevel{LogInheritance: LogDebug}}, map[string]bool{
+			"Loading templates": false, "Resolving inheritance": true, "Processing include": false, "Returning cached": false,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			opts := tt.opts
+			opts.FS = fsys
+			opts.Logger = logger
+			engine := New(opts)
+			if err := engine.Load(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := engine.Render("pages/home.html", nil); err != nil {
+				t.Fatal(err)
+			}
+			for substr, want := range tt.expect {
+				if got := count(logger.lines, substr) > 0; got != want {
+					t.Errorf("Expected %q logged: %v, got lines %q", substr, want, logger.lines)
+				}
+			}
+		})
+	}
+}
+
+type levelRecorder struct {
+	lines []string
+}
+
+func (l *levelRecorder) Debugf(format string, args ...interface{}) {
+	l.lines = append(l.lines, "debug: "+fmt.Sprintf(format, args...))
+}
+func (l *levelRecorder) Infof(format string, args ...interface{}) {
+	l.lines = append(l.lines, "info: "+fmt.Sprintf(format, args...))
+}
+func (l *levelRecorder) Warnf(format string, args ...interface{}) {
+	l.lines = append(l.lines, "warn: "+fmt.Sprintf(format, args...))
+}
+func (l *levelRecorder) Errorf(format string, args ...interface{}) {
+	l.lines = append(l.lines, "error: "+fmt.Sprintf(format, args...))
+}
+
+func TestLevelLogger(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pages/home.html": &fstest.MapFile{Data: []byte(`{{missing .}}`)},
+	}
+
+	logger := &levelRecorder{}
+	engine := New(Options{FS: fsys, Logger: logger, LogLevel: LogDebug, UnknownFuncs: UnknownFuncIgnore})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(logger.lines, "\n")
+	containsAll(t, []string{"info: [TMPLX] Loading templates", "debug: [TMPLX] Resolving inheritance", `warn: [TMPLX] Warning: function "missing"`}, got)
+
+	var buf bytes.Buffer
+	std := StdLogger(log.New(&buf, "", 0))
+	std.Warnf("careful %d", 1)
+	std.Errorf("broken")
+	if buf.String() != "WARN careful 1\nERROR broken\n" {
+		t.Errorf("Unexpected std log output %q", buf.String())
+	}
+
+	buf.Reset()
+	sl := SlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	sl.Debugf("hidden")
+	sl.Warnf("careful %d", 2)
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, `level=WARN msg="careful 2"`) {
+		t.Errorf("Unexpected slog output %q", out)
+	}
+
+	plain := &recordingLogger{}
+	AdaptLogger(plain).Errorf("still %s", "logged")
+	if len(plain.lines) != 1 || plain.lines[0] != "still logged" {
+		t.Errorf("Expected Infof-only loggers to receive every level, got %q", plain.lines)
+	}
+}
@@ -0,0 +1,22 @@
+package tmplx
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestMemFS(t *testing.T) {
+	fsys := memFS{
+		"page.html":          []byte("page"),
+		"layouts/base.html":  []byte("base"),
+		"partials/a/b.html":  []byte("b"),
+		"partials/a/c.html":  []byte("c"),
+		"partials/menu.html": []byte("menu"),
+	}
+	if err := fstest.TestFS(fsys, "page.html", "layouts/base.html", "partials/a/b.html", "partials/a/c.html", "partials/menu.html"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(memFS{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,87 @@
+package tmplx
+
+import (
+	"reflect"
+)
+
+// memoKey identifies a call of a pure func: its name and an array of its
+// arguments
+type memoKey struct {
+	name string
+	args any
+}
+
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+// setupMemo makes the funcs named in Options.PureFuncs remember their
+// results for the length of a render, so calling one again with the same
+// arguments, e.g. a translation lookup inside a range, doesn't run it again.
+func (e *TemplateEngine) setupMemo(opts Options) {
+	for _, name := range opts.PureFuncs {
+		fn, ok := e.funcMap[name]
+		if !ok {
+			e.logf(LogLoad, LogError, "[TMPLX] Pure func %s is not defined", name)
+			continue
+		}
+		rf, ok := e.renderFuncs[name]
+		if !ok {
+			rf = func(*renderState) any { return fn }
+		}
+		e.registerRenderFunc(name, memoFunc(name, rf))
+	}
+	e.memoized = len(opts.PureFuncs) > 0
+}
+
+// memoFunc wraps a render func to return the result of an earlier call of
+// the render with the same arguments. Calls with arguments that can't be
+// compared, such as slices, always run.
+func memoFunc(name string, rf renderFunc) renderFunc {
+	return func(st *renderState) any {
+		fn := reflect.ValueOf(rf(st))
+		return reflect.MakeFunc(fn.Type(), func(args []reflect.Value) []reflect.Value {
+			call := func() []reflect.Value {
+				if fn.Type().IsVariadic() {
+					return fn.CallSlice(args)
+				}
+				return fn.Call(args)
+			}
+			if st.shared {
+				return call()
+			}
+			key, ok := newMemoKey(name, args, fn.Type().IsVariadic())
+			if !ok {
+				return call()
+			}
+			if out, ok := st.memo[key]; ok {
+				return out
+			}
+			out := call()
+			if st.memo == nil {
+				st.memo = make(map[memoKey][]reflect.Value)
+			}
+			st.memo[key] = out
+			return out
+		}).Interface()
+	}
+}
+
+// newMemoKey returns the key of a call, spreading the arguments of a
+// variadic func, or false if an argument can't be compared
+func newMemoKey(name string, args []reflect.Value, variadic bool) (memoKey, bool) {
+	if variadic {
+		last := args[len(args)-1]
+		spread := append([]reflect.Value(nil), args[:len(args)-1]...)
+		for i := 0; i < last.Len(); i++ {
+			spread = append(spread, last.Index(i))
+		}
+		args = spread
+	}
+	key := reflect.New(reflect.ArrayOf(len(args), anyType)).Elem()
+	for i, arg := range args {
+		if !arg.Comparable() {
+			return memoKey{}, false
+		}
+		key.Index(i).Set(arg)
+	}
+	return memoKey{name: name, args: key.Interface()}, true
+}
@@ -0,0 +1,86 @@
+package tmplx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPureFuncs(t *testing.T) {
+	calls := map[string]int{}
+	engine := New(Options{
+		Loader: MapLoader{
+			"pages/list.html": `{{range .}}<li>{{t "item"}} {{join "-" "x" (index . 0)}} {{sum .}}</li>{{end}}`,
+		},
+		FuncMap: map[string]interface{}{
+			"t": func(key string) string {
+				calls["t"]++
+				return strings.ToUpper(key)
+			},
+			"join": func(sep string, parts ...string) string {
+				calls["join"]++
+				return strings.Join(parts, sep)
+			},
+			"sum": func(v []string) int {
+				calls["sum"]++
+				return len(v)
+			},
+		},
+		PureFuncs: []string{"t", "join", "sum"},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := [][]string{{"a", "b"}, {"a", "b"}, {"c"}}
+	result, err := engine.Render("pages/list.html", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "<li>ITEM x-a 2</li><li>ITEM x-a 2</li><li>ITEM x-c 1</li>"; result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	// slices can't be compared, so sum runs every time
+	expected := map[string]int{"t": 1, "join": 2, "sum": 3}
+	if fmt.Sprint(calls) != fmt.Sprint(expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+
+	// results don't outlive the render
+	if _, err := engine.Render("pages/list.html", data); err != nil {
+		t.Fatal(err)
+	}
+	if calls["t"] != 2 {
+		t.Errorf("Expected t to run again in a new render, ran %d times", calls["t"])
+	}
+}
+
+func TestPureLocaleFuncs(t *testing.T) {
+	calls := 0
+	engine := New(Options{
+		Loader:  MapLoader{"pages/home.html": `{{greet}} {{greet}}`},
+		Locales: []string{"en", "fr"},
+		LocaleFuncs: map[string]func(locale string) any{
+			"greet": func(locale string) any {
+				return func() string {
+					calls++
+					return map[string]string{"en": "hi", "fr": "salut"}[locale]
+				}
+			},
+		},
+		PureFuncs: []string{"greet"},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("pages/home.html", nil, WithLocale("fr")); result != "salut salut" {
+		t.Errorf("Unexpected output %q", result)
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "hi hi" {
+		t.Errorf("Unexpected output %q", result)
+	}
+	if calls != 2 {
+		t.Errorf("Expected one call per render, got %d", calls)
+	}
+}
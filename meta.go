@@ -0,0 +1,91 @@
+package tmplx
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// Meta describes a page for search engines and link previews. Set it in the
+// page data and render it in a layout's head with {{meta .Meta}}, which
+// writes the title, description, Open Graph and Twitter card tags.
+type Meta struct {
+	Title       string
+	Description string
+
+	// URL is the canonical URL of the page
+	URL string
+
+	// Image is the URL of the preview image
+	Image string
+
+	// Type is the og:type, defaulting to "website"
+	Type string
+
+	SiteName string
+
+	// TwitterCard defaults to "summary_large_image" with an Image and
+	// "summary" without
+	TwitterCard string
+
+	// TwitterSite is the @username of the site
+	TwitterSite string
+}
+
+var metaPartial = template.Must(template.New("meta").Parse(`
+{{- with .Title}}<title>{{.}}</title>
+<meta property="og:title" content="{{.}}">
+<meta name="twitter:title" content="{{.}}">
+{{end}}
+{{- with .Description}}<meta name="description" content="{{.}}">
+<meta property="og:description" content="{{.}}">
+<meta name="twitter:description" content="{{.}}">
+{{end}}
+{{- with .URL}}<link rel="canonical" href="{{.}}">
+<meta property="og:url" content="{{.}}">
+{{end}}
+{{- with .Image}}<meta property="og:image" content="{{.}}">
+<meta name="twitter:image" content="{{.}}">
+{{end}}
+{{- with .SiteName}}<meta property="og:site_name" content="{{.}}">
+{{end}}
+{{- with .TwitterSite}}<meta name="twitter:site" content="{{.}}">
+{{end}}
+<meta property="og:type" content="{{.Type}}">
+<meta name="twitter:card" content="{{.TwitterCard}}">`))
+
+// MetaTags renders the head tags describing m. It is available in templates
+// as meta, which also accepts a *Meta; a nil *Meta renders nothing.
+func MetaTags(m Meta) (template.HTML, error) {
+	if m.Type == "" {
+		m.Type = "website"
+	}
+	if m.TwitterCard == "" {
+		m.TwitterCard = "summary"
+		if m.Image != "" {
+			m.TwitterCard = "summary_large_image"
+		}
+	}
+
+	var b strings.Builder
+	if err := metaPartial.Execute(&b, m); err != nil {
+		return "", fmt.Errorf("error rendering meta tags: %v", err)
+	}
+	return template.HTML(b.String()), nil
+}
+
+// metaFunc is the meta template func
+func metaFunc(v interface{}) (template.HTML, error) {
+	switch m := v.(type) {
+	case Meta:
+		return MetaTags(m)
+	case *Meta:
+		if m == nil {
+			return "", nil
+		}
+		return MetaTags(*m)
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("meta expects a tmplx.Meta, got %T", v)
+}
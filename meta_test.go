@@ -0,0 +1,52 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMeta(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html": `<head>{{meta .Meta}}</head>{{block "content" .}}{{end}}`,
+			"page.html":         `{{extend "layouts/base.html"}}{{define "content"}}<p>page</p>{{end}}`,
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("page.html", H{"Meta": Meta{
+		Title:       `Tom & Jerry`,
+		Description: `A "classic"`,
+		URL:         "https://example.com/tom",
+		Image:       "https://example.com/tom.png",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{
+		`<title>Tom &amp; Jerry</title>`,
+		`<meta property="og:title" content="Tom &amp; Jerry">`,
+		`<meta name="description" content="A &#34;classic&#34;">`,
+		`<link rel="canonical" href="https://example.com/tom">`,
+		`<meta property="og:image" content="https://example.com/tom.png">`,
+		`<meta property="og:type" content="website">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+	}, result)
+	if strings.Contains(result, "og:site_name") {
+		t.Errorf("Expected unset fields left out, got %s", result)
+	}
+
+	result, err = engine.Render("page.html", H{"Meta": (*Meta)(nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "<head></head><p>page</p>" {
+		t.Errorf("Expected no tags for nil meta, got %q", result)
+	}
+
+	if _, err := engine.Render("page.html", H{"Meta": "title"}); err == nil {
+		t.Error("Expected error for wrong meta type, got nil")
+	}
+}
@@ -0,0 +1,105 @@
+package tmplx
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// currency is the symbol and number of minor unit digits of a currency
+type currency struct {
+	symbol string
+	digits int
+}
+
+// currencies are the ISO 4217 currencies the money func knows
+var currencies = map[string]currency{
+	"AUD": {"A$", 2}, "BHD": {"BHD", 3}, "BRL": {"R$", 2}, "CAD": {"CA$", 2},
+	"CHF": {"CHF", 2}, "CLP": {"CLP", 0}, "CNY": {"CN¥", 2}, "CZK": {"CZK", 2},
+	"DKK": {"DKK", 2}, "EUR": {"€", 2}, "GBP": {"£", 2}, "HKD": {"HK$", 2},
+	"HUF": {"HUF", 2}, "IDR": {"IDR", 2}, "ILS": {"₪", 2}, "INR": {"₹", 2},
+	"ISK": {"ISK", 0}, "JOD": {"JOD", 3}, "JPY": {"¥", 0}, "KRW": {"₩", 0},
+	"KWD": {"KWD", 3}, "MXN": {"MX$", 2}, "NOK": {"NOK", 2}, "NZD": {"NZ$", 2},
+	"OMR": {"OMR", 3}, "PLN": {"PLN", 2}, "SEK": {"SEK", 2}, "SGD": {"SGD", 2},
+	"THB": {"THB", 2}, "TND": {"TND", 3}, "TRY": {"TRY", 2}, "TWD": {"NT$", 2},
+	"USD": {"$", 2}, "VND": {"₫", 0}, "ZAR": {"ZAR", 2},
+}
+
+// setupMoney registers the money func unless the user defined one
+func (e *TemplateEngine) setupMoney(opts Options) {
+	if _, userDefined := opts.FuncMap["money"]; !userDefined {
+		e.registerRenderFunc("money", func(st *renderState) any {
+			return func(amount interface{}, code string) (string, error) {
+				return formatMoney(amount, code, lookupLocaleFormat(st.locale))
+			}
+		})
+	}
+}
+
+// formatMoney formats an amount in the minor units of a currency, such as
+// cents, in the format of a locale: 123456 USD is "$1,234.56" in English
+// and "1.234,56 $" in German.
+func formatMoney(amount interface{}, code string, l *localeFormat) (string, error) {
+	cur, ok := currencies[strings.ToUpper(code)]
+	if !ok {
+		return "", fmt.Errorf("money: unknown currency %q", code)
+	}
+	minor, err := toBigInt(amount)
+	if err != nil {
+		return "", fmt.Errorf("money: %v", err)
+	}
+
+	negative := minor.Sign() < 0
+	digits := new(big.Int).Abs(minor).String()
+	if len(digits) <= cur.digits {
+		digits = strings.Repeat("0", cur.digits-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-cur.digits], digits[len(digits)-cur.digits:]
+
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(l.group)
+		}
+		b.WriteRune(r)
+	}
+	if fraction != "" {
+		b.WriteString(l.decimal + fraction)
+	}
+	number := b.String()
+
+	// symbols after the number and symbols ending in a letter are set
+	// apart from it by a no-break space
+	sep := ""
+	if l.currencyAfter || unicode.IsLetter([]rune(cur.symbol)[len([]rune(cur.symbol))-1]) {
+		sep = "\u00a0"
+	}
+	s := cur.symbol + sep + number
+	if l.currencyAfter {
+		s = number + sep + cur.symbol
+	}
+	if negative {
+		s = "-" + s
+	}
+	return s, nil
+}
+
+// toBigInt converts any integer value, including *big.Int, to a big.Int.
+// Floats are refused: money amounts are counted in minor units.
+func toBigInt(v interface{}) (*big.Int, error) {
+	if b, ok := v.(*big.Int); ok && b != nil {
+		return b, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return nil, fmt.Errorf("amount %v is a float, pass an integer of minor units such as cents", v)
+	}
+	return nil, fmt.Errorf("expected an integer amount, got %T", v)
+}
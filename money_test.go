@@ -0,0 +1,61 @@
+package tmplx
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestMoney(t *testing.T) {
+	engine := New(Options{
+		Loader:  MapLoader{"invoice.html": `{{money .Total "USD"}}|{{money .Total "eur"}}|{{money .Yen "JPY"}}`},
+		Locales: []string{"en", "de", "fr"},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := H{"Total": int64(123456789), "Yen": 1500}
+	for locale, expected := range map[string]string{
+		"en": "$1,234,567.89|€1,234,567.89|¥1,500",
+		"de": "1.234.567,89\u00a0$|1.234.567,89\u00a0€|1.500\u00a0¥",
+		"fr": "1\u202f234\u202f567,89\u00a0$|1\u202f234\u202f567,89\u00a0€|1\u202f500\u00a0¥",
+	} {
+		result, err := engine.Render("invoice.html", data, WithLocale(locale))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %q, got %q", locale, expected, result)
+		}
+	}
+
+	en := lookupLocaleFormat("en")
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+	for _, tc := range []struct {
+		amount   interface{}
+		code     string
+		expected string
+	}{
+		{5, "USD", "$0.05"},
+		{-1999, "GBP", "-£19.99"},
+		{uint8(7), "KWD", "KWD\u00a00.007"},
+		{100000, "CHF", "CHF\u00a01,000.00"},
+		{huge, "USD", "$1,000,000,000,000,000,000.00"},
+	} {
+		got, err := formatMoney(tc.amount, tc.code, en)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.expected {
+			t.Errorf("money %v %s: expected %q, got %q", tc.amount, tc.code, tc.expected, got)
+		}
+	}
+
+	if _, err := formatMoney(12.5, "USD", en); err == nil || !strings.Contains(err.Error(), "minor units") {
+		t.Errorf("Expected float amount error, got %v", err)
+	}
+	if _, err := formatMoney(100, "XYZ", en); err == nil {
+		t.Error("Expected unknown currency error, got nil")
+	}
+}
@@ -0,0 +1,72 @@
+package tmplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+)
+
+// refresh re-reads the named template and everything it extends and
+// includes, and resolves it again, for engines created with
+// Options.DisableCache. A template that no longer exists is dropped.
+func (e *TemplateEngine) refresh(name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.readLoaders(); err != nil {
+		return err
+	}
+	e.loadCache = make(map[string]*template.Template)
+	e.inclCache = make(map[string]*inclCache)
+
+	s, found, err := e.sourceOf(name)
+	if err != nil {
+		return err
+	}
+	if found {
+		e.logf(LogCache, LogDebug, "[TMPLX] Refreshing %s", name)
+		tmpl, err := e.resolveInheritance(s, name, nil)
+		if err != nil {
+			return err
+		}
+		return e.store(name, tmpl)
+	}
+
+	if _, ok := e.cache[name]; ok {
+		e.cacheEvicted(name, CacheTemplates)
+	}
+	delete(e.cache, name)
+	delete(e.text, name)
+	return nil
+}
+
+// maybeRefresh refreshes the named template if the engine was created with
+// Options.DisableCache, for render entry points to call before looking it up
+func (e *TemplateEngine) maybeRefresh(name string) error {
+	if !e.noCache {
+		return nil
+	}
+	if err := e.refresh(name); err != nil {
+		return fmt.Errorf("error refreshing template %s: %w", name, err)
+	}
+	return nil
+}
+
+// sourceOf returns the source the named template file is loaded from, the
+// last one having it as when loading, or false if none has it
+func (e *TemplateEngine) sourceOf(name string) (Source, bool, error) {
+	for i := len(e.srcs) - 1; i >= 0; i-- {
+		s := e.srcs[i]
+		file := path.Join(s.Dir, name)
+		if _, err := fs.Stat(s.FS, file); errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return Source{}, false, err
+		}
+		ok, err := e.loadable(s, file)
+		return s, ok, err
+	}
+	return Source{}, false, nil
+}
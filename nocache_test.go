@@ -0,0 +1,106 @@
+package tmplx
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDisableCache(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html":   {Data: []byte(`<main>{{block "content" .}}{{end}}</main>`)},
+		"partials/nav.html":   {Data: []byte(`{{define "nav"}}<nav>v1</nav>{{end}}`)},
+		"pages/home.html":     {Data: []byte(`{{extend "layouts/base.html"}}{{include "partials/nav.html" .}}{{define "content"}}{{template "nav" .}}home{{end}}`)},
+		"errors/offline.html": {Data: []byte(`offline`)},
+	}
+	engine := New(Options{FS: fsys, DisableCache: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	render := func(name string) string {
+		t.Helper()
+		result, err := engine.Render(name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if result := render("pages/home.html"); result != "<main><nav>v1</nav>home</main>" {
+		t.Errorf("Unexpected output %q", result)
+	}
+
+	// edits to the page, its layout and its includes show up at once
+	fsys["layouts/base.html"] = &fstest.MapFile{Data: []byte(`<article>{{block "content" .}}{{end}}</article>`)}
+	fsys["partials/nav.html"] = &fstest.MapFile{Data: []byte(`{{define "nav"}}<nav>v2</nav>{{end}}`)}
+	if result := render("pages/home.html"); result != "<article><nav>v2</nav>home</article>" {
+		t.Errorf("Expected the edited layout and include, got %q", result)
+	}
+
+	// templates added after loading can be rendered
+	fsys["pages/new.html"] = &fstest.MapFile{Data: []byte(`new`)}
+	if result := render("pages/new.html"); result != "new" {
+		t.Errorf("Expected the new template, got %q", result)
+	}
+
+	// and deleted ones can't
+	delete(fsys, "pages/new.html")
+	if _, err := engine.Render("pages/new.html", nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a deleted template to be gone, got %v", err)
+	}
+
+	// errors are reported on the render
+	fsys["pages/home.html"] = &fstest.MapFile{Data: []byte(`{{if}}`)}
+	if _, err := engine.Render("pages/home.html", nil); err == nil {
+		t.Error("Expected the broken template to fail")
+	}
+}
+
+func TestDisableCacheEntryPoints(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pages/list.html": {Data: []byte(`{{block "rows" .}}<li>{{.}}</li>{{end}}`)},
+	}
+	engine := New(Options{FS: fsys, DisableCache: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	fsys["pages/list.html"] = &fstest.MapFile{Data: []byte(`{{block "rows" .}}<p>{{.}}</p>{{end}}`)}
+
+	var out strings.Builder
+	if err := engine.RenderBlock(&out, "pages/list.html", "rows", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "<p>a</p>" {
+		t.Errorf("Expected RenderBlock to see the edit, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := engine.RenderEach("pages/list.html", []interface{}{"a", "b"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "<p>a</p><p>b</p>" {
+		t.Errorf("Expected RenderEach to see the edit, got %q", out.String())
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("HX-Target", "rows")
+	rec := httptest.NewRecorder()
+	if err := engine.RenderHTMX(rec, req, "pages/list.html", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Body.String() != "<p>a</p>" {
+		t.Errorf("Expected RenderHTMX to see the edit, got %q", rec.Body.String())
+	}
+
+	// templates added after loading can be served over HTTP
+	fsys["pages/new.html"] = &fstest.MapFile{Data: []byte(`new`)}
+	rec = httptest.NewRecorder()
+	if err := engine.RenderHTTP(rec, httptest.NewRequest("GET", "/", nil), "pages/new.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Body.String() != "new" {
+		t.Errorf("Expected the new template over HTTP, got %q", rec.Body.String())
+	}
+}
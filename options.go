@@ -0,0 +1,231 @@
+package tmplx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// reservedFuncs are handled by the engine itself and can't be registered
+var reservedFuncs = []string{"extend", "include", "block", varDirective, markFunc, prologFunc}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// NewE is New with the options validated first. All problems found are
+// returned together, so a misconfigured engine fails at construction with a
+// clear message rather than at load or render time.
+func NewE(opts Options) (*TemplateEngine, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return New(opts), nil
+}
+
+// Validate checks the options for mistakes that New would otherwise only
+// surface later, if at all: directories that don't exist or aren't found in
+// their FS, a Loader combined with Dir or FS, reserved or malformed func
+// names, funcs html/template won't accept, locale funcs whose type differs
+// between locales, extensions without a leading dot, out of range enum
+// values, blank or duplicate locales and an Env missing from Envs. All
+// problems found are joined in one error.
+func (o Options) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if o.Dir != "" || o.FS != nil {
+		if err := validateSource("Dir/FS", Source{Dir: o.Dir, FS: o.FS}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i, s := range o.Sources {
+		if err := validateSource(fmt.Sprintf("Sources[%d]", i), s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if o.Dir == "" && o.FS == nil && o.Loader == nil && len(o.Sources) == 0 {
+		add("no template source: set Dir, FS, Loader or Sources")
+	}
+
+	for name, fn := range o.FuncMap {
+		if err := validateFunc(name, fn); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for name, factory := range o.LocaleFuncs {
+		if _, err := localeFuncType(name, factory, o.Locales); err != nil {
+			errs = append(errs, err)
+		}
+		if isReservedFunc(name) {
+			add("LocaleFuncs: %q is reserved", name)
+		}
+		if _, ok := o.FuncMap[name]; ok {
+			add("LocaleFuncs: %q is also in FuncMap", name)
+		}
+	}
+
+	for name, fn := range o.ServiceFuncs {
+		if err := validateServiceFunc(name, fn); err != nil {
+			errs = append(errs, err)
+		}
+		if _, ok := o.FuncMap[name]; ok {
+			add("ServiceFuncs: %q is also in FuncMap", name)
+		}
+	}
+
+	for name := range o.Directives {
+		switch {
+		case isReservedFunc(name):
+			add("Directives: %q is reserved", name)
+		case !isIdentifier(name):
+			add("Directives: %q is not a valid directive name", name)
+		}
+		if _, ok := o.FuncMap[name]; ok {
+			add("Directives: %q is also in FuncMap", name)
+		}
+	}
+
+	for format, c := range o.Converters {
+		if c == nil {
+			add("Converters: %q is nil", format)
+		}
+	}
+
+	for _, ext := range o.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			add("Extensions: %q must start with a dot", ext)
+		}
+	}
+
+	if o.Backend != HTMLBackend && o.Backend != TextBackend {
+		add("Backend: unknown backend %v", o.Backend)
+	}
+	if o.UnknownFuncs != UnknownFuncError && o.UnknownFuncs != UnknownFuncIgnore {
+		add("UnknownFuncs: unknown policy %d", o.UnknownFuncs)
+	}
+	if o.ETag < ETagNone || o.ETag > ETagInputs {
+		add("ETag: unknown mode %d", o.ETag)
+	}
+	if o.StrictCSP < CSPOff || o.StrictCSP > CSPFail {
+		add("StrictCSP: unknown mode %d", o.StrictCSP)
+	}
+	for i, check := range o.OutputChecks {
+		if check == nil {
+			add("OutputChecks: check %d is nil", i)
+		}
+	}
+	if o.LogLevel < LogDefault || o.LogLevel > LogDebug {
+		add("LogLevel: unknown level %d", o.LogLevel)
+	}
+	for cat, level := range o.LogLevels {
+		if level < LogDefault || level > LogDebug {
+			add("LogLevels: unknown level %d for %s", level, cat)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, locale := range o.Locales {
+		switch {
+		case strings.TrimSpace(locale) == "":
+			add("Locales: blank locale")
+		case seen[strings.ToLower(locale)]:
+			add("Locales: %q is listed twice", locale)
+		}
+		seen[strings.ToLower(locale)] = true
+	}
+
+	for _, env := range o.Envs {
+		if env == "" || strings.ContainsAny(env, "./") {
+			add("Envs: %q is not a valid environment name", env)
+		}
+	}
+	if o.Env != "" && len(o.Envs) > 0 && !containsString(o.Envs, o.Env) {
+		add("Env: %q is not listed in Envs", o.Env)
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateSource(label string, s Source) error {
+	switch {
+	case s.Loader != nil:
+		if s.Dir != "" || s.FS != nil {
+			return fmt.Errorf("%s: Loader is set, so Dir and FS would be ignored", label)
+		}
+	case s.FS == nil:
+		dir := s.Dir
+		if dir == "" {
+			dir = "."
+		}
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("%s: directory %s: %v", label, dir, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s: %s is not a directory", label, dir)
+		}
+	case s.Dir != "":
+		dir := strings.Trim(path.Clean("/"+s.Dir), "/")
+		if dir == "" {
+			return nil
+		}
+		info, err := fs.Stat(s.FS, dir)
+		if err != nil {
+			return fmt.Errorf("%s: Dir %q not found in FS; with FS set, Dir is a subdirectory of it", label, s.Dir)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s: Dir %q in FS is not a directory", label, s.Dir)
+		}
+	}
+	return nil
+}
+
+func isReservedFunc(name string) bool {
+	for _, r := range reservedFuncs {
+		if name == r {
+			return true
+		}
+	}
+	return false
+}
+
+// validateFunc applies the rules html/template panics on when funcs are
+// registered
+func validateFunc(name string, fn interface{}) error {
+	if isReservedFunc(name) {
+		return fmt.Errorf("FuncMap: %q is reserved", name)
+	}
+	if !isIdentifier(name) {
+		return fmt.Errorf("FuncMap: %q is not a valid func name", name)
+	}
+
+	typ := reflect.TypeOf(fn)
+	if typ == nil || typ.Kind() != reflect.Func {
+		return fmt.Errorf("FuncMap: %q is %T, not a func", name, fn)
+	}
+	switch {
+	case typ.NumOut() == 1:
+	case typ.NumOut() == 2 && typ.Out(1) == errorType:
+	default:
+		return fmt.Errorf("FuncMap: %q must return one value, or a value and an error", name)
+	}
+	return nil
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
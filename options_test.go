@@ -0,0 +1,63 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewE(t *testing.T) {
+	fsys := fstest.MapFS{"templates/page.html": &fstest.MapFile{Data: []byte("hi")}}
+
+	engine, err := NewE(Options{FS: fsys, Dir: "templates", FuncMap: map[string]interface{}{
+		"upper": strings.ToUpper,
+		"safe":  func(s string) (string, error) { return s, nil },
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewE(Options{
+		Dir: "does-not-exist",
+		Sources: []Source{
+			{FS: fsys, Dir: "pages"},
+			{Loader: MapLoader{}, Dir: "templates"},
+		},
+		FuncMap: map[string]interface{}{
+			"include":   func() string { return "" },
+			"bad-name":  func() string { return "" },
+			"notFunc":   42,
+			"noResults": func() {},
+		},
+		Extensions: []string{"html"},
+		Backend:    Backend(7),
+		Locales:    []string{"en", "", "EN"},
+	})
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, want := range []string{
+		"Dir/FS: directory does-not-exist",
+		`Sources[0]: Dir "pages" not found in FS`,
+		"Sources[1]: Loader is set",
+		`"include" is reserved`,
+		`"bad-name" is not a valid func name`,
+		`"notFunc" is int, not a func`,
+		`"noResults" must return one value`,
+		`Extensions: "html" must start with a dot`,
+		"Backend: unknown backend Backend(7)",
+		"Locales: blank locale",
+		`Locales: "EN" is listed twice`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in:\n%v", want, err)
+		}
+	}
+
+	if _, err := NewE(Options{}); err == nil {
+		t.Error("Expected error without any template source")
+	}
+}
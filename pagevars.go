@@ -0,0 +1,143 @@
+package tmplx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template/parse"
+	"unicode"
+	"unicode/utf8"
+)
+
+// varDirective sets a page variable, e.g. {{var "title" "Dashboard"}}
+const varDirective = "var"
+
+// PageVars are the variables a template and the layouts it extends set with
+// the var directive. Names are stored with their first letter upper cased,
+// so {{var "title" "Dashboard"}} is .Page.Title in the layout.
+type PageVars map[string]string
+
+// PageVars returns the variables the named template sets, for handlers
+// rendering with struct data to copy into their own Page field
+func (e *TemplateEngine) PageVars(name string) PageVars {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.pageVars[name]
+}
+
+func (e *TemplateEngine) setPageVars(name string, vars PageVars) {
+	if len(vars) == 0 {
+		delete(e.pageVars, name)
+		return
+	}
+	e.pageVars[name] = vars
+}
+
+// extractPageVars removes the var directives at the top level of a template
+// file and returns their values. Later directives win.
+func extractPageVars(file, content string) (string, PageVars, error) {
+	if !strings.Contains(content, varDirective) {
+		return content, nil, nil
+	}
+	trees := make(map[string]*parse.Tree)
+	scan := parse.New(file)
+	scan.Mode = parse.SkipFuncCheck
+	if _, err := scan.Parse(content, "", "", trees); err != nil {
+		// left for the template parser to report
+		return content, nil, nil
+	}
+	root, ok := trees[file]
+	if !ok || root.Root == nil {
+		return content, nil, nil
+	}
+
+	var found []*parse.ActionNode
+	for _, node := range root.Root.Nodes {
+		action, ok := node.(*parse.ActionNode)
+		if !ok || len(action.Pipe.Decl) > 0 || len(action.Pipe.Cmds) != 1 {
+			continue
+		}
+		if ident, ok := action.Pipe.Cmds[0].Args[0].(*parse.IdentifierNode); ok && ident.Ident == varDirective {
+			found = append(found, action)
+		}
+	}
+	if len(found) == 0 {
+		return content, nil, nil
+	}
+
+	vars := make(PageVars, len(found))
+	sort.Slice(found, func(i, j int) bool { return found[i].Pos > found[j].Pos })
+	for _, action := range found {
+		args, err := directiveArgs(varDirective, action.Pipe.Cmds[0].Args[1:])
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %v", file, err)
+		}
+		if len(args) != 2 || !isIdentifier(args[0]) {
+			return "", nil, fmt.Errorf("%s: var requires a name and a value, got %s", file, action)
+		}
+		name := pageVarName(args[0])
+		if _, later := vars[name]; !later {
+			vars[name] = args[1]
+		}
+
+		start, end, err := actionSpan(content, action)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %v", file, err)
+		}
+		content = content[:start] + content[end:]
+	}
+	return content, vars, nil
+}
+
+// pageVarName upper cases the first letter of a variable name so it can be
+// used as a field: title is .Page.Title
+func pageVarName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// inheritPageVars returns the variables of a template: those of the layout
+// it extends, overridden by its own
+func inheritPageVars(parent, own PageVars) PageVars {
+	if len(parent) == 0 {
+		return own
+	}
+	vars := make(PageVars, len(parent)+len(own))
+	for name, v := range parent {
+		vars[name] = v
+	}
+	for name, v := range own {
+		vars[name] = v
+	}
+	return vars
+}
+
+// withPageVars adds the variables of the named template to map data as
+// Page, unless the data has a Page of its own. Other data is passed as is.
+func (e *TemplateEngine) withPageVars(name string, data interface{}) interface{} {
+	vars := e.PageVars(name)
+	if len(vars) == 0 {
+		return data
+	}
+	switch d := data.(type) {
+	case nil:
+		return H{"Page": vars}
+	case H:
+		return withPage(d, vars)
+	case map[string]interface{}:
+		return withPage(d, vars)
+	}
+	return data
+}
+
+func withPage[M ~map[string]any](data M, vars PageVars) M {
+	if _, ok := data["Page"]; ok {
+		return data
+	}
+	copied := make(M, len(data)+1)
+	for k, v := range data {
+		copied[k] = v
+	}
+	copied["Page"] = vars
+	return copied
+}
@@ -0,0 +1,67 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPageVars(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{
+		"layouts/base.html": `{{var "section" "Main"}}{{var "title" "Untitled"}}` +
+			`<title>{{.Page.Title}} · {{.Page.Section}}</title>{{block "content" .}}{{end}}`,
+		"pages/dashboard.html": `{{extend "layouts/base.html"}}
+{{ var "title" "Dashboard" }}
+{{define "content"}}<h1>{{.Page.Title}}</h1>{{.Name}}{{end}}`,
+		"pages/plain.html": `{{extend "layouts/base.html"}}{{define "content"}}plain{{end}}`,
+	}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("pages/dashboard.html", H{"Name": "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<title>Dashboard · Main</title><h1>Dashboard</h1>Ada`; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	result, err = engine.Render("pages/plain.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<title>Untitled · Main</title>plain`; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	// data with a Page of its own keeps it
+	result, err = engine.Render("pages/plain.html", H{"Page": PageVars{"Title": "Mine", "Section": "Own"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<title>Mine · Own</title>plain`; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	if vars := engine.PageVars("pages/dashboard.html"); vars["Title"] != "Dashboard" || vars["Section"] != "Main" {
+		t.Errorf("Expected the dashboard's variables, got %v", vars)
+	}
+}
+
+func TestPageVarsErrors(t *testing.T) {
+	tests := map[string]string{
+		`{{var "title"}}`:                    "var requires a name and a value",
+		`{{var .Title "x"}}`:                 "constant arguments",
+		`{{if true}}{{var "a" "b"}}{{end}}x`: "var can only be used at the top level",
+	}
+	for content, message := range tests {
+		engine := New(Options{Loader: MapLoader{"page.html": content}})
+		err := engine.Load()
+		if err == nil {
+			_, err = engine.Render("page.html", nil)
+		}
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected an error containing %q, got %v", content, message, err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package tmplx
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// resolveAll resolves and stores the named templates of a source, up to
+// Options.LoadConcurrency at once. Templates share the layouts and includes
+// resolved by any worker. Engines ignoring unknown funcs resolve one at a
+// time, since the stubs they register change the funcs every template is
+// parsed with.
+func (e *TemplateEngine) resolveAll(s Source, names []string) error {
+	workers := min(e.loadWorkers, len(names))
+	if workers <= 1 || e.unknown == UnknownFuncIgnore {
+		for _, name := range names {
+			if err := e.resolveFile(s, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		next   atomic.Int64
+		failed atomic.Bool
+		wg     sync.WaitGroup
+	)
+	errs := make([]error, len(names))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= len(names) {
+					return
+				}
+				if err := e.resolveFile(s, names[i]); err != nil {
+					errs[i] = err
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// report the error a sequential load would have, the first in walk order
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
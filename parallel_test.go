@@ -0,0 +1,53 @@
+package tmplx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadConcurrency(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html":  {Data: []byte(`<main>{{block "content" .}}{{end}}</main>`)},
+		"layouts/docs.html":  {Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}<nav>docs</nav>{{block "doc" .}}{{end}}{{end}}`)},
+		"partials/card.html": {Data: []byte(`{{define "card"}}<div>{{.}}</div>{{end}}`)},
+	}
+	for i := 0; i < 50; i++ {
+		fsys[fmt.Sprintf("pages/page%d.html", i)] = &fstest.MapFile{Data: []byte(fmt.Sprintf(
+			`{{extend "layouts/docs.html"}}{{var "title" "Page %d"}}{{include "partials/card.html" .}}{{define "doc"}}{{template "card" .Page.Title}}{{end}}`, i))}
+	}
+
+	sequential := New(Options{FS: fsys})
+	if err := sequential.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for _, devMode := range []bool{false, true} {
+		engine := New(Options{FS: fsys, LoadConcurrency: 8, DevMode: devMode})
+		if err := engine.Load(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := engine.DefinedTemplates(), sequential.DefinedTemplates(); got != want {
+			t.Fatalf("Expected the same templates as a sequential load, got %s", got)
+		}
+		for i := 0; i < 50; i++ {
+			name := fmt.Sprintf("pages/page%d.html", i)
+			result, err := engine.Render(name, map[string]interface{}{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := fmt.Sprintf("<div>Page %d</div>", i); !strings.Contains(result, want) || !strings.Contains(result, "<nav>docs</nav>") {
+				t.Errorf("Unexpected output of %s: %q", name, result)
+			}
+		}
+	}
+
+	// the first broken file in walk order is reported, as when loading one
+	// at a time
+	fsys["pages/page10.html"] = &fstest.MapFile{Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}{{if}}{{end}}`)}
+	fsys["pages/page40.html"] = &fstest.MapFile{Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}{{end}}{{end}}`)}
+	err := New(Options{FS: fsys, LoadConcurrency: 8}).Load()
+	if err == nil || !strings.Contains(err.Error(), "pages/page10.html") {
+		t.Errorf("Expected the error of page10, got %v", err)
+	}
+}
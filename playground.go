@@ -0,0 +1,161 @@
+package tmplx
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// playgroundName is the name templates edited in the playground are compiled under
+const playgroundName = "__playground__.html"
+
+// overlayFS serves a single in-memory file on top of the engine's sources, so
+// a template that isn't on disk can extend and include the loaded ones. Like
+// the engine, it reads a file from the last source that has it.
+type overlayFS struct {
+	file memFS
+	srcs []Source
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if _, ok := o.file[name]; ok {
+		return o.file.Open(name)
+	}
+	for i := len(o.srcs) - 1; i >= 0; i-- {
+		s := o.srcs[i]
+		if f, err := s.FS.Open(path.Join(s.Dir, name)); err == nil {
+			return f, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// compileSource resolves content as if it were a template file named name,
+// extending and including templates from the engine's sources. It resolves
+// on a copy of the engine, so nothing it caches or marks outlives the call;
+// the copy is returned for looking up marks.
+func (e *TemplateEngine) compileSource(name, content string) (*template.Template, *TemplateEngine, error) {
+	d := e.derive()
+	// the copy's caches are private, so hooks watching the engine's don't
+	// hear about them
+	d.cacheEvents = CacheEvents{}
+	s := Source{
+		Dir: ".",
+		FS: overlayFS{
+			file: memFS{name: []byte(content)},
+			srcs: d.srcs,
+		},
+	}
+	tmpl, err := d.resolveInheritance(s, name, nil)
+	return tmpl, d, err
+}
+
+// renderSource compiles and renders template content that isn't part of the
+// loaded set, using the engine's layouts, includes and funcs.
+func (e *TemplateEngine) renderSource(w io.Writer, content string, data interface{}) error {
+	tmpl, d, err := e.compileSource(playgroundName, content)
+	if err != nil {
+		return err
+	}
+	if d.instrument {
+		w = newMarkWriter(w, d, playgroundName, nil)
+	}
+	return tmpl.Execute(w, data)
+}
+
+var playgroundPage = template.Must(template.New("playground").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>tmplx playground</title>
+<style>
+body { margin: 0; font-family: sans-serif; display: flex; height: 100vh; }
+.pane { flex: 1; display: flex; flex-direction: column; padding: 1em; box-sizing: border-box; min-width: 0; }
+textarea { font-family: monospace; flex: 1; margin-bottom: 1em; }
+iframe { flex: 1; border: 1px solid #ccc; margin-bottom: 1em; }
+pre { flex: 1; overflow: auto; background: #f4f4f4; margin: 0; padding: .5em; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<div class="pane">
+<label for="template">Template</label>
+<textarea id="template">{{.Template}}</textarea>
+<label for="data">Data (JSON)</label>
+<textarea id="data">{}</textarea>
+<button id="render">Render</button>
+</div>
+<div class="pane">
+<iframe id="preview" sandbox></iframe>
+<pre id="output"></pre>
+</div>
+<script>
+async function render() {
+  const body = new URLSearchParams({
+    template: document.getElementById("template").value,
+    data: document.getElementById("data").value,
+  });
+  const res = await fetch(location.pathname, {method: "POST", body});
+  const text = await res.text();
+  const out = document.getElementById("output");
+  out.textContent = text;
+  out.className = res.ok ? "" : "error";
+  document.getElementById("preview").srcdoc = res.ok ? text : "";
+}
+document.getElementById("render").addEventListener("click", render);
+</script>
+</body>
+</html>
+`))
+
+const playgroundDefault = `{{extend "layouts/base.html"}}
+{{define "content"}}Hello, {{.Name}}!{{end}}`
+
+// PlaygroundHandler serves a page for editing a template and JSON data side
+// by side and previewing the result, rendered by the engine with its loaded
+// layouts, includes and funcs. The edited template is never cached.
+//
+// Templates can call any registered func, so the handler only responds when
+// the engine is in DevMode, and should never be reachable in production.
+func (e *TemplateEngine) PlaygroundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !e.devMode {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if err := playgroundPage.Execute(w, map[string]string{"Template": playgroundDefault}); err != nil {
+				e.logf(LogRender, LogError, "[TMPLX] Error rendering playground: %v", err)
+			}
+
+		case http.MethodPost:
+			var data interface{}
+			if raw := strings.TrimSpace(r.FormValue("data")); raw != "" {
+				if err := json.Unmarshal([]byte(raw), &data); err != nil {
+					http.Error(w, "invalid JSON data: "+err.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+			}
+
+			var out strings.Builder
+			if err := e.renderSource(&out, r.FormValue("template"), data); err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = io.WriteString(w, out.String())
+
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
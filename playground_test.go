@@ -0,0 +1,100 @@
+package tmplx
+
+import (
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPlaygroundHandler(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{
+			Data: []byte(`<body>{{include "partials/nav.html" .}}{{block "content" .}}{{end}}</body>`),
+		},
+		"partials/nav.html": &fstest.MapFile{
+			Data: []byte(`<nav></nav>`),
+		},
+	}
+
+	engine := New(Options{
+		FS:      fsys,
+		DevMode: true,
+		FuncMap: template.FuncMap{"upper": strings.ToUpper},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(tmpl, data string) *httptest.ResponseRecorder {
+		form := url.Values{"template": {tmpl}, "data": {data}}
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		engine.PlaygroundHandler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(`{{extend "layouts/base.html"}}{{define "content"}}<p>{{upper .Name}}</p>{{end}}`, `{"Name": "world"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	containsAll(t, []string{"<p>WORLD</p>", "<nav></nav>"}, rec.Body.String())
+
+	if _, err := engine.GetTemplate(playgroundName); err == nil {
+		t.Error("Expected the playground template not to be cached")
+	}
+	marks := len(engine.marks)
+	post(`{{include "partials/nav.html" .}}`, `{}`)
+	if len(engine.marks) != marks {
+		t.Errorf("Expected playground renders to leave the engine's marks alone, got %d more", len(engine.marks)-marks)
+	}
+
+	if rec := post(`{{.Name`, `{}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a template error, got %d", rec.Code)
+	}
+	if rec := post(`ok`, `{`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for invalid JSON, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	engine.PlaygroundHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	containsAll(t, []string{`<textarea id="template">`}, rec.Body.String())
+
+	prod := New(Options{FS: fsys})
+	rec = httptest.NewRecorder()
+	prod.PlaygroundHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 outside dev mode, got %d", rec.Code)
+	}
+}
+
+func TestPlaygroundSourcePrecedence(t *testing.T) {
+	base := fstest.MapFS{"partials/nav.html": &fstest.MapFile{Data: []byte(`<nav>base</nav>`)}}
+	theme := fstest.MapFS{"partials/nav.html": &fstest.MapFile{Data: []byte(`<nav>theme</nav>`)}}
+	engine := New(Options{Sources: []Source{{FS: base, Dir: "."}, {FS: theme, Dir: "."}}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	// like sourceOf, the last source having a file wins
+	s, _, err := engine.sourceOf("partials/nav.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := fs.ReadFile(s.FS, "partials/nav.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := engine.renderSource(&out, `{{include "partials/nav.html" .}}`, nil); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != string(want) || out.String() != "<nav>theme</nav>" {
+		t.Errorf("Expected the theme's nav, got %q", out.String())
+	}
+}
@@ -0,0 +1,95 @@
+package tmplx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// RenderPool renders with an engine while capping how many renders run at
+// once. Renders beyond the cap wait for a free slot, so a traffic spike on
+// heavy pages queues instead of holding every page in memory at the same
+// time. Create one with TemplateEngine.Pool and share it between handlers.
+type RenderPool struct {
+	e       *TemplateEngine
+	slots   chan struct{}
+	waiting atomic.Int64
+}
+
+var _ Renderer = (*RenderPool)(nil)
+
+// Pool returns a RenderPool running at most n renders of e at a time. n below
+// 1 is treated as 1.
+func (e *TemplateEngine) Pool(n int) *RenderPool {
+	if n < 1 {
+		n = 1
+	}
+	return &RenderPool{e: e, slots: make(chan struct{}, n)}
+}
+
+// acquire waits for a free slot, giving up when ctx is done
+func (p *RenderPool) acquire(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	p.waiting.Add(1)
+	defer p.waiting.Add(-1)
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *RenderPool) release() {
+	<-p.slots
+}
+
+// Active returns the number of renders running
+func (p *RenderPool) Active() int {
+	return len(p.slots)
+}
+
+// Waiting returns the number of renders queued for a slot
+func (p *RenderPool) Waiting() int {
+	return int(p.waiting.Load())
+}
+
+// Render is TemplateEngine.Render, run in the pool
+func (p *RenderPool) Render(name string, data interface{}, opts ...RenderOption) (string, error) {
+	if err := p.acquire(context.Background()); err != nil {
+		return "", err
+	}
+	defer p.release()
+	return p.e.Render(name, data, opts...)
+}
+
+// RenderResponse is TemplateEngine.RenderResponse, run in the pool
+func (p *RenderPool) RenderResponse(w io.Writer, name string, data interface{}, opts ...RenderOption) error {
+	if err := p.acquire(context.Background()); err != nil {
+		return err
+	}
+	defer p.release()
+	return p.e.RenderResponse(w, name, data, opts...)
+}
+
+// ExecuteTemplate is TemplateEngine.ExecuteTemplate, run in the pool
+func (p *RenderPool) ExecuteTemplate(w io.Writer, name string, data interface{}) error {
+	return p.RenderResponse(w, name, data)
+}
+
+// RenderHTTP is TemplateEngine.RenderHTTP, run in the pool. A request still
+// queued when its client goes away is dropped with the context's error and
+// nothing written.
+func (p *RenderPool) RenderHTTP(w http.ResponseWriter, r *http.Request, name string, data interface{}, opts ...RenderOption) error {
+	if err := p.acquire(r.Context()); err != nil {
+		return err
+	}
+	defer p.release()
+	return p.e.RenderHTTP(w, r, name, data, opts...)
+}
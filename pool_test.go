@@ -0,0 +1,89 @@
+package tmplx
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	engine := New(Options{
+		Loader: MapLoader{"page.html": `{{work}}`},
+		FuncMap: map[string]interface{}{
+			"work": func() string {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				<-release
+				return "done"
+			},
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	pool := engine.Pool(2)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result, err := pool.Render("page.html", nil); err != nil || result != "done" {
+				t.Errorf("Unexpected result %q, %v", result, err)
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for pool.Waiting() != 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if pool.Active() != 2 || pool.Waiting() != 3 {
+		t.Errorf("Expected 2 active and 3 waiting, got %d and %d", pool.Active(), pool.Waiting())
+	}
+
+	close(release)
+	wg.Wait()
+	if peak.Load() != 2 {
+		t.Errorf("Expected at most 2 concurrent renders, got %d", peak.Load())
+	}
+}
+
+func TestPoolCancel(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{"page.html": "hi"}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	pool := engine.Pool(1)
+	if err := pool.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	if err := pool.RenderHTTP(rec, r, "page.html", nil); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("Expected nothing written, got %q", rec.Body.String())
+	}
+
+	pool.release()
+	if result, err := pool.Render("page.html", nil); err != nil || result != "hi" {
+		t.Errorf("Unexpected result %q, %v", result, err)
+	}
+}
@@ -0,0 +1,162 @@
+package tmplx
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// PostProcessor rewrites the rendered output of a template before it is
+// written, e.g. to minify it or rewrite its links. name is the template
+// rendered.
+type PostProcessor interface {
+	PostProcess(name string, out []byte) ([]byte, error)
+}
+
+// PostProcessorFunc adapts a function to a PostProcessor
+type PostProcessorFunc func(name string, out []byte) ([]byte, error)
+
+// PostProcess calls f(name, out)
+func (f PostProcessorFunc) PostProcess(name string, out []byte) ([]byte, error) {
+	return f(name, out)
+}
+
+// WithPostProcessors runs processors after those of Options.PostProcessors
+// for a single render
+func WithPostProcessors(processors ...PostProcessor) RenderOption {
+	return func(cfg *renderConfig) {
+		cfg.postProcessors = append(cfg.postProcessors, processors...)
+	}
+}
+
+// postProcessors returns the chain of a render: the engine's, then its own
+func (e *TemplateEngine) postProcessors(cfg renderConfig) []PostProcessor {
+	if len(cfg.postProcessors) == 0 {
+		return e.processors
+	}
+	return append(append([]PostProcessor(nil), e.processors...), cfg.postProcessors...)
+}
+
+// postProcess runs out, rendered from the named template, through
+// processors in order
+func postProcess(processors []PostProcessor, name string, out []byte) ([]byte, error) {
+	for _, p := range processors {
+		var err error
+		if out, err = p.PostProcess(name, out); err != nil {
+			return nil, fmt.Errorf("error post-processing template %s: %v", name, err)
+		}
+	}
+	return out, nil
+}
+
+// TrailingSlash is the trailing slash policy of a LinkRewriter
+type TrailingSlash int
+
+const (
+	// TrailingSlashKeep leaves page links as they are
+	TrailingSlashKeep TrailingSlash = iota
+
+	// TrailingSlashAdd ends page links with a slash: /about becomes /about/
+	TrailingSlashAdd
+
+	// TrailingSlashRemove strips the slash ending page links: /about/
+	// becomes /about
+	TrailingSlashRemove
+)
+
+// defaultAssetExtensions are the extensions of the URLs a LinkRewriter
+// serves from its CDN unless AssetExtensions says otherwise
+var defaultAssetExtensions = []string{
+	".css", ".js", ".mjs", ".map", ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".avif", ".ico",
+	".woff", ".woff2", ".ttf", ".otf", ".mp4", ".webm", ".mp3", ".pdf",
+}
+
+// LinkRewriter is a PostProcessor rewriting the URLs of href and src
+// attributes. Absolute URLs of the site itself are made relative first,
+// then the trailing slash policy applies to page links, then assets are
+// pointed at the CDN. URLs of other sites are left alone.
+type LinkRewriter struct {
+	// SiteURL is the site's own origin, e.g. "https://example.com". Links to
+	// it are made root-relative, https://example.com/about becoming /about.
+	SiteURL string
+
+	// TrailingSlash is the policy for links to pages, i.e. root-relative
+	// URLs that aren't assets
+	TrailingSlash TrailingSlash
+
+	// CDN is prefixed to root-relative asset URLs, e.g.
+	// "https://cdn.example.com", so /css/app.css becomes
+	// https://cdn.example.com/css/app.css
+	CDN string
+
+	// AssetExtensions are the extensions of asset URLs, common stylesheet,
+	// script, image, font and media extensions if empty
+	AssetExtensions []string
+}
+
+// linkAttr matches href and src attributes and their quoted value
+var linkAttr = regexp.MustCompile(`(?i)(\s(?:href|src)\s*=\s*)("[^"]*"|'[^']*')`)
+
+// PostProcess rewrites the links of out
+func (l *LinkRewriter) PostProcess(_ string, out []byte) ([]byte, error) {
+	return linkAttr.ReplaceAllFunc(out, func(attr []byte) []byte {
+		m := linkAttr.FindSubmatch(attr)
+		value := string(m[2])
+		quote, url := value[:1], value[1:len(value)-1]
+		return []byte(string(m[1]) + quote + l.rewrite(url) + quote)
+	}), nil
+}
+
+// rewrite returns the rewritten form of a URL
+func (l *LinkRewriter) rewrite(url string) string {
+	if site := strings.TrimSuffix(l.SiteURL, "/"); site != "" && strings.HasPrefix(url, site) {
+		rest := url[len(site):]
+		switch {
+		case rest == "":
+			url = "/"
+		case strings.ContainsAny(rest[:1], "/?#"):
+			url = rest
+			if url[0] != '/' {
+				url = "/" + url
+			}
+		}
+	}
+	if !strings.HasPrefix(url, "/") || strings.HasPrefix(url, "//") {
+		return url
+	}
+
+	p, suffix := url, ""
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		p, suffix = url[:i], url[i:]
+	}
+	if l.isAsset(p) {
+		if l.CDN != "" {
+			return strings.TrimSuffix(l.CDN, "/") + url
+		}
+		return url
+	}
+
+	switch {
+	case p == "/":
+	case l.TrailingSlash == TrailingSlashAdd && !strings.HasSuffix(p, "/") && path.Ext(p) == "":
+		p += "/"
+	case l.TrailingSlash == TrailingSlashRemove:
+		p = strings.TrimRight(p, "/")
+	}
+	return p + suffix
+}
+
+func (l *LinkRewriter) isAsset(p string) bool {
+	exts := l.AssetExtensions
+	if len(exts) == 0 {
+		exts = defaultAssetExtensions
+	}
+	ext := strings.ToLower(path.Ext(p))
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package tmplx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLinkRewriter(t *testing.T) {
+	l := &LinkRewriter{
+		SiteURL:       "https://example.com",
+		TrailingSlash: TrailingSlashAdd,
+		CDN:           "https://cdn.example.com/",
+	}
+	cases := []struct{ url, expected string }{
+		{"/about", "/about/"},
+		{"/about/", "/about/"},
+		{"/blog?page=2#top", "/blog/?page=2#top"},
+		{"/", "/"},
+		{"https://example.com", "/"},
+		{"https://example.com/contact", "/contact/"},
+		{"https://example.com.evil.net/x", "https://example.com.evil.net/x"},
+		{"https://other.org/about", "https://other.org/about"},
+		{"//other.org/app.js", "//other.org/app.js"},
+		{"/css/app.css?v=3", "https://cdn.example.com/css/app.css?v=3"},
+		{"https://example.com/img/Logo.PNG", "https://cdn.example.com/img/Logo.PNG"},
+		{"#section", "#section"},
+		{"mailto:hi@example.com", "mailto:hi@example.com"},
+	}
+	for _, c := range cases {
+		if got := l.rewrite(c.url); got != c.expected {
+			t.Errorf("rewrite(%q): expected %q, got %q", c.url, c.expected, got)
+		}
+	}
+
+	l = &LinkRewriter{TrailingSlash: TrailingSlashRemove}
+	for url, expected := range map[string]string{"/about/": "/about", "/": "/", "/docs/?q=1": "/docs?q=1"} {
+		if got := l.rewrite(url); got != expected {
+			t.Errorf("rewrite(%q): expected %q, got %q", url, expected, got)
+		}
+	}
+}
+
+func TestPostProcessors(t *testing.T) {
+	upper := PostProcessorFunc(func(_ string, out []byte) ([]byte, error) {
+		return bytes.ToUpper(out), nil
+	})
+	engine := New(Options{
+		Loader: MapLoader{
+			"pages/home.html": `<a href="/about">About</a><img src='/img/logo.png' alt="">`,
+		},
+		PostProcessors: []PostProcessor{&LinkRewriter{TrailingSlash: TrailingSlashAdd, CDN: "https://cdn.example.com"}},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("pages/home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<a href="/about/">About</a><img src='https://cdn.example.com/img/logo.png' alt="">`; result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	// render processors run after the engine's
+	result, _ = engine.Render("pages/home.html", nil, WithPostProcessors(upper))
+	if !strings.Contains(result, `HREF="/ABOUT/"`) {
+		t.Errorf("Expected the render's processor to run last, got %q", result)
+	}
+
+	failing := PostProcessorFunc(func(string, []byte) ([]byte, error) { return nil, fmt.Errorf("boom") })
+	if _, err := engine.Render("pages/home.html", nil, WithPostProcessors(failing)); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected the processor's error, got %v", err)
+	}
+}
+
+func TestPostProcessorsFragments(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"pages/cart.html": `{{define "items"}}<a href="/item">Item</a>{{end}}{{define "count"}}<a href="/cart">1</a>{{end}}`,
+			"pages/row.html":  `<a href="/row/{{.}}">{{.}}</a>`,
+		},
+		PostProcessors: []PostProcessor{&LinkRewriter{TrailingSlash: TrailingSlashAdd}},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fragments := []Fragment{{Block: "items"}, {Block: "count", Target: "#count"}}
+	if err := engine.RenderFragments(&buf, "pages/cart.html", nil, fragments); err != nil {
+		t.Fatal(err)
+	}
+	expected := `<a href="/item/">Item</a><div hx-swap-oob="innerHTML:#count"><a href="/cart/">1</a></div>`
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := engine.RenderEach("pages/row.html", []interface{}{1, 2}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<a href="/row/1/">1</a><a href="/row/2/">2</a>`; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	failing := PostProcessorFunc(func(string, []byte) ([]byte, error) { return nil, fmt.Errorf("boom") })
+	buf.Reset()
+	err := engine.RenderBlock(&buf, "pages/cart.html", "items", nil, WithPostProcessors(failing))
+	if err == nil || !strings.Contains(err.Error(), "boom") || buf.Len() != 0 {
+		t.Errorf("Expected the processor's error and no output, got %q, %v", buf.String(), err)
+	}
+	err = engine.RenderEach("pages/row.html", []interface{}{1}, &buf, WithPostProcessors(failing))
+	if err == nil || !strings.Contains(err.Error(), "item 0") || buf.Len() != 0 {
+		t.Errorf("Expected the processor's error for the item, got %q, %v", buf.String(), err)
+	}
+}
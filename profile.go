@@ -0,0 +1,110 @@
+package tmplx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProfileEntry is the time spent in one template, block or include during a
+// render, summed over every time it ran
+type ProfileEntry struct {
+	// Kind is "template", "block" or "include"
+	Kind string
+
+	// Name is the template path, block name or included path
+	Name string
+
+	// Source is the file the block was defined in
+	Source string
+
+	// Calls is how often it ran, e.g. once per iteration of a range
+	Calls int
+
+	// Total is the time spent in it, nested blocks and includes included
+	Total time.Duration
+
+	// Self is Total minus the time spent in nested blocks and includes
+	Self time.Duration
+}
+
+// RenderProfile breaks the time of a render down by template, block and
+// include
+type RenderProfile struct {
+	// Total is the wall time of the whole render
+	Total time.Duration
+
+	// Entries are sorted by Self, slowest first
+	Entries []ProfileEntry
+}
+
+// RenderWithProfile renders a template like Render and also measures the
+// time spent in each template, block and include, so the slow part of a page
+// can be found. Like RenderWithTrace it requires Options.Trace or
+// Options.DevMode and the HTML backend; timings include the markers those
+// add, so compare entries with each other rather than with production
+// timings.
+func (e *TemplateEngine) RenderWithProfile(name string, data interface{}, opts ...RenderOption) (string, *RenderProfile, error) {
+	start := time.Now()
+	out, trace, err := e.RenderWithTrace(name, data, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+	return out, newRenderProfile(trace, time.Since(start)), nil
+}
+
+func newRenderProfile(trace *RenderTrace, total time.Duration) *RenderProfile {
+	type key struct{ kind, name, source string }
+	entries := make(map[key]*ProfileEntry)
+	var order []key
+
+	// Spans are in begin order, so the parent of a span is the last span
+	// before it one level up
+	var parents []int
+	nested := make([]time.Duration, len(trace.Spans))
+	for i, span := range trace.Spans {
+		parents = parents[:min(span.Depth, len(parents))]
+		if len(parents) > 0 {
+			nested[parents[len(parents)-1]] += span.Duration
+		}
+		parents = append(parents, i)
+	}
+
+	for i, span := range trace.Spans {
+		k := key{span.Kind, span.Name, span.Source}
+		entry, ok := entries[k]
+		if !ok {
+			entry = &ProfileEntry{Kind: span.Kind, Name: span.Name, Source: span.Source}
+			entries[k] = entry
+			order = append(order, k)
+		}
+		entry.Calls++
+		entry.Total += span.Duration
+		entry.Self += span.Duration - nested[i]
+	}
+
+	p := &RenderProfile{Total: total}
+	for _, k := range order {
+		p.Entries = append(p.Entries, *entries[k])
+	}
+	sort.SliceStable(p.Entries, func(i, j int) bool {
+		return p.Entries[i].Self > p.Entries[j].Self
+	})
+	return p
+}
+
+// String formats the profile as a table, slowest entry first
+func (p *RenderProfile) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "total %v\n", p.Total)
+	for _, entry := range p.Entries {
+		percent := 0.0
+		if p.Total > 0 {
+			percent = float64(entry.Self) * 100 / float64(p.Total)
+		}
+		label := boundary{kind: entry.Kind, name: entry.Name, source: entry.Source}
+		fmt.Fprintf(&b, "%5.1f%% self %-12v total %-12v calls %-5d %s\n", percent, entry.Self, entry.Total, entry.Calls, label)
+	}
+	return b.String()
+}
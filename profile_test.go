@@ -0,0 +1,59 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderWithProfile(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html": `<body>{{include "partials/nav.html" .}}{{block "content" .}}{{end}}</body>`,
+			"partials/nav.html": `<nav></nav>`,
+			"pages/list.html":   `{{extend "layouts/base.html"}}{{define "content"}}<ul>{{range .}}{{block "row" .}}<li>{{slow .}}</li>{{end}}{{end}}</ul>{{end}}`,
+		},
+		FuncMap: map[string]interface{}{
+			"slow": func(i int) int {
+				time.Sleep(5 * time.Millisecond)
+				return i
+			},
+		},
+		Trace: true,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, profile, err := engine.RenderWithProfile("pages/list.html", []int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "<body><nav></nav><ul><li>1</li><li>2</li><li>3</li></ul></body>" {
+		t.Errorf("Unexpected output %q", result)
+	}
+
+	slowest := profile.Entries[0]
+	if slowest.Kind != "block" || slowest.Name != "row" || slowest.Calls != 3 {
+		t.Errorf("Expected the row block to be slowest with 3 calls, got %+v", slowest)
+	}
+	if slowest.Self < 15*time.Millisecond {
+		t.Errorf("Expected at least 15ms in row, got %v", slowest.Self)
+	}
+
+	for _, entry := range profile.Entries {
+		if entry.Self > entry.Total {
+			t.Errorf("Self time exceeds total for %+v", entry)
+		}
+		if entry.Kind == "block" && entry.Name == "content" && entry.Self >= slowest.Self {
+			t.Errorf("Expected nested rows excluded from content self time, got %v", entry.Self)
+		}
+	}
+	if !strings.Contains(profile.String(), `block "row"`) {
+		t.Errorf("Expected row in profile table:\n%s", profile)
+	}
+
+	if _, _, err := New(Options{Loader: MapLoader{"a.html": "a"}}).RenderWithProfile("a.html", nil); err == nil {
+		t.Error("Expected error without tracing enabled")
+	}
+}
@@ -0,0 +1,482 @@
+package tmplx
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// QRCodeFuncs are the opt-in qrcode and qrcodeURI funcs, added with
+// engine.AddFuncs(tmplx.QRCodeFuncs) or merged into Options.FuncMap:
+//
+//	{{qrcode .TicketURL 200}}
+//	<img src="{{qrcodeURI .OTPAuthURL 160}}" alt="Scan with your authenticator app">
+var QRCodeFuncs = template.FuncMap{
+	"qrcode":    QRCode,
+	"qrcodeURI": QRCodeURI,
+}
+
+// QRCode returns value encoded as a QR code in an inline SVG element size
+// pixels wide, with medium error correction and the standard quiet zone
+func QRCode(value string, size int) (template.HTML, error) {
+	svg, err := qrSVG(value, size)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(svg), nil
+}
+
+// QRCodeURI is QRCode as a data URI, for the src of an <img>
+func QRCodeURI(value string, size int) (template.URL, error) {
+	svg, err := qrSVG(value, size)
+	if err != nil {
+		return "", err
+	}
+	return template.URL("data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(svg))), nil
+}
+
+// qrQuietZone is the light border around a code, in modules
+const qrQuietZone = 4
+
+func qrSVG(value string, size int) (string, error) {
+	if size <= 0 {
+		return "", fmt.Errorf("qrcode: invalid size %d", size)
+	}
+	qr, err := encodeQR([]byte(value))
+	if err != nil {
+		return "", err
+	}
+
+	var path strings.Builder
+	for y, row := range qr.modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+qrQuietZone, y+qrQuietZone)
+			}
+		}
+	}
+	n := qr.size + 2*qrQuietZone
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		size, size, n, n, n, n, path.String()), nil
+}
+
+// The encoder writes byte mode data at error correction level M, choosing
+// the smallest version that fits and the mask with the lowest penalty.
+
+// qrECCPerBlock and qrBlocks are the error correction codewords per block
+// and the number of blocks of each version at level M, indexed by version
+var (
+	qrECCPerBlock = [41]int{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28}
+	qrBlocks      = [41]int{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49}
+)
+
+// qrLevelM is the format information value of error correction level M
+const qrLevelM = 0
+
+type qrCode struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// encodeQR encodes data as a QR code
+func encodeQR(data []byte) (*qrCode, error) {
+	version := 0
+	for v := 1; v <= 40; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if len(data) < 1<<countBits && 4+countBits+8*len(data) <= 8*qrDataCodewords(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("qrcode: %d bytes is too long to encode", len(data))
+	}
+
+	// mode indicator, character count, data, terminator and padding
+	var bits qrBits
+	bits.append(0x4, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := 8 * qrDataCodewords(version)
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	qr := newQRCode(version)
+	qr.drawFunctionPatterns()
+	qr.drawCodewords(qrAddECC(codewords, version))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		qr.applyMask(mask)
+		qr.drawFormatBits(mask)
+		if p := qr.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		qr.applyMask(mask) // undo
+	}
+	qr.applyMask(best)
+	qr.drawFormatBits(best)
+	return qr, nil
+}
+
+type qrBits []bool
+
+func (b *qrBits) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 != 0)
+	}
+}
+
+// qrRawModules returns the number of modules of a version available for
+// data and error correction
+func qrRawModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// qrDataCodewords returns the number of data codewords of a version
+func qrDataCodewords(version int) int {
+	return qrRawModules(version)/8 - qrECCPerBlock[version]*qrBlocks[version]
+}
+
+// qrAddECC splits data into blocks, adds the error correction codewords of
+// each and interleaves them
+func qrAddECC(data []byte, version int) []byte {
+	numBlocks, eccLen := qrBlocks[version], qrECCPerBlock[version]
+	raw := qrRawModules(version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := qrDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		ecc := qrRemainder(block, divisor)
+		if i < numShort {
+			block = append(block, 0) // keeps blocks aligned; skipped below
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, raw)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// qrDivisor returns the Reed-Solomon generator polynomial of a degree,
+// without its leading term
+func qrDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// qrRemainder returns the Reed-Solomon error correction codewords of data
+func qrRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func newQRCode(version int) *qrCode {
+	size := 4*version + 17
+	qr := &qrCode{version: version, size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range qr.modules {
+		qr.modules[i] = make([]bool, size)
+		qr.function[i] = make([]bool, size)
+	}
+	return qr
+}
+
+func (qr *qrCode) setFunction(x, y int, dark bool) {
+	qr.modules[y][x] = dark
+	qr.function[y][x] = true
+}
+
+func (qr *qrCode) drawFunctionPatterns() {
+	for i := 0; i < qr.size; i++ {
+		qr.setFunction(6, i, i%2 == 0)
+		qr.setFunction(i, 6, i%2 == 0)
+	}
+
+	for _, c := range [][2]int{{3, 3}, {qr.size - 4, 3}, {3, qr.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < qr.size && y >= 0 && y < qr.size {
+					d := max(abs(dx), abs(dy))
+					qr.setFunction(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+
+	align := qr.alignmentPositions()
+	last := len(align) - 1
+	for i, ay := range align {
+		for j, ax := range align {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue // finder patterns
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					qr.setFunction(ax+dx, ay+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	qr.drawFormatBits(0) // reserves the area, drawn for real once masked
+	if qr.version >= 7 {
+		rem := qr.version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := qr.version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 != 0
+			a, b := qr.size-11+i%3, i/3
+			qr.setFunction(a, b, dark)
+			qr.setFunction(b, a, dark)
+		}
+	}
+}
+
+func (qr *qrCode) alignmentPositions() []int {
+	if qr.version == 1 {
+		return nil
+	}
+	n := qr.version/7 + 2
+	step := (qr.version*8 + n*3 + 5) / (n*4 - 4) * 2
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, qr.size-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+func (qr *qrCode) drawFormatBits(mask int) {
+	data := qrLevelM<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		qr.setFunction(8, i, bit(i))
+	}
+	qr.setFunction(8, 7, bit(6))
+	qr.setFunction(8, 8, bit(7))
+	qr.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.setFunction(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		qr.setFunction(qr.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.setFunction(8, qr.size-15+i, bit(i))
+	}
+	qr.setFunction(8, qr.size-8, true)
+}
+
+// drawCodewords places data in the zigzag order of the standard, two
+// columns at a time from the bottom right
+func (qr *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = qr.size - 1 - vert
+				}
+				if !qr.function[y][x] && i < len(data)*8 {
+					qr.modules[y][x] = data[i/8]>>(7-i%8)&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by a mask pattern; applying it
+// twice undoes it
+func (qr *qrCode) applyMask(mask int) {
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !qr.function[y][x] {
+				qr.modules[y][x] = !qr.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code is to scan, by the rules of the
+// standard: long runs, 2x2 blocks, finder-like patterns and imbalance
+func (qr *qrCode) penalty() int {
+	n := qr.size
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return qr.modules[x][y]
+		}
+		return qr.modules[y][x]
+	}
+
+	score := 0
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			run := 1
+			for x := 1; x <= n; x++ {
+				if x < n && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += run - 2
+				}
+				run = 1
+			}
+			for x := 0; x+11 <= n; x++ {
+				if qrFinderLike(func(i int) bool { return at(x+i, y, transpose) }) {
+					score += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if qr.modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				c := qr.modules[y][x]
+				if qr.modules[y][x+1] == c && qr.modules[y+1][x] == c && qr.modules[y+1][x+1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+	percent := dark * 100 / (n * n)
+	return score + abs(percent-50)/5*10
+}
+
+var (
+	qrFinderA = [11]bool{true, false, true, true, true, false, true, false, false, false, false}
+	qrFinderB = [11]bool{false, false, false, false, true, false, true, true, true, false, true}
+)
+
+// qrFinderLike reports whether 11 modules read as a finder pattern next to
+// four light modules
+func qrFinderLike(at func(i int) bool) bool {
+	a, b := true, true
+	for i := 0; i < 11; i++ {
+		m := at(i)
+		a = a && m == qrFinderA[i]
+		b = b && m == qrFinderB[i]
+	}
+	return a || b
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
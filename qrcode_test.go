@@ -0,0 +1,109 @@
+package tmplx
+
+import (
+	"encoding/base64"
+	"html"
+	"strings"
+	"testing"
+)
+
+func TestEncodeQR(t *testing.T) {
+	qr, err := encodeQR([]byte("https://example.com/t/42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"#######.#.###.#...#######",
+		"#.....#.#.#.#...#.#.....#",
+		"#.###.#.#.##.##.#.#.###.#",
+		"#.###.#..###.#..#.#.###.#",
+		"#.###.#.#.#..##.#.#.###.#",
+		"#.....#.....#.#.#.#.....#",
+		"#######.#.#.#.#.#.#######",
+		".........#...#.##........",
+		"#..######.##....##..#.###",
+		".##.....#....#####.#####.",
+		"#....##.###.##.###.###..#",
+		"#.#.##..#..#..#..###.####",
+		"#.#.#.##.#.#...##.##....#",
+		"#..##..##.#.##.##...#..#.",
+		"###..##..#...####.#.#####",
+		"#.#....#..#.#.#..###.##.#",
+		"#...#.##...####.#####.##.",
+		"........####.#..#...#.##.",
+		"#######.#.#.#...#.#.#...#",
+		"#.....#.#..#.#.##...#...#",
+		"#.###.#.##.##..######....",
+		"#.###.#.#...#...###....##",
+		"#.###.#..####..#.#..#####",
+		"#.....#..##.#..#...##.###",
+		"#######.##.#..#.#.#..#..#",
+	}
+	for y, row := range qr.modules {
+		var b strings.Builder
+		for _, dark := range row {
+			if dark {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		if b.String() != expected[y] {
+			t.Errorf("Row %d: expected %s, got %s", y, expected[y], b.String())
+		}
+	}
+
+	for _, tc := range []struct{ length, version int }{{14, 1}, {15, 2}, {213, 10}, {2331, 40}} {
+		qr, err := encodeQR([]byte(strings.Repeat("x", tc.length)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if qr.version != tc.version {
+			t.Errorf("Expected version %d for %d bytes, got %d", tc.version, tc.length, qr.version)
+		}
+	}
+	if _, err := encodeQR([]byte(strings.Repeat("x", 2332))); err == nil {
+		t.Error("Expected error for data too long, got nil")
+	}
+}
+
+func TestQRCodeFuncs(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"ticket.html": `{{qrcode .}}|<img src="{{qrcodeURI . 100}}" alt="">`,
+		},
+	})
+	if err := engine.Load(); err == nil {
+		t.Fatal("Expected qrcode to be opt-in, got nil")
+	}
+
+	engine = New(Options{
+		Loader: MapLoader{
+			"ticket.html": `{{qrcode . 200}}|<img src="{{qrcodeURI . 100}}" alt="">`,
+		},
+		FuncMap: QRCodeFuncs,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("ticket.html", "TICKET-1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg, img, _ := strings.Cut(result, "|")
+	containsAll(t, []string{`width="200" height="200" viewBox="0 0 29 29"`, `<path d="M4,4h1v1h-1z`}, svg)
+
+	img = html.UnescapeString(img)
+	uri := strings.TrimSuffix(strings.TrimPrefix(img, `<img src="data:image/svg+xml;base64,`), `" alt="">`)
+	decoded, err := base64.StdEncoding.DecodeString(uri)
+	if err != nil {
+		t.Fatalf("Expected base64 data URI: %v", err)
+	}
+	if !strings.Contains(string(decoded), `width="100"`) {
+		t.Errorf("Unexpected data URI content %s", decoded)
+	}
+
+	if _, err := QRCode("x", 0); err == nil {
+		t.Error("Expected error for invalid size, got nil")
+	}
+}
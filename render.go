@@ -0,0 +1,239 @@
+package tmplx
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"sync"
+	texttemplate "text/template"
+)
+
+// RenderOption customizes a single render call
+type RenderOption func(*renderConfig)
+
+type renderConfig struct {
+	locale   string
+	trace    *RenderTrace
+	calls    *callTree
+	version  string
+	ctx      context.Context
+	fallback string
+	limits   *Limits
+	budget   *budget
+	services []any
+
+	postProcessors []PostProcessor
+}
+
+func newRenderConfig(opts []RenderOption) renderConfig {
+	var cfg renderConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// stateful reports whether the render needs template funcs bound to its own
+// state rather than the engine defaults. Engines with islands always do, to
+// track the islands each page uses.
+func (e *TemplateEngine) stateful(cfg renderConfig) bool {
+	return (cfg.locale != "" && cfg.locale != e.defaultLocale) || cfg.calls != nil || len(e.islands) > 0 ||
+		(cfg.ctx != nil && e.flags != nil) || e.limits.set() || e.memoized || e.contextual.Load()
+}
+
+// WithLocale renders with the given locale, which is visible to templates
+// through the locale func and to the funcs registered in Options.LocaleFuncs.
+func WithLocale(locale string) RenderOption {
+	return func(cfg *renderConfig) {
+		cfg.locale = locale
+	}
+}
+
+// WithContext renders with ctx, which template funcs such as feature pass
+// on, e.g. to a FlagProvider. RenderHTTP uses the request's context.
+func WithContext(ctx context.Context) RenderOption {
+	return func(cfg *renderConfig) {
+		cfg.ctx = ctx
+	}
+}
+
+// renderState is the state of a single render, visible to render funcs
+type renderState struct {
+	locale  string
+	calls   *callTree
+	islands []string
+	ctx     context.Context
+	budget  *budget
+	memo    map[memoKey][]reflect.Value
+
+	// shared is set on the states of the default funcs, which renders
+	// without state of their own share
+	shared bool
+}
+
+// renderFunc builds a template func bound to the state of a render. Funcs
+// built from a zero state are registered at parse time and serve renders
+// that need no state of their own.
+type renderFunc func(st *renderState) any
+
+// boundExecutor is a private clone of a resolved template whose render funcs
+// read from state. Clones are pooled per template so html/template only has
+// to escape each clone once.
+type boundExecutor struct {
+	exec  executor
+	state *renderState
+
+	// pool is the pool the executor goes back to, which a reload replaces
+	pool *sync.Pool
+}
+
+func (e *TemplateEngine) registerRenderFunc(name string, rf renderFunc) {
+	st := e.defaultState()
+	st.shared = true
+	e.renderFuncs[name] = rf
+	e.funcMap[name] = rf(st)
+}
+
+func (e *TemplateEngine) defaultState() *renderState {
+	return &renderState{locale: e.defaultLocale}
+}
+
+// prepareBase keeps an unexecuted clone of a resolved template that stateful
+// renders clone from; html/template refuses to clone executed templates.
+func (e *TemplateEngine) prepareBase(name string) error {
+	exec, _ := e.lookup(name)
+
+	var base executor
+	switch t := exec.(type) {
+	case *template.Template:
+		clone, err := t.Clone()
+		if err != nil {
+			return err
+		}
+		base = clone
+	case *texttemplate.Template:
+		clone, err := t.Clone()
+		if err != nil {
+			return err
+		}
+		base = clone
+	}
+
+	e.bases[name] = base
+	e.pools[name] = &sync.Pool{}
+	return nil
+}
+
+func (e *TemplateEngine) acquire(name string) (*boundExecutor, error) {
+	e.mu.RLock()
+	pool, ok := e.pools[name]
+	base := e.bases[name]
+	if !ok {
+		e.mu.RUnlock()
+		return nil, notFound(name)
+	}
+	if b, ok := pool.Get().(*boundExecutor); ok {
+		e.mu.RUnlock()
+		return b, nil
+	}
+	// AddFuncs adds render funcs under e.mu
+	renderFuncs := make(map[string]renderFunc, len(e.renderFuncs))
+	for fn, rf := range e.renderFuncs {
+		renderFuncs[fn] = rf
+	}
+	e.mu.RUnlock()
+
+	st := &renderState{}
+	funcs := make(map[string]any, len(renderFuncs))
+	for fn, rf := range renderFuncs {
+		funcs[fn] = rf(st)
+	}
+
+	switch base := base.(type) {
+	case *template.Template:
+		clone, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		return &boundExecutor{exec: clone.Funcs(funcs), state: st, pool: pool}, nil
+	case *texttemplate.Template:
+		clone, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		return &boundExecutor{exec: clone.Funcs(funcs), state: st, pool: pool}, nil
+	default:
+		return nil, fmt.Errorf("template %s has no base to clone", name)
+	}
+}
+
+func (e *TemplateEngine) release(b *boundExecutor) {
+	*b.state = renderState{}
+	b.pool.Put(b)
+}
+
+// execute runs the named template, binding render funcs to a private state
+// when the render has options that need one.
+func (e *TemplateEngine) execute(w io.Writer, name string, data interface{}, cfg renderConfig) error {
+	w, cfg = e.prepare(w, cfg)
+	if e.instrument {
+		w = newMarkWriter(w, e, name, cfg.trace)
+	}
+
+	exec, done, err := e.bind(name, cfg)
+	if err != nil {
+		return err
+	}
+	defer done()
+	return exec.Execute(w, e.withPageVars(name, data))
+}
+
+// prepare sets up the context and budget of a render: its services, its
+// store and its limits, wrapping w to count output if limited or to stop
+// once the context is done
+func (e *TemplateEngine) prepare(w io.Writer, cfg renderConfig) (io.Writer, renderConfig) {
+	if len(e.services) > 0 || len(cfg.services) > 0 {
+		cfg.ctx = e.bindServices(cfg.ctx, cfg.services)
+	}
+	if e.contextual.Load() {
+		cfg.ctx = withStore(cfg.ctx)
+	}
+	if limits := e.limitsFor(cfg); limits.set() || cancellable(cfg.ctx) {
+		cfg.budget = newBudget(limits, cfg.ctx)
+		w = &budgetWriter{w: w, budget: cfg.budget}
+	}
+	return w, cfg
+}
+
+// bind returns the executor to render name with under cfg, and a func to
+// call once done with it.
+func (e *TemplateEngine) bind(name string, cfg renderConfig) (executor, func(), error) {
+	if !e.stateful(cfg) {
+		exec, ok := e.executor(name)
+		if !ok {
+			return nil, nil, notFound(name)
+		}
+		return exec, func() {}, nil
+	}
+
+	if e.lazy {
+		if err := e.loadLazily(name); err != nil {
+			return nil, nil, err
+		}
+	}
+	b, err := e.acquire(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	*b.state = *e.defaultState()
+	if cfg.locale != "" {
+		b.state.locale = cfg.locale
+	}
+	b.state.calls = cfg.calls
+	b.state.ctx = cfg.ctx
+	b.state.budget = cfg.budget
+	return b.exec, func() { e.release(b) }, nil
+}
@@ -0,0 +1,193 @@
+package tmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// RetryLoader wraps a Loader whose store can fail, such as a remote one, so
+// a blip doesn't take down rendering. Failed calls are retried with
+// exponential backoff, and once the attempts are used up the last content
+// read successfully is served instead. Missing templates are neither retried
+// nor served stale.
+type RetryLoader struct {
+	// Loader is the loader wrapped
+	Loader Loader
+
+	// Attempts is how many times a call is made before giving up, 3 if zero
+	Attempts int
+
+	// Backoff is the wait before the first retry, doubled after each one,
+	// 100ms if zero
+	Backoff time.Duration
+
+	mu    sync.Mutex
+	names []string
+	stale map[string][]byte
+}
+
+func (l *RetryLoader) attempts() int {
+	if l.Attempts <= 0 {
+		return 3
+	}
+	return l.Attempts
+}
+
+func (l *RetryLoader) backoff() time.Duration {
+	if l.Backoff <= 0 {
+		return 100 * time.Millisecond
+	}
+	return l.Backoff
+}
+
+// retry calls fn until it succeeds, reports a missing template or runs out
+// of attempts
+func (l *RetryLoader) retry(fn func() error) error {
+	wait := l.backoff()
+	var err error
+	for i := 0; i < l.attempts(); i++ {
+		if i > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		if err = fn(); err == nil || errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return err
+}
+
+func (l *RetryLoader) List() ([]string, error) {
+	var names []string
+	err := l.retry(func() (err error) {
+		names, err = l.Loader.List()
+		return err
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		if l.names != nil {
+			return l.names, nil
+		}
+		return nil, err
+	}
+	l.names = names
+	return names, nil
+}
+
+func (l *RetryLoader) Read(name string) ([]byte, error) {
+	var content []byte
+	err := l.retry(func() (err error) {
+		content, err = l.Loader.Read(name)
+		return err
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		delete(l.stale, name)
+		return nil, err
+	case err != nil:
+		if stale, ok := l.stale[name]; ok {
+			return stale, nil
+		}
+		return nil, err
+	}
+	if l.stale == nil {
+		l.stale = make(map[string][]byte)
+	}
+	l.stale[name] = content
+	return content, nil
+}
+
+// Watch passes through to the wrapped loader, if it is a Watcher
+func (l *RetryLoader) Watch(ctx context.Context, changed func()) error {
+	w, ok := l.Loader.(Watcher)
+	if !ok {
+		return fmt.Errorf("%T does not support watching", l.Loader)
+	}
+	return w.Watch(ctx, changed)
+}
+
+// ChainLoader tries loaders in turn, such as a remote store and then a copy
+// of its templates embedded in the binary: each call is answered by the
+// first loader that succeeds. Unlike OverlayLoader, the templates listed are
+// those of a single loader, not all of them. If every loader fails, the
+// first error other than a missing template is returned.
+func ChainLoader(loaders ...Loader) Loader {
+	return chainLoader(loaders)
+}
+
+type chainLoader []Loader
+
+func (c chainLoader) List() ([]string, error) {
+	var first error
+	for _, l := range c {
+		names, err := l.List()
+		if err == nil {
+			return names, nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if first == nil {
+		return nil, fmt.Errorf("no loaders in chain")
+	}
+	return nil, first
+}
+
+func (c chainLoader) Read(name string) ([]byte, error) {
+	var first error
+	for _, l := range c {
+		content, err := l.Read(name)
+		if err == nil {
+			return content, nil
+		}
+		if first == nil || errors.Is(first, fs.ErrNotExist) && !errors.Is(err, fs.ErrNotExist) {
+			first = err
+		}
+	}
+	if first == nil {
+		return nil, fmt.Errorf("template %s: %w", name, fs.ErrNotExist)
+	}
+	return nil, first
+}
+
+// Watch watches every loader of the chain that is a Watcher, until ctx is
+// done or one of them fails
+func (c chainLoader) Watch(ctx context.Context, changed func()) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var watchers []Watcher
+	for _, l := range c {
+		if w, ok := l.(Watcher); ok {
+			watchers = append(watchers, w)
+		}
+	}
+	if len(watchers) == 0 {
+		return fmt.Errorf("no loader in chain supports watching")
+	}
+
+	errs := make(chan error, len(watchers))
+	for _, w := range watchers {
+		go func(w Watcher) {
+			errs <- w.Watch(ctx, changed)
+		}(w)
+	}
+	var first error
+	for range watchers {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
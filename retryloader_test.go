@@ -0,0 +1,123 @@
+package tmplx
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// flakyLoader fails its next calls while failures is positive
+type flakyLoader struct {
+	MapLoader
+	failures int
+	calls    int
+}
+
+func (l *flakyLoader) fail() error {
+	l.calls++
+	if l.failures != 0 {
+		l.failures--
+		return errors.New("store unavailable")
+	}
+	return nil
+}
+
+func (l *flakyLoader) List() ([]string, error) {
+	if err := l.fail(); err != nil {
+		return nil, err
+	}
+	return l.MapLoader.List()
+}
+
+func (l *flakyLoader) Read(name string) ([]byte, error) {
+	if err := l.fail(); err != nil {
+		return nil, err
+	}
+	return l.MapLoader.Read(name)
+}
+
+func TestRetryLoader(t *testing.T) {
+	remote := &flakyLoader{MapLoader: MapLoader{"pages/home.html": "v1"}}
+	loader := &RetryLoader{Loader: remote, Attempts: 3, Backoff: time.Millisecond}
+
+	// transient failures are retried
+	remote.failures = 2
+	if content, err := loader.Read("pages/home.html"); err != nil || string(content) != "v1" {
+		t.Fatalf("Expected v1 after retries, got %q, %v", content, err)
+	}
+	if remote.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", remote.calls)
+	}
+
+	// once the attempts are used up, the last content read is served
+	if _, err := loader.List(); err != nil {
+		t.Fatal(err)
+	}
+	remote.MapLoader["pages/home.html"] = "v2"
+	remote.failures = -1
+	if content, err := loader.Read("pages/home.html"); err != nil || string(content) != "v1" {
+		t.Errorf("Expected stale v1, got %q, %v", content, err)
+	}
+	if names, err := loader.List(); err != nil || !reflect.DeepEqual(names, []string{"pages/home.html"}) {
+		t.Errorf("Expected stale names, got %v, %v", names, err)
+	}
+
+	// nothing stale to serve
+	if _, err := loader.Read("pages/about.html"); err == nil {
+		t.Error("Expected an error for a template never read")
+	}
+
+	// missing templates aren't retried
+	remote.failures, remote.calls = 0, 0
+	if _, err := loader.Read("pages/about.html"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+	if remote.calls != 1 {
+		t.Errorf("Expected a single call for a missing template, got %d", remote.calls)
+	}
+
+	// an engine keeps rendering through an outage
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	remote.failures = -1
+	if err := engine.Reload(); err != nil {
+		t.Fatalf("Expected reload to serve stale templates, got %v", err)
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "v2" {
+		t.Errorf("Expected v2, got %q", result)
+	}
+}
+
+func TestChainLoader(t *testing.T) {
+	remote := &flakyLoader{MapLoader: MapLoader{"pages/home.html": "remote"}}
+	embedded := MapLoader{"pages/home.html": "embedded", "pages/about.html": "about"}
+	loader := ChainLoader(remote, embedded)
+
+	if content, _ := loader.Read("pages/home.html"); string(content) != "remote" {
+		t.Errorf("Expected the first loader to answer, got %q", content)
+	}
+	if names, _ := loader.List(); !reflect.DeepEqual(names, []string{"pages/home.html"}) {
+		t.Errorf("Expected the names of the first loader, got %v", names)
+	}
+
+	remote.failures = -1
+	if content, _ := loader.Read("pages/home.html"); string(content) != "embedded" {
+		t.Errorf("Expected the next loader while the first fails, got %q", content)
+	}
+	if names, _ := loader.List(); len(names) != 2 {
+		t.Errorf("Expected the names of the next loader, got %v", names)
+	}
+
+	// a store failure is more telling than a missing template
+	if _, err := ChainLoader(embedded, remote).Read("pages/contact.html"); err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the store error, got %v", err)
+	}
+	remote.failures = 0
+	if _, err := loader.Read("pages/contact.html"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package tmplx
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// HostRouter picks the engine to render with from the request host, for
+// serving several branded sites from one process. Hosts are matched exactly,
+// then by wildcard patterns such as "*.example.com", most specific first.
+// Requests for any other host use the default engine.
+type HostRouter struct {
+	mu      sync.RWMutex
+	def     *TemplateEngine
+	hosts   map[string]*TemplateEngine
+	domains map[string]*TemplateEngine // keyed by the suffix after "*"
+}
+
+// NewHostRouter returns a router falling back to def, which may be nil to
+// reject unknown hosts.
+func NewHostRouter(def *TemplateEngine) *HostRouter {
+	return &HostRouter{
+		def:     def,
+		hosts:   make(map[string]*TemplateEngine),
+		domains: make(map[string]*TemplateEngine),
+	}
+}
+
+// Handle routes host to e. A host starting with "*." matches every
+// subdomain of the rest of it.
+func (h *HostRouter) Handle(host string, e *TemplateEngine) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	host = strings.ToLower(host)
+	if strings.HasPrefix(host, "*.") {
+		h.domains[host[1:]] = e
+		return
+	}
+	h.hosts[host] = e
+}
+
+// Theme is a source of templates overlaid on others. A theme with a Parent
+// is overlaid on its parent, which may have a parent of its own, so a
+// template is looked up in the theme, then its parents, then the default
+// templates.
+type Theme struct {
+	Source Source
+	Parent *Theme
+}
+
+// HandleTheme routes host to a copy of the default engine with the theme's
+// templates overlaid on its sources (see OverlayLoader), so the theme can
+// replace any template and still extend and include the rest.
+func (h *HostRouter) HandleTheme(host string, theme Source) error {
+	return h.HandleThemeChain(host, &Theme{Source: theme})
+}
+
+// HandleThemeChain is HandleTheme for a theme inheriting from parent
+// themes, so it only has to carry what it changes:
+//
+//	corporate := &tmplx.Theme{Source: tmplx.Source{Dir: "themes/corporate"}}
+//	router.HandleThemeChain("globex.com", &tmplx.Theme{Source: tmplx.Source{Dir: "themes/globex"}, Parent: corporate})
+func (h *HostRouter) HandleThemeChain(host string, theme *Theme) error {
+	if h.def == nil {
+		return fmt.Errorf("theme for %s needs a default engine", host)
+	}
+
+	// parents first, so each theme overlays the ones it inherits from
+	var chain []Loader
+	seen := make(map[*Theme]bool)
+	for t := theme; t != nil; t = t.Parent {
+		if seen[t] {
+			return fmt.Errorf("theme for %s inherits from itself", host)
+		}
+		seen[t] = true
+		s := t.Source
+		setupSource(&s)
+		chain = append([]Loader{sourceLoader(s)}, chain...)
+	}
+
+	layers := make([]Loader, 0, len(h.def.srcs)+len(chain))
+	for _, s := range h.def.srcs {
+		layers = append(layers, sourceLoader(s))
+	}
+	layers = append(layers, chain...)
+
+	e := h.def.derive()
+	overlay := Source{Loader: OverlayLoader(layers...)}
+	setupSource(&overlay)
+	e.srcs = []Source{overlay}
+	if err := e.Load(); err != nil {
+		return fmt.Errorf("error loading theme for %s: %v", host, err)
+	}
+	h.Handle(host, e)
+	return nil
+}
+
+// Engine returns the engine for host, which may include a port
+func (h *HostRouter) Engine(host string) (*TemplateEngine, bool) {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if e, ok := h.hosts[host]; ok {
+		return e, true
+	}
+	for suffix := host; ; {
+		i := strings.Index(suffix, ".")
+		if i < 0 {
+			break
+		}
+		suffix = suffix[i+1:]
+		if e, ok := h.domains["."+suffix]; ok {
+			return e, true
+		}
+	}
+	return h.def, h.def != nil
+}
+
+// RenderHTTP renders the named template with the engine for r's host, like
+// TemplateEngine.RenderHTTP.
+func (h *HostRouter) RenderHTTP(w http.ResponseWriter, r *http.Request, name string, data interface{}, opts ...RenderOption) error {
+	e, ok := h.Engine(r.Host)
+	if !ok {
+		return fmt.Errorf("no engine for host %s", r.Host)
+	}
+	return e.RenderHTTP(w, r, name, data, opts...)
+}
@@ -0,0 +1,109 @@
+package tmplx
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostRouter(t *testing.T) {
+	base := New(Options{Loader: MapLoader{
+		"layouts/base.html": `<h1>{{block "brand" .}}Default{{end}}</h1>{{block "content" .}}{{end}}`,
+		"pages/home.html":   `{{extend "layouts/base.html"}}{{define "content"}}home{{end}}`,
+	}})
+	if err := base.Load(); err != nil {
+		t.Fatal(err)
+	}
+	acme := New(Options{Loader: MapLoader{"pages/home.html": "acme"}})
+	if err := acme.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewHostRouter(base)
+	router.Handle("acme.com", acme)
+	router.Handle("*.acme.com", acme)
+	err := router.HandleTheme("globex.com", Source{Loader: MapLoader{
+		"layouts/base.html": `<h2>{{block "brand" .}}Globex{{end}}</h2>{{block "content" .}}{{end}}`,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"acme.com", "acme"},
+		{"ACME.com:8080", "acme"},
+		{"shop.eu.acme.com", "acme"},
+		{"globex.com", "<h2>Globex</h2>home"},
+		{"example.com", "<h1>Default</h1>home"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Host = tt.host
+		rec := httptest.NewRecorder()
+		if err := router.RenderHTTP(rec, r, "pages/home.html", nil); err != nil {
+			t.Fatalf("%s: %v", tt.host, err)
+		}
+		if rec.Body.String() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.host, tt.expected, rec.Body.String())
+		}
+	}
+
+	strict := NewHostRouter(nil)
+	strict.Handle("acme.com", acme)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Host = "example.com"
+	if err := strict.RenderHTTP(httptest.NewRecorder(), r, "pages/home.html", nil); err == nil {
+		t.Error("Expected error for an unknown host without a default engine")
+	}
+}
+
+func TestHostRouterThemeChain(t *testing.T) {
+	base := New(Options{Loader: MapLoader{
+		"layouts/base.html": `<h1>{{block "brand" .}}Default{{end}}</h1>{{include "partials/nav.html" .}}{{block "content" .}}{{end}}`,
+		"partials/nav.html": `<nav>default</nav>`,
+		"pages/home.html":   `{{extend "layouts/base.html"}}{{define "content"}}home{{end}}`,
+	}})
+	if err := base.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	corporate := &Theme{Source: Source{Loader: MapLoader{
+		"layouts/base.html": `<h2>{{block "brand" .}}Corporate{{end}}</h2>{{include "partials/nav.html" .}}{{block "content" .}}{{end}}`,
+		"partials/nav.html": `<nav>corporate</nav>`,
+	}}}
+	globex := &Theme{Source: Source{Loader: MapLoader{
+		"partials/nav.html": `<nav>globex</nav>`,
+	}}, Parent: corporate}
+
+	router := NewHostRouter(base)
+	if err := router.HandleThemeChain("globex.com", globex); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.HandleThemeChain("corp.com", corporate); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"globex.com":  `<h2>Corporate</h2><nav>globex</nav>home`,
+		"corp.com":    `<h2>Corporate</h2><nav>corporate</nav>home`,
+		"example.com": `<h1>Default</h1><nav>default</nav>home`,
+	}
+	for host, expected := range tests {
+		e, _ := router.Engine(host)
+		result, err := e.Render("pages/home.html", nil)
+		if err != nil {
+			t.Fatalf("%s: %v", host, err)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %s, got %s", host, expected, result)
+		}
+	}
+
+	loop := &Theme{Source: Source{Loader: MapLoader{}}}
+	loop.Parent = &Theme{Source: Source{Loader: MapLoader{}}, Parent: loop}
+	if err := router.HandleThemeChain("loop.com", loop); err == nil {
+		t.Error("Expected an error for a theme inheriting from itself")
+	}
+}
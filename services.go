@@ -0,0 +1,130 @@
+package tmplx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+type servicesContextKey struct{}
+
+// ContextWithServices returns a copy of ctx providing services to the
+// funcs of a render, e.g. from a middleware adding the request's session.
+// Service finds them by type.
+func ContextWithServices(ctx context.Context, services ...any) context.Context {
+	return context.WithValue(ctx, servicesContextKey{}, append(servicesFrom(ctx), services...))
+}
+
+func servicesFrom(ctx context.Context) []any {
+	if ctx == nil {
+		return nil
+	}
+	services, _ := ctx.Value(servicesContextKey{}).([]any)
+	return services[:len(services):len(services)]
+}
+
+// Service returns the service of type T provided to the render ctx belongs
+// to, e.g. tmplx.Service[*SessionStore](ctx). T may be an interface, which
+// the first service implementing it satisfies. Services given to the render
+// with WithServices come first, then those of ctx, then Options.Services.
+func Service[T any](ctx context.Context) (T, bool) {
+	services := servicesFrom(ctx)
+	for i := len(services) - 1; i >= 0; i-- {
+		if svc, ok := services[i].(T); ok {
+			return svc, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// WithServices provides services to the funcs of a single render, on top of
+// those of the render's context and Options.Services
+func WithServices(services ...any) RenderOption {
+	return func(cfg *renderConfig) {
+		cfg.services = append(cfg.services, services...)
+	}
+}
+
+// bindServices returns the context of a render providing the engine's
+// services below those of ctx and the render's own on top
+func (e *TemplateEngine) bindServices(ctx context.Context, services []any) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	all := append(append(append([]any(nil), e.services...), servicesFrom(ctx)...), services...)
+	return context.WithValue(ctx, servicesContextKey{}, all)
+}
+
+// setupServices registers the funcs of Options.ServiceFuncs, binding the
+// context parameter of each to the context of the render
+func (e *TemplateEngine) setupServices(opts Options) {
+	for name, fn := range opts.ServiceFuncs {
+		if err := validateServiceFunc(name, fn); err != nil {
+			e.logf(LogLoad, LogError, "[TMPLX] %v", err)
+			continue
+		}
+		e.registerRenderFunc(name, serviceFunc(fn))
+	}
+	if len(opts.ServiceFuncs) > 0 {
+		e.contextual.Store(true)
+	}
+	e.services = opts.Services
+}
+
+// serviceFunc adapts a func taking a context first into a render func
+// passing the context of the render
+func serviceFunc(fn any) renderFunc {
+	v := reflect.ValueOf(fn)
+	typ := v.Type()
+	in := make([]reflect.Type, typ.NumIn()-1)
+	for i := range in {
+		in[i] = typ.In(i + 1)
+	}
+	out := make([]reflect.Type, typ.NumOut())
+	for i := range out {
+		out[i] = typ.Out(i)
+	}
+	bound := reflect.FuncOf(in, out, typ.IsVariadic())
+
+	return func(st *renderState) any {
+		return reflect.MakeFunc(bound, func(args []reflect.Value) []reflect.Value {
+			ctx := st.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			args = append([]reflect.Value{reflect.ValueOf(&ctx).Elem()}, args...)
+			if typ.IsVariadic() {
+				return v.CallSlice(args)
+			}
+			return v.Call(args)
+		}).Interface()
+	}
+}
+
+// validateServiceFunc checks a func of Options.ServiceFuncs takes a context
+// first and is otherwise a valid template func
+func validateServiceFunc(name string, fn any) error {
+	if isReservedFunc(name) {
+		return fmt.Errorf("ServiceFuncs: %q is reserved", name)
+	}
+	if !isIdentifier(name) {
+		return fmt.Errorf("ServiceFuncs: %q is not a valid func name", name)
+	}
+	typ := reflect.TypeOf(fn)
+	if typ == nil || typ.Kind() != reflect.Func {
+		return fmt.Errorf("ServiceFuncs: %q is %T, not a func", name, fn)
+	}
+	if typ.NumIn() == 0 || typ.In(0) != contextType {
+		return fmt.Errorf("ServiceFuncs: %q must take a context.Context first", name)
+	}
+	switch {
+	case typ.NumOut() == 1:
+	case typ.NumOut() == 2 && typ.Out(1) == errorType:
+	default:
+		return fmt.Errorf("ServiceFuncs: %q must return one value, or a value and an error", name)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package tmplx
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type sessionService struct{ user string }
+
+type greeter interface{ Greet(name string) string }
+
+type englishGreeter struct{}
+
+func (englishGreeter) Greet(name string) string { return "Hello, " + name }
+
+func TestServiceFuncs(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{"pages/home.html": `{{greet (user)}} {{sum 1 2}}`},
+		ServiceFuncs: map[string]any{
+			"user": func(ctx context.Context) string {
+				if s, ok := Service[*sessionService](ctx); ok {
+					return s.user
+				}
+				return "guest"
+			},
+			"greet": func(ctx context.Context, name string) (string, error) {
+				g, ok := Service[greeter](ctx)
+				if !ok {
+					return "", fmt.Errorf("no greeter")
+				}
+				return g.Greet(name), nil
+			},
+			"sum": func(ctx context.Context, n ...int) int {
+				total := 0
+				for _, v := range n {
+					total += v
+				}
+				return total
+			},
+		},
+		Services: []any{englishGreeter{}, &sessionService{user: "default"}},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := engine.Render("pages/home.html", nil); err != nil || result != "Hello, default 3" {
+		t.Errorf("Expected the engine's services, got %q, %v", result, err)
+	}
+
+	// services of the context win over the engine's, and those of the
+	// render over both
+	ctx := ContextWithServices(context.Background(), &sessionService{user: "ada"})
+	if result, _ := engine.Render("pages/home.html", nil, WithContext(ctx)); result != "Hello, ada 3" {
+		t.Errorf("Expected the context's session, got %q", result)
+	}
+	result, _ := engine.Render("pages/home.html", nil, WithContext(ctx), WithServices(&sessionService{user: "grace"}))
+	if result != "Hello, grace 3" {
+		t.Errorf("Expected the render's session, got %q", result)
+	}
+
+	// bound per request
+	r := httptest.NewRequest("GET", "/", nil)
+	r = r.WithContext(ContextWithServices(r.Context(), &sessionService{user: "linus"}))
+	w := httptest.NewRecorder()
+	if err := engine.RenderHTTP(w, r, "pages/home.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if body := w.Body.String(); body != "Hello, linus 3" {
+		t.Errorf("Expected the request's session, got %q", body)
+	}
+}
+
+func TestValidateServiceFuncs(t *testing.T) {
+	err := Options{
+		Loader: MapLoader{},
+		ServiceFuncs: map[string]any{
+			"noContext": func(name string) string { return name },
+			"twoValues": func(ctx context.Context) (string, string) { return "", "" },
+			"notAFunc":  "user",
+		},
+	}.Validate()
+	for _, want := range []string{
+		`"noContext" must take a context.Context first`,
+		`"twoValues" must return one value, or a value and an error`,
+		`"notAFunc" is string, not a func`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %s, got %v", want, err)
+		}
+	}
+}
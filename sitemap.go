@@ -0,0 +1,108 @@
+package tmplx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SitemapEntry describes a page in a sitemap. Every field is optional.
+type SitemapEntry struct {
+	// Path is the page's URL path relative to the base URL. It defaults to
+	// the template name without extension, with a trailing "index" dropped,
+	// so "blog/index.html" becomes "/blog/".
+	Path string
+
+	// LastMod is when the page last changed
+	LastMod time.Time
+
+	// ChangeFreq hints how often the page changes, e.g. "daily" or "monthly"
+	ChangeFreq string
+
+	// Priority is the page's priority relative to the site's other pages,
+	// between 0 and 1
+	Priority float64
+
+	// Exclude leaves the page out of the sitemap
+	Exclude bool
+}
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// Sitemap returns a sitemap.xml listing every page template under baseURL.
+// Pages are the loaded templates that no other template extends or includes,
+// so layouts and partials are left out, as are SVG templates and the
+// built-in partials. meta, if not nil, describes each page by template
+// name; without it pages get their default path only.
+func (e *TemplateEngine) Sitemap(baseURL string, meta func(name string) SitemapEntry) ([]byte, error) {
+	e.compileAll()
+	e.mu.RLock()
+	referenced := make(map[string]bool)
+	for _, deps := range e.deps {
+		for _, dep := range deps {
+			referenced[dep] = true
+		}
+	}
+	names := e.templateNames()
+	e.mu.RUnlock()
+
+	set := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	base := strings.TrimSuffix(baseURL, "/")
+	for _, name := range names {
+		if referenced[name] || isSVG(name) || strings.HasPrefix(name, "tmplx/") {
+			continue
+		}
+
+		var entry SitemapEntry
+		if meta != nil {
+			entry = meta(name)
+		}
+		if entry.Exclude {
+			continue
+		}
+		if entry.Path == "" {
+			entry.Path = sitemapPath(name)
+		}
+
+		u := sitemapURL{
+			Loc:        base + "/" + strings.TrimPrefix(entry.Path, "/"),
+			ChangeFreq: entry.ChangeFreq,
+		}
+		if !entry.LastMod.IsZero() {
+			u.LastMod = entry.LastMod.UTC().Format(time.RFC3339)
+		}
+		if entry.Priority > 0 {
+			u.Priority = strconv.FormatFloat(entry.Priority, 'f', 1, 64)
+		}
+		set.URLs = append(set.URLs, u)
+	}
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding sitemap: %v", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// sitemapPath returns the default URL path of a page template
+func sitemapPath(name string) string {
+	p := "/" + strings.TrimSuffix(name, path.Ext(name))
+	if path.Base(p) == "index" {
+		return strings.TrimSuffix(p, "index")
+	}
+	return p
+}
@@ -0,0 +1,58 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitemap(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html": `<main>{{block "content" .}}{{end}}</main>{{include "partials/nav.html" .}}`,
+			"partials/nav.html": `<nav></nav>`,
+			"index.html":        `{{extend "layouts/base.html"}}{{define "content"}}home{{end}}`,
+			"blog/index.html":   `{{extend "layouts/base.html"}}{{define "content"}}blog{{end}}`,
+			"blog/first.html":   `{{extend "layouts/base.html"}}{{define "content"}}first{{end}}`,
+			"errors/500.html":   `oops`,
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := engine.Sitemap("https://example.com/", func(name string) SitemapEntry {
+		switch name {
+		case "errors/500.html":
+			return SitemapEntry{Exclude: true}
+		case "blog/first.html":
+			return SitemapEntry{
+				Path:       "/blog/hello-world",
+				LastMod:    time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+				ChangeFreq: "monthly",
+				Priority:   0.8,
+			}
+		}
+		return SitemapEntry{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitemap := string(out)
+	containsAll(t, []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		`<loc>https://example.com/</loc>`,
+		`<loc>https://example.com/blog/</loc>`,
+		"<loc>https://example.com/blog/hello-world</loc>\n    <lastmod>2024-03-01T12:00:00Z</lastmod>\n    <changefreq>monthly</changefreq>\n    <priority>0.8</priority>",
+	}, sitemap)
+	for _, unexpected := range []string{"layouts", "partials", "errors"} {
+		if strings.Contains(sitemap, unexpected) {
+			t.Errorf("Expected %s left out of sitemap, got %s", unexpected, sitemap)
+		}
+	}
+	if n := strings.Count(sitemap, "<url>"); n != 3 {
+		t.Errorf("Expected 3 pages, got %d", n)
+	}
+}
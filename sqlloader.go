@@ -0,0 +1,167 @@
+package tmplx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// SQLLoader is a Loader reading templates from a database table with name,
+// content and updated_at columns, for applications where templates are edited
+// at runtime:
+//
+//	CREATE TABLE templates (
+//	    name       TEXT PRIMARY KEY,
+//	    content    TEXT NOT NULL,
+//	    updated_at TIMESTAMP NOT NULL
+//	);
+//
+// List reads every row in one query and Read serves from that snapshot, so
+// no placeholders are needed and any database/sql driver works. As a Watcher
+// it polls the row count and latest updated_at, and also reloads on every
+// send on Notify, e.g. from a LISTEN/NOTIFY or pub/sub subscription.
+type SQLLoader struct {
+	// DB is the database to read from
+	DB *sql.DB
+
+	// Table is the table name, "templates" if empty. It is used in queries
+	// as is, so it must not come from user input.
+	Table string
+
+	// Interval is how often Watch polls for changes, 5 seconds if zero.
+	// A negative interval disables polling, leaving Notify only.
+	Interval time.Duration
+
+	// Notify optionally signals that templates have changed
+	Notify <-chan struct{}
+
+	// Logger, if set, is told about polls that fail. Watch keeps polling
+	// through them, so a database that is briefly unreachable doesn't end
+	// hot reloading.
+	Logger Logger
+
+	mu       sync.Mutex
+	contents map[string][]byte
+}
+
+func (l *SQLLoader) table() string {
+	if l.Table == "" {
+		return "templates"
+	}
+	return l.Table
+}
+
+func (l *SQLLoader) List() ([]string, error) {
+	rows, err := l.DB.Query("SELECT name, content FROM " + l.table() + " ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("error querying templates: %v", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	contents := make(map[string][]byte)
+	for rows.Next() {
+		var name, content string
+		if err := rows.Scan(&name, &content); err != nil {
+			return nil, fmt.Errorf("error scanning template row: %v", err)
+		}
+		names = append(names, name)
+		contents[name] = []byte(content)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying templates: %v", err)
+	}
+
+	l.mu.Lock()
+	l.contents = contents
+	l.mu.Unlock()
+	return names, nil
+}
+
+func (l *SQLLoader) Read(name string) ([]byte, error) {
+	l.mu.Lock()
+	listed := l.contents != nil
+	l.mu.Unlock()
+	if !listed {
+		if _, err := l.List(); err != nil {
+			return nil, err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	content, ok := l.contents[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s: %w", name, fs.ErrNotExist)
+	}
+	return content, nil
+}
+
+// version returns a value that changes whenever rows are added, removed or
+// updated
+func (l *SQLLoader) version(ctx context.Context) (string, error) {
+	var count int64
+	var latest interface{}
+	row := l.DB.QueryRowContext(ctx, "SELECT COUNT(*), MAX(updated_at) FROM "+l.table())
+	if err := row.Scan(&count, &latest); err != nil {
+		return "", fmt.Errorf("error polling templates: %v", err)
+	}
+	return fmt.Sprintf("%d/%v", count, latest), nil
+}
+
+func (l *SQLLoader) Watch(ctx context.Context, changed func()) error {
+	interval := l.Interval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+
+	var tick <-chan time.Time
+	var last string
+	if interval > 0 {
+		// if the first poll fails, the first one that succeeds reloads
+		v, err := l.version(ctx)
+		if err != nil {
+			l.pollFailed(err)
+		}
+		last = v
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	notify := l.Notify
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-notify:
+			if !ok {
+				notify = nil
+				continue
+			}
+			changed()
+		case <-tick:
+			v, err := l.version(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				l.pollFailed(err)
+				continue
+			}
+			if v != last {
+				last = v
+				changed()
+			}
+		}
+	}
+}
+
+func (l *SQLLoader) pollFailed(err error) {
+	if l.Logger != nil {
+		AdaptLogger(l.Logger).Warnf("[TMPLX] %v, retrying", err)
+	}
+}
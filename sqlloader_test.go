@@ -0,0 +1,216 @@
+package tmplx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memDB is a tiny database/sql driver serving the queries SQLLoader makes
+// from an in-memory table
+type memDB struct {
+	mu    sync.Mutex
+	rows  map[string]string
+	rev   int
+	fails int // polls to fail before answering again
+}
+
+func (d *memDB) set(name, content string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rows[name] = content
+	d.rev++
+}
+
+type memConn struct{ d *memDB }
+
+func (c memConn) Prepare(query string) (driver.Stmt, error) { return memStmt{c.d, query}, nil }
+func (c memConn) Close() error                              { return nil }
+func (c memConn) Begin() (driver.Tx, error)                 { return nil, fmt.Errorf("not supported") }
+
+type memStmt struct {
+	d     *memDB
+	query string
+}
+
+func (s memStmt) Close() error                               { return nil }
+func (s memStmt) NumInput() int                              { return 0 }
+func (s memStmt) Exec([]driver.Value) (driver.Result, error) { return nil, fmt.Errorf("not supported") }
+
+func (s memStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "SELECT name, content FROM templates"):
+		rows := &memRows{cols: []string{"name", "content"}}
+		for name, content := range s.d.rows {
+			rows.data = append(rows.data, []driver.Value{name, content})
+		}
+		return rows, nil
+	case strings.HasPrefix(s.query, "SELECT COUNT(*), MAX(updated_at) FROM templates"):
+		if s.d.fails > 0 {
+			s.d.fails--
+			return nil, fmt.Errorf("connection reset")
+		}
+		stamp := time.Date(2024, 1, 1, 0, 0, s.d.rev, 0, time.UTC)
+		return &memRows{cols: []string{"count", "max"}, data: [][]driver.Value{{int64(len(s.d.rows)), stamp}}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", s.query)
+}
+
+type memRows struct {
+	cols []string
+	data [][]driver.Value
+}
+
+func (r *memRows) Columns() []string { return r.cols }
+func (r *memRows) Close() error      { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.data) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.data[0])
+	r.data = r.data[1:]
+	return nil
+}
+
+// memDriver opens the memDB registered under the DSN
+type memDriver struct{}
+
+var (
+	memDBs      sync.Map
+	memRegister sync.Once
+)
+
+func (memDriver) Open(dsn string) (driver.Conn, error) {
+	d, ok := memDBs.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("unknown database %s", dsn)
+	}
+	return memConn{d.(*memDB)}, nil
+}
+
+func openMemDB(t *testing.T, rows map[string]string) (*memDB, *sql.DB) {
+	memRegister.Do(func() { sql.Register("tmplxmem", memDriver{}) })
+
+	d := &memDB{rows: rows}
+	dsn := fmt.Sprintf("%s-%p", t.Name(), d)
+	memDBs.Store(dsn, d)
+	db, err := sql.Open("tmplxmem", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		memDBs.Delete(dsn)
+	})
+	return d, db
+}
+
+func TestSQLLoader(t *testing.T) {
+	mem, db := openMemDB(t, map[string]string{
+		"layouts/base.html": `<body>{{block "content" .}}{{end}}</body>`,
+		"pages/home.html":   `{{extend "layouts/base.html"}}{{define "content"}}v1{{end}}`,
+	})
+	loader := &SQLLoader{DB: db, Interval: 10 * time.Millisecond}
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "<body>v1</body>" {
+		t.Errorf("Unexpected output %q", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan struct{}, 1)
+	go loader.Watch(ctx, func() {
+		if err := engine.Reload(); err != nil {
+			t.Error(err)
+		}
+		reloaded <- struct{}{}
+	})
+
+	time.Sleep(20 * time.Millisecond)
+	mem.set("pages/home.html", `{{extend "layouts/base.html"}}{{define "content"}}v2{{end}}`)
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("Expected polling to detect the update")
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "<body>v2</body>" {
+		t.Errorf("Expected updated output, got %q", result)
+	}
+
+	if _, err := loader.Read("missing.html"); err == nil {
+		t.Error("Expected error reading a missing template")
+	}
+}
+
+func TestSQLLoaderNotify(t *testing.T) {
+	_, db := openMemDB(t, map[string]string{"page.html": "hi"})
+	notify := make(chan struct{})
+	loader := &SQLLoader{DB: db, Interval: -1, Notify: notify}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	calls := 0
+	go func() { done <- loader.Watch(ctx, func() { calls++ }) }()
+
+	notify <- struct{}{}
+	notify <- struct{}{}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 change calls, got %d", calls)
+	}
+}
+
+func TestSQLLoaderPollErrors(t *testing.T) {
+	mem, db := openMemDB(t, map[string]string{"page.html": "v1"})
+	mem.fails = 3
+	logger := &recordingLogger{}
+	loader := &SQLLoader{DB: db, Interval: 5 * time.Millisecond, Logger: logger}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() {
+		done <- loader.Watch(ctx, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	// the first successful poll after failing from the start reloads
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected polling to go on after errors")
+	}
+	mem.set("page.html", "v2")
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected polling to detect the update")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.lines) != 3 || !strings.Contains(logger.lines[0], "connection reset") {
+		t.Errorf("Expected the 3 failed polls logged, got %q", logger.lines)
+	}
+}
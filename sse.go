@@ -0,0 +1,68 @@
+package tmplx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RenderSSE streams Server-Sent Events to the client of r, one for each item
+// received from items, with the named block of the template rendered for it
+// as the event data. Events are named after the block, which suits htmx's
+// sse extension (sse-swap="row"). It returns when items is closed or the
+// client goes away, or with the first render error.
+func (e *TemplateEngine) RenderSSE(w http.ResponseWriter, r *http.Request, name, block string, items <-chan interface{}) error {
+	if err := e.maybeRefresh(name); err != nil {
+		return err
+	}
+	if _, ok := e.executor(name); !ok {
+		return notFound(name)
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return fmt.Errorf("error flushing event stream: %v", err)
+	}
+
+	opts := []RenderOption{WithLocale(e.requestLocale(r)), WithContext(r.Context())}
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case item, ok := <-items:
+			if !ok {
+				return nil
+			}
+
+			buf.Reset()
+			if err := e.RenderBlock(&buf, name, block, item, opts...); err != nil {
+				return err
+			}
+			if err := writeEvent(w, block, buf.String()); err != nil {
+				return err
+			}
+			if err := rc.Flush(); err != nil {
+				return fmt.Errorf("error flushing event stream: %v", err)
+			}
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event, with a data line per line of data
+func writeEvent(w io.Writer, event, data string) error {
+	var b strings.Builder
+	b.WriteString("event: " + event + "\n")
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
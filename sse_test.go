@@ -0,0 +1,77 @@
+package tmplx
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRenderSSE(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"orders.html": `<table>{{range .}}{{block "row" .}}<tr>
+<td>{{.}}</td></tr>{{end}}{{end}}</table>`,
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	items := make(chan interface{}, 2)
+	items <- "a&b"
+	items <- 2
+	close(items)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/orders/events", nil)
+	if err := engine.RenderSSE(rec, req, "orders.html", "row", items); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Expected event stream content type, got %q", got)
+	}
+	if !rec.Flushed {
+		t.Error("Expected events to be flushed")
+	}
+	expected := "event: row\ndata: <tr>\ndata: <td>a&amp;b</td></tr>\n\n" +
+		"event: row\ndata: <tr>\ndata: <td>2</td></tr>\n\n"
+	if got := rec.Body.String(); got != expected {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expected, got)
+	}
+
+	// a client going away ends the stream
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := engine.RenderSSE(httptest.NewRecorder(), req.WithContext(ctx), "orders.html", "row", make(chan interface{})); err != nil {
+		t.Errorf("Expected nil error when the client leaves, got %v", err)
+	}
+
+	failing := make(chan interface{}, 1)
+	failing <- "x"
+	if err := engine.RenderSSE(httptest.NewRecorder(), req, "orders.html", "missing", failing); err == nil {
+		t.Error("Expected error for missing block, got nil")
+	}
+}
+
+func TestRenderSSEContext(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{
+		"feed.html": `{{block "row" .}}{{ctx "user"}}: {{.}}{{end}}`,
+	}})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ContextWithStore(context.Background())
+	Set(ctx, "user", "ada")
+	items := make(chan interface{}, 1)
+	items <- "hi"
+	close(items)
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/feed", nil).WithContext(ctx)
+	if err := engine.RenderSSE(rec, req, "feed.html", "row", items); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != "event: row\ndata: ada: hi\n\n" {
+		t.Errorf("Expected events to see the request's context, got %q", got)
+	}
+}
@@ -0,0 +1,192 @@
+package tmplx
+
+import (
+	"context"
+	"html/template"
+	"reflect"
+	"sync"
+	"text/template/parse"
+)
+
+// storeFuncs are the template funcs reading and writing the render store,
+// and the context of the render
+var storeFuncs = []string{"ctx", "ctxSet", "ctxAppend", "context"}
+
+type storeContextKey struct{}
+
+// renderStore holds the values funcs and templates share during a render
+type renderStore struct {
+	mu     sync.Mutex
+	values map[string]any
+}
+
+// ContextWithStore returns a copy of ctx carrying a new, empty render store.
+// Every render gets a store of its own; render with WithContext and a
+// context from ContextWithStore to read what it stored once it is done.
+func ContextWithStore(ctx context.Context) context.Context {
+	return context.WithValue(ctx, storeContextKey{}, &renderStore{values: make(map[string]any)})
+}
+
+func storeFrom(ctx context.Context) *renderStore {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(storeContextKey{}).(*renderStore)
+	return s
+}
+
+// Set stores value under key in the render store of ctx, for later funcs
+// and templates of the render to read with Get or {{ctx "key"}}. It does
+// nothing if ctx has no store.
+func Set(ctx context.Context, key string, value any) {
+	if s := storeFrom(ctx); s != nil {
+		s.mu.Lock()
+		s.values[key] = value
+		s.mu.Unlock()
+	}
+}
+
+// Get returns the value stored under key in the render store of ctx, or nil
+func Get(ctx context.Context, key string) any {
+	s := storeFrom(ctx)
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key]
+}
+
+// Append adds value to the list stored under key in the render store of ctx,
+// unless the list has it already, e.g. to collect the scripts components ask
+// for and emit each once in the layout. The list is a []any.
+func Append(ctx context.Context, key string, value any) {
+	s := storeFrom(ctx)
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list, _ := s.values[key].([]any)
+	for _, v := range list {
+		if sameValue(v, value) {
+			return
+		}
+	}
+	s.values[key] = append(list, value)
+}
+
+// sameValue reports whether a and b are equal, false if they can't be
+// compared
+func sameValue(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		return !va.IsValid() && !vb.IsValid()
+	}
+	return va.Type() == vb.Type() && va.Comparable() && va.Equal(vb)
+}
+
+// withStore returns ctx with a render store, adding a new one if it has none
+func withStore(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if storeFrom(ctx) != nil {
+		return ctx
+	}
+	return ContextWithStore(ctx)
+}
+
+// setupStore registers the ctx, ctxSet, ctxAppend and context funcs, which
+// reach the context of each render
+func (e *TemplateEngine) setupStore(opts Options) {
+	funcs := map[string]renderFunc{
+		"ctx": func(st *renderState) any {
+			return func(key string) any { return Get(st.ctx, key) }
+		},
+		"ctxSet": func(st *renderState) any {
+			return func(key string, value any) template.HTML {
+				Set(st.ctx, key, value)
+				return ""
+			}
+		},
+		"ctxAppend": func(st *renderState) any {
+			return func(key string, value any) template.HTML {
+				Append(st.ctx, key, value)
+				return ""
+			}
+		},
+		"context": func(st *renderState) any {
+			return func() context.Context { return renderContext(st) }
+		},
+	}
+	for name, rf := range funcs {
+		if _, userDefined := opts.FuncMap[name]; !userDefined {
+			e.registerRenderFunc(name, rf)
+		}
+	}
+}
+
+// usesStore reports whether a resolved template calls a store func, so the
+// engine gives its renders a store
+func (e *TemplateEngine) usesStore(tmpl *template.Template) bool {
+	names := make(map[string]bool, len(storeFuncs))
+	for _, name := range storeFuncs {
+		if _, ok := e.renderFuncs[name]; ok {
+			names[name] = true
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && callsFunc(t.Tree.Root, names) {
+			return true
+		}
+	}
+	return false
+}
+
+// callsFunc reports whether any of the named funcs is called below node
+func callsFunc(node parse.Node, names map[string]bool) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, c := range n.Nodes {
+			if callsFunc(c, names) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return callsFunc(n.Pipe, names)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if callsFunc(cmd, names) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if callsFunc(arg, names) {
+				return true
+			}
+		}
+	case *parse.IdentifierNode:
+		return names[n.Ident]
+	case *parse.IfNode:
+		return callsBranch(&n.BranchNode, names)
+	case *parse.RangeNode:
+		return callsBranch(&n.BranchNode, names)
+	case *parse.WithNode:
+		return callsBranch(&n.BranchNode, names)
+	case *parse.TemplateNode:
+		return callsFunc(n.Pipe, names)
+	}
+	return false
+}
+
+func callsBranch(b *parse.BranchNode, names map[string]bool) bool {
+	return callsFunc(b.Pipe, names) || callsFunc(b.List, names) || callsFunc(b.ElseList, names)
+}
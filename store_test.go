@@ -0,0 +1,82 @@
+package tmplx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRenderStore(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html":   `<main>{{block "content" .}}{{end}}</main>{{range ctx "scripts"}}<script src="{{.}}"></script>{{end}}`,
+			"partials/chart.html": `{{define "chart"}}{{ctxAppend "scripts" "/chart.js"}}<canvas></canvas>{{end}}`,
+			"pages/home.html": `{{extend "layouts/base.html"}}{{include "partials/chart.html" .}}` +
+				`{{define "content"}}{{template "chart" .}}{{template "chart" .}}{{ctxSet "title" "Home"}}{{greeting}}{{end}}`,
+			"pages/plain.html": `{{ctx "title"}}`,
+		},
+		ServiceFuncs: map[string]any{
+			"greeting": func(ctx context.Context) string {
+				appendUser(ctx)
+				return fmt.Sprintf("<p>%v</p>", Get(ctx, "title"))
+			},
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("pages/home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<main><canvas></canvas><canvas></canvas>&lt;p&gt;Home&lt;/p&gt;</main><script src="/chart.js"></script>`
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	// every render has a store of its own
+	if result, _ := engine.Render("pages/plain.html", nil); result != "" {
+		t.Errorf("Expected an empty store, got %q", result)
+	}
+
+	// values stored before the render are visible to it, and values stored
+	// during it to the caller
+	ctx := ContextWithStore(context.Background())
+	Set(ctx, "title", "Preset")
+	if result, _ := engine.Render("pages/plain.html", nil, WithContext(ctx)); result != "Preset" {
+		t.Errorf("Expected the preset title, got %q", result)
+	}
+	if _, err := engine.Render("pages/home.html", nil, WithContext(ctx)); err != nil {
+		t.Fatal(err)
+	}
+	if scripts := fmt.Sprint(Get(ctx, "scripts")); scripts != "[/chart.js]" {
+		t.Errorf("Expected the collected scripts, got %s", scripts)
+	}
+	if users := fmt.Sprint(Get(ctx, "users")); !strings.Contains(users, "greeting") {
+		t.Errorf("Expected the service func to append, got %s", users)
+	}
+}
+
+func appendUser(ctx context.Context) {
+	Append(ctx, "users", "greeting")
+	Append(ctx, "users", "greeting")
+}
+
+func TestRenderStoreWithoutStore(t *testing.T) {
+	ctx := context.Background()
+	Set(ctx, "k", "v")
+	Append(ctx, "k", "v")
+	if v := Get(ctx, "k"); v != nil {
+		t.Errorf("Expected nil without a store, got %v", v)
+	}
+
+	ctx = ContextWithStore(ctx)
+	Append(ctx, "k", []string{"not comparable"})
+	Append(ctx, "k", []string{"not comparable"})
+	Append(ctx, "k", nil)
+	if list := Get(ctx, "k").([]any); len(list) != 3 {
+		t.Errorf("Expected values that can't be compared to be kept, got %v", list)
+	}
+}
@@ -0,0 +1,36 @@
+package tmplx
+
+import (
+	"html/template"
+	"path"
+	"regexp"
+	"strconv"
+)
+
+// prologFunc writes an XML processing instruction of an SVG template, which
+// html/template would otherwise escape as text
+const prologFunc = "tmplxProlog"
+
+// xmlInstruction matches processing instructions such as the XML declaration
+var xmlInstruction = regexp.MustCompile(`<\?[^?]*\?>`)
+
+// isSVG reports whether name is an SVG template, whose output must stay
+// well-formed XML: dev mode adds no comments to it
+func isSVG(name string) bool {
+	return path.Ext(name) == ".svg"
+}
+
+// svgProlog protects the processing instructions of SVG templates, like
+// <?xml version="1.0"?>, from escaping
+func svgProlog(file, content string) string {
+	if !isSVG(file) {
+		return content
+	}
+	return xmlInstruction.ReplaceAllStringFunc(content, func(pi string) string {
+		return "{{" + prologFunc + " " + strconv.Quote(pi) + "}}"
+	})
+}
+
+func prolog(pi string) template.HTML {
+	return template.HTML(pi)
+}
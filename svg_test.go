@@ -0,0 +1,52 @@
+package tmplx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSVGTemplates(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"badges/base.svg": `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20"><defs>{{block "defs" .}}<linearGradient id="g"/>{{end}}</defs>{{block "body" .}}{{end}}</svg>`,
+			"badges/build.svg": `{{extend "badges/base.svg"}}{{define "body"}}<text x="5" y="14" aria-label="{{.Label}}">{{.Label}}</text>{{end}}`,
+			"page.html":        `<p>{{.Label}}</p>`,
+		},
+		Extensions: []string{".html", ".svg"},
+		DevMode:    true,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := engine.Write(rec, http.StatusOK, "badges/build.svg", H{"Width": 90, "Label": `<passing> & "ok"`}); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "image/svg+xml" {
+		t.Errorf("Expected SVG content type, got %q", got)
+	}
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, `<?xml version="1.0" encoding="UTF-8"?>`) || strings.Contains(body, "<!--") {
+		t.Errorf("Expected well-formed SVG without dev mode comments, got %s", body)
+	}
+	containsAll(t, []string{
+		`width="90"`,
+		`<defs><linearGradient id="g"/></defs>`,
+		`aria-label="&lt;passing&gt; &amp; &#34;ok&#34;">&lt;passing&gt; &amp; &#34;ok&#34;</text>`,
+	}, body)
+
+	rec = httptest.NewRecorder()
+	if err := engine.Write(rec, http.StatusOK, "page.html", H{"Label": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Expected HTML content type for pages, got %q", got)
+	}
+	if !strings.Contains(rec.Body.String(), "<!--") {
+		t.Errorf("Expected dev mode comments in HTML pages, got %s", rec.Body.String())
+	}
+}
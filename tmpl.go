@@ -1,14 +1,22 @@
 package tmplx
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	texttemplate "text/template"
 	"text/template/parse"
+	"time"
 )
 
 // Package tmpl provides a template engine with inheritance, blocks and includes support.
@@ -42,16 +50,102 @@ import (
 type inclCache struct {
 	content string
 	tmpl    *template.Template
+	lines   lineMap
 }
 
+// TemplateEngine loads and renders templates. It is safe for concurrent use:
+// any number of goroutines may render while others call Reload, AddFuncs or
+// LoadTemplates, and every render sees either the old or the new templates,
+// never a mix.
 type TemplateEngine struct {
+	// mu guards the loaded templates and the sources they were read from.
+	// Exported methods take it; the unexported ones loading templates expect
+	// the caller to hold it.
+	mu sync.RWMutex
+
+	// loadMu serializes loading, so a reload doesn't drop the funcs AddFuncs
+	// adds while it runs
+	loadMu sync.Mutex
+
+	// resolveMu guards the caches filled while resolving templates, which
+	// the workers of a parallel load share
+	resolveMu sync.Mutex
+
 	srcs      []Source
 	cache     map[string]*template.Template
 	loadCache map[string]*template.Template
+	pageVars  map[string]PageVars
+	parents   map[string]string
+	deps      dependencyGraph
 	inclCache map[string]*inclCache
 	funcMap   template.FuncMap
 	loaded    bool
-	logger    Logger
+	logger    LevelLogger
+	strict    bool
+	unknown   UnknownFuncPolicy
+	devMode   bool
+	backend   Backend
+	text      map[string]*texttemplate.Template
+	exts      []string
+	ctype     string
+	etag      ETagMode
+	digests   map[string]string
+
+	renderFuncs   map[string]renderFunc
+	bases         map[string]executor
+	pools         map[string]*sync.Pool
+	locales       []string
+	defaultLocale string
+	localeParam   string
+	localeCookie  string
+	buffer        bool
+	trace         bool
+	logDefault    LogLevel
+	logLevels     map[LogCategory]LogLevel
+	instrument    bool
+	marks         []boundary
+	directives    map[string]Directive
+	transforms    []TreeTransform
+	filterSyntax  bool
+	converters    map[string]Converter
+	beforeReload  []ReloadHook
+	afterReload   []ReloadHook
+	islands       map[string]Island
+	criticalCSS   CSSExtractor
+	strictCSP     CSPMode
+	outputChecks  []OutputCheck
+	layoutFor     func(name string) string
+	flags         FlagProvider
+	env           string
+	envs          []string
+	cacheEvents   CacheEvents
+	fallback      string
+	onRenderError func(name string, err error)
+	limits        Limits
+	memoized      bool
+	services      []any
+	autoLangDir   bool
+	processors    []PostProcessor
+	watchInterval time.Duration
+	noCache       bool
+	lazy          bool
+	loadWorkers   int
+	assets        *Assets
+	version       string
+	versions      map[string]*TemplateEngine
+	versionsMu    sync.Mutex
+
+	// contextual is set once a render may need a store or services in its
+	// context. Loads set it while renders read it.
+	contextual atomic.Bool
+
+	// missing holds the names LazyLoad found no template for, until the
+	// next reload
+	missing map[string]bool
+
+	// dirConfigs caches the directory configs of the source being loaded,
+	// by directory. It is nil outside a load.
+	dirConfigs map[string]*dirConfig
 }
 
 type templateTree struct {
@@ -60,6 +154,7 @@ type templateTree struct {
 	extends  string
 	blocks   map[string]string
 	includes []string
+	vars     PageVars
 }
 
 type Source struct {
@@ -67,11 +162,22 @@ type Source struct {
 	Dir string
 
 	// FS provides an optional fs.FS implementation for reading templates
-	// If nil, os.DirFS(Dir) will be used
+	// If nil, os.DirFS(Dir) will be used. If set, Dir is a subdirectory of it.
 	FS fs.FS
+
+	// Loader reads templates from somewhere other than a filesystem, such as
+	// a database or object storage. When set, Dir and FS are ignored.
+	Loader Loader
 }
 
 func setupSource(s *Source) {
+	if s.Loader != nil {
+		// FS is filled from the loader each time templates are loaded
+		s.Dir = "."
+		s.FS = memFS{}
+		return
+	}
+
 	if strings.HasSuffix(s.Dir, "/") {
 		s.Dir = strings.TrimSuffix(s.Dir, "/")
 	}
@@ -84,8 +190,17 @@ func setupSource(s *Source) {
 		}
 		s.Dir = "."
 	} else {
+		// Dir is a subdirectory of the FS, e.g. "templates" for an embed.FS
+		// built from //go:embed templates. Template names are relative to it.
+		s.Dir = strings.Trim(path.Clean("/"+s.Dir), "/")
 		if s.Dir == "" {
 			s.Dir = "."
+			return
+		}
+		if info, err := fs.Stat(s.FS, s.Dir); err == nil && info.IsDir() {
+			if sub, err := fs.Sub(s.FS, s.Dir); err == nil {
+				s.FS, s.Dir = sub, "."
+			}
 		}
 	}
 }
@@ -96,27 +211,258 @@ type Options struct {
 	Dir string
 
 	// FS provides an optional fs.FS implementation for reading templates
-	// If nil, os.DirFS(Dir) will be used
+	// If nil, os.DirFS(Dir) will be used. If set, Dir is a subdirectory of
+	// it, e.g. "templates" for an embed.FS from //go:embed templates.
 	FS fs.FS
 
 	// Sources specifies a list of directories and filesystems to load templates from
 	Sources []Source
 
+	// Loader is a shorthand for a single source reading templates through a Loader
+	Loader Loader
+
 	// FuncMap defines custom template functions
-	// Note: 'extend', 'block' and 'include' are reserved function names
+	// Note: 'extend', 'block', 'include' and 'var' are reserved function names
 	FuncMap template.FuncMap
 
 	// Logger for template operations. If nil, uses a no-op logger
 	Logger Logger
+
+	// LogLevel is the level of every log category without an entry in
+	// LogLevels. Defaults to LogInfo.
+	LogLevel LogLevel
+
+	// LogLevels sets the level of individual categories, e.g. LogDebug for
+	// LogInheritance while leaving the rest at LogLevel.
+	LogLevels map[LogCategory]LogLevel
+
+	// StrictBlocks makes it a load error for a child template to define a block
+	// that does not exist anywhere in its layout chain. This catches typos like
+	// {{define "contnet"}} which would otherwise silently render the default.
+	StrictBlocks bool
+
+	// UnknownFuncs controls how calls to functions missing from FuncMap are
+	// treated at parse time. Defaults to UnknownFuncError.
+	UnknownFuncs UnknownFuncPolicy
+
+	// DevMode enables development diagnostics, such as warnings about output
+	// that ends up double-escaped, and wraps the output of every template,
+	// block and include in HTML comments naming where it came from
+	// (<!-- begin partials/nav.html --> ... <!-- end partials/nav.html -->).
+	// Not intended for production use.
+	DevMode bool
+
+	// Backend selects html/template (default) or text/template for execution
+	Backend Backend
+
+	// Extensions lists the file extensions treated as templates. Defaults to ".html".
+	Extensions []string
+
+	// ContentType is set on HTTP responses that don't already have one.
+	// Defaults to "text/html; charset=utf-8", or "text/plain; charset=utf-8"
+	// with TextBackend. SVG templates always use "image/svg+xml".
+	ContentType string
+
+	// ETag enables ETag generation and If-None-Match handling in RenderHTTP
+	ETag ETagMode
+
+	// Locales lists the locales the application has catalogs for. The first
+	// one is the default. RenderHTTP negotiates the render locale from the
+	// request against this list.
+	Locales []string
+
+	// LocaleFuncs registers template funcs that depend on the render locale.
+	// Each factory receives the locale of the current render and returns the
+	// func to call, e.g. a translation lookup in that locale's catalog.
+	LocaleFuncs map[string]func(locale string) any
+
+	// LocaleQueryParam and LocaleCookie name the query parameter and cookie
+	// that override Accept-Language. Both default to "lang".
+	LocaleQueryParam string
+	LocaleCookie     string
+
+	// BufferOutput renders RenderResponse, Write and RenderHTTP output into
+	// memory before writing it, guaranteeing that a render failing mid-way
+	// never sends a partial page. By default output is streamed for the
+	// best time to first byte.
+	BufferOutput bool
+
+	// Trace enables RenderWithTrace, which reports the template, block or
+	// include that produced each range of output lines. DevMode implies it.
+	// Output is the same as without it; templates rendered inside URLs,
+	// attributes, scripts or styles just get no spans.
+	Trace bool
+
+	// LayoutFor returns the layout a template extends when it has no extend
+	// directive of its own, given its name such as "pages/admin/users.html",
+	// or "" for none. See LayoutsByDir.
+	LayoutFor func(name string) string
+
+	// Directives registers load-time directives by name, like extend and
+	// include but implemented outside the engine. See Directive.
+	Directives map[string]Directive
+
+	// Transforms rewrite the parse trees of every resolved template, in
+	// order, before it is cached. See TreeTransform.
+	Transforms []TreeTransform
+
+	// FilterSyntax accepts Jinja-style pipelines such as
+	// {{ title | upper | truncate(80) }}, translating them to Go template
+	// syntax when templates are loaded. See "Filter Syntax" in the README.
+	FilterSyntax bool
+
+	// Converters turn rendered HTML into other formats for RenderAs, keyed
+	// by format name, e.g. "pdf"
+	Converters map[string]Converter
+
+	// UIPartials adds the built-in partials, such as tmplx/pagination.html,
+	// to every source. See UIPartials.
+	UIPartials bool
+
+	// BeforeReload runs when Watch sees a change, before templates are
+	// reloaded, e.g. AssetCommand to rebuild CSS along with them.
+	// AfterReload runs once they have been, e.g. to tell browsers to refresh.
+	BeforeReload []ReloadHook
+	AfterReload  []ReloadHook
+
+	// Islands enables the island and islandScripts funcs, keyed by the kind
+	// of client component, e.g. "react". See Island.
+	Islands map[string]Island
+
+	// CriticalCSS, if set, inlines the CSS each rendered HTML page needs for
+	// its first paint in its head and defers its stylesheets. Pages are then
+	// rendered in full before being written. See UsedCSS.
+	CriticalCSS CSSExtractor
+
+	// Assets enables the asset func mapping asset names to URLs. See Assets.
+	Assets *Assets
+
+	// StrictCSP audits each rendered HTML page for inline event handlers,
+	// inline scripts and styles without a nonce and other markup a strict
+	// Content-Security-Policy blocks, warning about or failing on it. Pages
+	// are then rendered in full before being written. See AuditCSP.
+	StrictCSP CSPMode
+
+	// OutputChecks run on every HTML page rendered in dev mode, logging the
+	// problems they find with the template that produced each line, e.g.
+	// WellFormed or Accessibility. Pages are then rendered in full before
+	// being written.
+	OutputChecks []OutputCheck
+
+	// Highlighter renders code for the highlight func, e.g.
+	// ChromaHighlighter(quick.Highlight). Without one, code is escaped into
+	// <pre><code class="language-x">. See Highlighter.
+	Highlighter Highlighter
+
+	// FlagProvider answers the feature func, e.g. {{if feature "new-nav"}},
+	// for template-level rollouts. Without one every flag is off.
+	FlagProvider FlagProvider
+
+	// Env names the environment, e.g. "dev" or "staging". Templates with a
+	// variant for it, such as partials/banner.dev.html for
+	// partials/banner.html, are read from the variant wherever they are
+	// rendered, extended or included. Only environments listed in Envs have
+	// variants.
+	Env string
+
+	// Envs lists the environments templates have variants for, e.g. "dev",
+	// "staging" and "prod". A file named for one of them, such as
+	// banner.dev.html, is never loaded under its own name, whatever Env is.
+	// Other files with a second extension, like post.card.html, are
+	// ordinary templates.
+	Envs []string
+
+	// CacheEvents are called when templates are loaded into, found in,
+	// missing from or dropped from the engine's caches, e.g. for metrics
+	CacheEvents CacheEvents
+
+	// FallbackTemplate is rendered in place of a page whose execution
+	// fails, e.g. a simplified version or a maintenance page, so visitors
+	// never get a broken response. Pages are then rendered in full before
+	// being written. WithFallback overrides it for a render.
+	FallbackTemplate string
+
+	// OnRenderError is called with the template and error of every failed
+	// render, including those replaced by the fallback template
+	OnRenderError func(name string, err error)
+
+	// Limits bound the output, func calls and time of every render, for
+	// templates written by semi-trusted users. WithLimits overrides them
+	// for a render.
+	Limits Limits
+
+	// PureFuncs names funcs whose result depends only on their arguments,
+	// such as translation lookups or formatters. Their results are reused
+	// within a render when they are called again with the same arguments.
+	PureFuncs []string
+
+	// ServiceFuncs registers template funcs taking a context.Context first,
+	// which the engine binds to the context of each render, so funcs can
+	// get request-scoped services with Service instead of closing over
+	// globals. Templates call them with the remaining arguments.
+	ServiceFuncs map[string]any
+
+	// Services are provided to every render, below those of its context
+	// and WithServices, e.g. a database handle
+	Services []any
+
+	// AutoLangDir adds lang and dir attributes following the render locale
+	// to <html> tags that lack them, so layouts stay locale-agnostic
+	AutoLangDir bool
+
+	// PostProcessors rewrite the output of every render in order, e.g. a
+	// LinkRewriter. Output is then rendered in full before being written.
+	// WithPostProcessors adds to them for a render.
+	PostProcessors []PostProcessor
+
+	// WatchInterval is how often Watch polls template directories for
+	// changes, 1 second if zero
+	WatchInterval time.Duration
+
+	// DisableCache re-reads and re-resolves a template, with the layouts
+	// and includes it uses, every time it is rendered, so edits show up
+	// without a watcher. It is meant for development only.
+	DisableCache bool
+
+	// LazyLoad resolves each template the first time it is rendered or
+	// looked up instead of at Load, which then only reads loaders. It cuts
+	// startup time for large template sets where most requests touch a few
+	// templates; errors in a template surface on its first render.
+	LazyLoad bool
+
+	// LoadConcurrency is how many templates Load resolves at once, to cut
+	// startup time on large template trees. Zero or one loads them one at a
+	// time. Directives, tree transforms and cache event hooks may then be
+	// called from several goroutines at once.
+	LoadConcurrency int
 }
 
+// UnknownFuncPolicy controls how undefined template functions are handled
+type UnknownFuncPolicy int
+
+const (
+	// UnknownFuncError fails parsing when a template calls an undefined function
+	UnknownFuncError UnknownFuncPolicy = iota
+
+	// UnknownFuncIgnore registers undefined functions as no-ops that render an
+	// empty string, logging a warning for each one. Useful while migrating large
+	// template sets whose helpers have not all been ported yet.
+	UnknownFuncIgnore
+)
+
+// Logger is the minimal logging interface accepted by Options.Logger. Loggers
+// that also implement LevelLogger receive each line at its own level;
+// otherwise every line goes to Infof.
 type Logger interface {
 	Infof(format string, args ...interface{})
 }
 
 type noopLogger struct{}
 
-func (n *noopLogger) Infof(string, ...interface{}) {}
+func (n *noopLogger) Debugf(string, ...interface{}) {}
+func (n *noopLogger) Infof(string, ...interface{})  {}
+func (n *noopLogger) Warnf(string, ...interface{})  {}
+func (n *noopLogger) Errorf(string, ...interface{}) {}
 
 // New creates a new template engine with the given options.
 // If no filesystem is provided in options, it will use os.DirFS with the specified directory.
@@ -129,15 +475,23 @@ func New(opts Options) *TemplateEngine {
 	if opts.Dir != "" || opts.FS != nil {
 		opts.Sources = append(opts.Sources, Source{Dir: opts.Dir, FS: opts.FS})
 	}
+	if opts.Loader != nil {
+		opts.Sources = append(opts.Sources, Source{Loader: opts.Loader})
+	}
 
 	for i := range opts.Sources {
 		setupSource(&opts.Sources[i])
+		if opts.UIPartials {
+			// layered below the source, so its own templates take precedence
+			opts.Sources[i] = Source{Loader: OverlayLoader(UIPartials, sourceLoader(opts.Sources[i]))}
+			setupSource(&opts.Sources[i])
+		}
 	}
 
 	// Set up logger
-	logger := opts.Logger
-	if logger == nil {
-		logger = &noopLogger{}
+	var logger LevelLogger = &noopLogger{}
+	if opts.Logger != nil {
+		logger = AdaptLogger(opts.Logger)
 	}
 
 	funcMap := template.FuncMap{
@@ -151,35 +505,219 @@ func New(opts Options) *TemplateEngine {
 		"include": func(name string, data interface{}) (string, error) {
 			return "", fmt.Errorf("include can only be called during template parsing")
 		},
+		varDirective: func(...interface{}) (string, error) {
+			return "", fmt.Errorf("var can only be used at the top level of a template")
+		},
+
+		prologFunc: prolog,
+
+		// Helpers that user funcs may replace
+		"meta":         metaFunc,
+		"truncateHTML": truncateHTML,
+		"excerpt":      excerpt,
+		"slugify":      slugify,
+		"urlQuery":     urlQuery,
+		"pathJoin":     pathJoin,
+		"diffHTML":     diffHTML,
 	}
 
 	// Add user-provided functions
 	for name, fn := range opts.FuncMap {
-		if name != "extend" && name != "include" {
+		if name != "extend" && name != "include" && name != prologFunc && name != varDirective {
 			funcMap[name] = fn
 		}
 	}
 
-	return &TemplateEngine{
+	exts := opts.Extensions
+	if len(exts) == 0 {
+		exts = defaultExtensions
+	}
+
+	ctype := opts.ContentType
+	if ctype == "" {
+		ctype = defaultContentType(opts.Backend)
+	}
+
+	e := &TemplateEngine{
 		srcs:      opts.Sources,
 		cache:     make(map[string]*template.Template),
 		loadCache: make(map[string]*template.Template),
+		pageVars:  make(map[string]PageVars),
+		parents:   make(map[string]string),
+		deps:      make(dependencyGraph),
 		inclCache: make(map[string]*inclCache),
 		funcMap:   funcMap,
 		logger:    logger,
+		strict:    opts.StrictBlocks,
+		unknown:   opts.UnknownFuncs,
+		devMode:   opts.DevMode,
+		backend:   opts.Backend,
+		text:      make(map[string]*texttemplate.Template),
+		exts:      exts,
+		ctype:     ctype,
+		etag:      opts.ETag,
+		digests:   make(map[string]string),
+
+		renderFuncs:   make(map[string]renderFunc),
+		bases:         make(map[string]executor),
+		pools:         make(map[string]*sync.Pool),
+		missing:       make(map[string]bool),
+		locales:       opts.Locales,
+		localeParam:   opts.LocaleQueryParam,
+		localeCookie:  opts.LocaleCookie,
+		buffer:        opts.BufferOutput,
+		trace:         opts.Trace,
+		logDefault:    opts.LogLevel,
+		logLevels:     opts.LogLevels,
+		transforms:    opts.Transforms,
+		filterSyntax:  opts.FilterSyntax,
+		converters:    opts.Converters,
+		beforeReload:  opts.BeforeReload,
+		afterReload:   opts.AfterReload,
+		criticalCSS:   opts.CriticalCSS,
+		strictCSP:     opts.StrictCSP,
+		outputChecks:  opts.OutputChecks,
+		layoutFor:     opts.LayoutFor,
+		flags:         opts.FlagProvider,
+		env:           opts.Env,
+		envs:          opts.Envs,
+		cacheEvents:   opts.CacheEvents,
+		fallback:      opts.FallbackTemplate,
+		onRenderError: opts.OnRenderError,
+		limits:        opts.Limits,
+		autoLangDir:   opts.AutoLangDir,
+		processors:    opts.PostProcessors,
+		watchInterval: opts.WatchInterval,
+		noCache:       opts.DisableCache,
+		lazy:          opts.LazyLoad,
+		loadWorkers:   opts.LoadConcurrency,
+	}
+	e.setupLocales(opts)
+	e.setupHumanize(opts)
+	e.setupMoney(opts)
+	e.setupIslands(opts.Islands)
+	e.setupAssets(opts)
+	e.setupDirection(opts)
+	e.setupFlags(opts)
+	e.setupHighlight(opts)
+	e.setupStore(opts)
+	e.setupServices(opts)
+	e.setupAnnotations()
+	e.setupLimits()
+	e.setupMemo(opts)
+	for name, d := range opts.Directives {
+		if err := e.RegisterDirective(name, d); err != nil {
+			e.logf(LogLoad, LogError, "[TMPLX] %v", err)
+		}
 	}
+
+	return e
+}
+
+// derive returns an unloaded engine with the same sources, funcs and settings
+// as e
+func (e *TemplateEngine) derive() *TemplateEngine {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	fresh := &TemplateEngine{
+		srcs:      append([]Source(nil), e.srcs...),
+		cache:     make(map[string]*template.Template),
+		loadCache: make(map[string]*template.Template),
+		pageVars:  make(map[string]PageVars),
+		parents:   make(map[string]string),
+		deps:      make(dependencyGraph),
+		inclCache: make(map[string]*inclCache),
+		funcMap:   e.funcMapCopy(),
+		logger:    e.logger,
+		strict:    e.strict,
+		unknown:   e.unknown,
+		devMode:   e.devMode,
+		backend:   e.backend,
+		text:      make(map[string]*texttemplate.Template),
+		exts:      e.exts,
+		ctype:     e.ctype,
+		etag:      e.etag,
+		digests:   make(map[string]string),
+
+		renderFuncs:   e.renderFuncs,
+		bases:         make(map[string]executor),
+		pools:         make(map[string]*sync.Pool),
+		missing:       make(map[string]bool),
+		locales:       e.locales,
+		defaultLocale: e.defaultLocale,
+		localeParam:   e.localeParam,
+		localeCookie:  e.localeCookie,
+		buffer:        e.buffer,
+		trace:         e.trace,
+		logDefault:    e.logDefault,
+		logLevels:     e.logLevels,
+		instrument:    e.instrument,
+		directives:    e.directives,
+		transforms:    e.transforms,
+		filterSyntax:  e.filterSyntax,
+		converters:    e.converters,
+		beforeReload:  e.beforeReload,
+		afterReload:   e.afterReload,
+		islands:       e.islands,
+		criticalCSS:   e.criticalCSS,
+		strictCSP:     e.strictCSP,
+		outputChecks:  e.outputChecks,
+		layoutFor:     e.layoutFor,
+		flags:         e.flags,
+		env:           e.env,
+		envs:          e.envs,
+		cacheEvents:   e.cacheEvents,
+		fallback:      e.fallback,
+		onRenderError: e.onRenderError,
+		limits:        e.limits,
+		memoized:      e.memoized,
+		services:      e.services,
+		autoLangDir:   e.autoLangDir,
+		processors:    e.processors,
+		watchInterval: e.watchInterval,
+		noCache:       e.noCache,
+		lazy:          e.lazy,
+		loadWorkers:   e.loadWorkers,
+		assets:        e.assets,
+		version:       e.version,
+	}
+	fresh.contextual.Store(e.contextual.Load())
+	return fresh
 }
 
 // Load loads all templates from the filesystem into memory.
 // This must be called before using the engine for rendering.
-// It will parse all .html files and resolve template inheritance.
+// It will parse all template files (.html by default) and resolve template inheritance.
 func (e *TemplateEngine) Load() error {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.load()
+}
+
+func (e *TemplateEngine) load() error {
 	if e.loaded {
 		return nil
 	}
 
-	if err := e.LoadTemplates(); err != nil {
-		return fmt.Errorf("failed to load templates: %v", err)
+	if err := e.loadTemplates(); err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	if e.fallback != "" {
+		if e.lazy {
+			if err := e.compile(e.fallback); err != nil {
+				return fmt.Errorf("failed to load templates: %w", err)
+			}
+		}
+		if _, ok := e.lookup(e.fallback); !ok {
+			return withKind(ErrTemplateNotFound, fmt.Errorf("failed to load templates: fallback template %s not found", e.fallback))
+		}
+	}
+
+	if e.devMode && e.backend == HTMLBackend {
+		e.logEscapeWarnings()
 	}
 
 	e.loaded = true
@@ -197,37 +735,90 @@ func NewTemplateEngine(root string) (*TemplateEngine, error) {
 // AddFuncs adds custom functions to the template engine's function map.
 // This will trigger a reload of all templates since the functions might be used in them.
 func (e *TemplateEngine) AddFuncs(funcMap template.FuncMap) error {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	defer e.dropVersions()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	// Add all functions to the engine's funcMap
 	for name, fn := range funcMap {
-		e.funcMap[name] = fn
+		e.addFunc(name, fn)
 	}
 
-	// Need to reload templates since functions might be used in them
-	return e.LoadTemplates()
+	// Need to reload templates since functions might be used in them. The
+	// resolved layouts and includes were parsed without them and may have
+	// been executed already, so resolve everything again.
+	e.loadCache = make(map[string]*template.Template)
+	e.inclCache = make(map[string]*inclCache)
+	e.deps = make(dependencyGraph)
+	return e.loadTemplates()
+}
+
+// preprocess rewrites the raw content of a template file before it is
+// parsed: load-time conditionals, filter syntax, SVG prologs, then
+// directives
+func (e *TemplateEngine) preprocess(s Source, file, content string) (string, error) {
+	content, err := e.conditionals(file, content)
+	if err != nil {
+		return "", err
+	}
+	content, err = e.filters(file, content)
+	if err != nil {
+		return "", err
+	}
+	return e.expandDirectives(s, file, e.langAttrs(file, svgProlog(file, content)))
 }
 
 func (e *TemplateEngine) parseTemplateFile(s Source, path string) (*templateTree, error) {
 
-	content, err := fs.ReadFile(s.FS, path)
+	raw, err := e.readTemplate(s, path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := e.preprocess(s, path, string(raw))
+	if err != nil {
+		return nil, err
+	}
+	content, vars, err := extractPageVars(path, content)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := filepath.Rel(s.Dir, path)
 	if err != nil {
 		return nil, err
 	}
+	name = filepath.ToSlash(name)
+	cfg, err := e.dirConfigFor(s, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, include := range cfg.includes {
+		if include != name {
+			content += fmt.Sprintf("{{include %q .}}", include)
+		}
+	}
+
+	e.stubUnknownFuncs(path, content)
 
 	tree := &templateTree{
 		name:     filepath.Base(path),
-		content:  string(content),
+		content:  content,
 		blocks:   make(map[string]string),
 		includes: []string{},
+		vars:     vars,
 	}
 
 	// First do a pre-parse scan for extend directive
 	scanner := template.New("").Funcs(e.funcMap)
-	parsed, err := scanner.Parse(string(content))
+	parsed, err := scanner.Parse(content)
 	if err != nil {
-		return nil, fmt.Errorf("error scanning template %s: %v", path, err)
+		return nil, newTemplateError(name, err, fmt.Errorf("error scanning template %s: %v", path, err))
 	}
 
 	// Extract extends directive
+	extended := false
 	for _, node := range parsed.Tree.Root.Nodes {
 		if action, ok := node.(*parse.ActionNode); ok {
 			if len(action.Pipe.Cmds) > 0 {
@@ -239,6 +830,7 @@ func (e *TemplateEngine) parseTemplateFile(s Source, path string) (*templateTree
 							if len(cmd.Args) != 2 {
 								return nil, fmt.Errorf("extend requires exactly one argument")
 							}
+							extended = true
 							if str, ok := cmd.Args[1].(*parse.StringNode); ok {
 								tree.extends = str.Text
 								tree.content = strings.Replace(tree.content, node.String(), "", 1)
@@ -257,6 +849,10 @@ func (e *TemplateEngine) parseTemplateFile(s Source, path string) (*templateTree
 		}
 	}
 
+	if !extended {
+		tree.extends = e.defaultLayout(name, cfg)
+	}
+
 	// Now create template without extend function
 	tmpl := template.New(tree.name).Funcs(e.funcMapWithFuncs(template.FuncMap{
 		"block":   func(string, interface{}) (string, error) { return "", nil },
@@ -266,12 +862,40 @@ func (e *TemplateEngine) parseTemplateFile(s Source, path string) (*templateTree
 	// Parse the content after extend directive has been removed
 	_, err = tmpl.Parse(tree.content)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing template %s: %v", path, err)
+		return nil, newTemplateError(name, err, fmt.Errorf("error parsing template %s: %v", path, err))
 	}
 
 	return tree, nil
 }
 
+var undefinedFuncRe = regexp.MustCompile(`function "([^"]+)" not defined`)
+
+// stubUnknownFuncs registers a no-op for every undefined function used in
+// content when the engine is configured with UnknownFuncIgnore.
+func (e *TemplateEngine) stubUnknownFuncs(name, content string) {
+	if e.unknown != UnknownFuncIgnore {
+		return
+	}
+
+	for {
+		_, err := template.New(name).Funcs(e.funcMap).Parse(content)
+		if err == nil {
+			return
+		}
+
+		m := undefinedFuncRe.FindStringSubmatch(err.Error())
+		if m == nil {
+			return
+		}
+		if _, exists := e.funcMap[m[1]]; exists {
+			return
+		}
+
+		e.logf(LogLoad, LogWarn, "[TMPLX] Warning: function %q used in %s is not defined, rendering it as empty", m[1], name)
+		e.funcMap[m[1]] = func(...interface{}) string { return "" }
+	}
+}
+
 func (e *TemplateEngine) funcMapCopy() template.FuncMap {
 	funcMap := make(template.FuncMap)
 	for k, v := range e.funcMap {
@@ -288,18 +912,24 @@ func (e *TemplateEngine) funcMapWithFuncs(funcs template.FuncMap) template.FuncM
 	return funcMap
 }
 
-func (e *TemplateEngine) resolveInheritance(s Source, name string, visited map[string]bool) (*template.Template, error) {
-	if visited[name] {
-		return nil, fmt.Errorf("circular template inheritance detected for %s", name)
+// resolveInheritance resolves the named template and the layouts it extends.
+// chain lists the templates extending it, to report cycles in full.
+func (e *TemplateEngine) resolveInheritance(s Source, name string, chain []string) (*template.Template, error) {
+	chain = append(chain[:len(chain):len(chain)], name)
+	if containsString(chain[:len(chain)-1], name) {
+		return nil, withKind(ErrCircularInheritance, fmt.Errorf("circular template inheritance detected: %s", strings.Join(chain, " -> ")))
 	}
-	visited[name] = true
 
-	if tmpl, ok := e.loadCache[name]; ok {
-		e.logger.Infof("[TMPLX] Returning cached inheritance for %s", name)
+	e.resolveMu.Lock()
+	tmpl, ok := e.loadCache[name]
+	e.resolveMu.Unlock()
+	e.cacheLookup(name, CacheInheritance, ok)
+	if ok {
+		e.logf(LogCache, LogDebug, "[TMPLX] Returning cached inheritance for %s", name)
 		return tmpl, nil
 	}
 
-	e.logger.Infof("[TMPLX] Resolving inheritance for %s", name)
+	e.logf(LogInheritance, LogDebug, "[TMPLX] Resolving inheritance for %s", name)
 
 	currentPath := filepath.Join(s.Dir, name)
 	tree, err := e.parseTemplateFile(s, currentPath)
@@ -312,9 +942,12 @@ func (e *TemplateEngine) resolveInheritance(s Source, name string, visited map[s
 		parentPath := tree.extends
 
 		// Resolve the parent template first
-		parentTemplate, err := e.resolveInheritance(s, parentPath, visited)
+		parentTemplate, err := e.resolveInheritance(s, parentPath, chain)
 		if err != nil {
-			return nil, fmt.Errorf("error resolving parent template %s: %v", parentPath, err)
+			if errors.Is(err, fs.ErrNotExist) {
+				err = withKind(ErrTemplateNotFound, err)
+			}
+			return nil, leadsTo(name, fmt.Errorf("error resolving parent template %s: %w", parentPath, err))
 		}
 
 		// Create new template with the current name and funcs
@@ -325,6 +958,8 @@ func (e *TemplateEngine) resolveInheritance(s Source, name string, visited map[s
 		if err != nil {
 			return nil, fmt.Errorf("error parsing parent content: %v", err)
 		}
+		// the page's own content is that of the outermost layout
+		baseTemplate.Tree.ParseName = parentTemplate.Tree.ParseName
 
 		// Copy all associated templates from parent
 		if err := e.copyTemplates(baseTemplate, parentTemplate); err != nil {
@@ -335,17 +970,18 @@ func (e *TemplateEngine) resolveInheritance(s Source, name string, visited map[s
 
 		// Process includes in the current content
 		currentContent := removeExtendDirective(tree.content)
-		processedContent, includeTmpl, err := e.processIncludes(s, currentContent, name, make(map[string]bool))
+		processedContent, includeTmpl, lines, err := e.processIncludes(s, currentContent, []string{name})
 		if err != nil {
-			return nil, fmt.Errorf("error processing includes: %v", err)
+			return nil, leadsTo(name, fmt.Errorf("error processing includes: %w", err))
 		}
 
 		// Create temporary template to parse child content
 		childTemplate := template.New("temp").Funcs(e.funcMap)
 		_, err = childTemplate.Parse(processedContent)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing child template %s: %v", name, err)
+			return nil, newTemplateError(name, err, fmt.Errorf("error parsing child template %s: %v", name, err)).locate(lines)
 		}
+		setTreeSources(childTemplate, name, treeSources(includeTmpl))
 
 		// Copy all block definitions from includes
 		if includeTmpl != nil {
@@ -355,6 +991,12 @@ func (e *TemplateEngine) resolveInheritance(s Source, name string, visited map[s
 			}
 		}
 
+		if e.strict {
+			if err := checkBlocksDefined(name, parentPath, parentTemplate, childTemplate); err != nil {
+				return nil, err
+			}
+		}
+
 		// Only copy the block definitions from child
 		err = e.copyBlockTemplates(baseTemplate, childTemplate)
 		if err != nil {
@@ -364,18 +1006,24 @@ func (e *TemplateEngine) resolveInheritance(s Source, name string, visited map[s
 		//DebugTemplate(baseTemplate)
 
 		_ = baseTemplate
+		e.resolveMu.Lock()
 		e.loadCache[name] = baseTemplate
+		e.parents[name] = parentPath
+		e.deps.add(name, parentPath)
+		e.setPageVars(name, inheritPageVars(e.pageVars[parentPath], tree.vars))
+		e.resolveMu.Unlock()
+		e.cacheLoaded(name, CacheInheritance)
 		return baseTemplate, nil
 
 	}
 
 	// For base templates
-	baseTemplate := template.New(tree.name).Funcs(e.funcMap)
+	baseTemplate := template.New(tree.name)
 
 	// Process includes first
-	processedContent, includeTmpl, err := e.processIncludes(s, tree.content, name, make(map[string]bool))
+	processedContent, includeTmpl, lines, err := e.processIncludes(s, tree.content, []string{name})
 	if err != nil {
-		return nil, fmt.Errorf("error processing includes: %v", err)
+		return nil, leadsTo(name, fmt.Errorf("error processing includes: %w", err))
 	}
 
 	// Copy block definitions from includes first
@@ -388,15 +1036,20 @@ func (e *TemplateEngine) resolveInheritance(s Source, name string, visited map[s
 
 	// Parse the current template's content - this will define/override blocks
 	// First remove any extend directive from the current template
-	_, err = baseTemplate.Parse(processedContent)
+	_, err = baseTemplate.Funcs(e.funcMap).Parse(processedContent)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing template %s: %v", name, err)
+		return nil, newTemplateError(name, err, fmt.Errorf("error parsing template %s: %v", name, err)).locate(lines)
 	}
+	setTreeSources(baseTemplate, name, treeSources(includeTmpl))
 
 	//DebugTemplate(baseTemplate)
 	_ = baseTemplate
 
+	e.resolveMu.Lock()
 	e.loadCache[name] = baseTemplate
+	e.setPageVars(name, tree.vars)
+	e.resolveMu.Unlock()
+	e.cacheLoaded(name, CacheInheritance)
 	return baseTemplate, nil
 }
 
@@ -412,6 +1065,92 @@ func (e *TemplateEngine) copyBlockTemplates(baseTemplate *template.Template, chi
 	return nil
 }
 
+// checkBlocksDefined verifies that every block defined by a child template either
+// exists in the parent chain or is invoked by one of the child's own blocks.
+func checkBlocksDefined(name, parentPath string, parentTemplate, childTemplate *template.Template) error {
+	referenced := make(map[string]bool)
+	for _, t := range childTemplate.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		for _, ref := range templateRefs(t.Tree.Root) {
+			referenced[ref] = true
+		}
+	}
+
+	for _, t := range childTemplate.Templates() {
+		if t.Name() == "temp" || referenced[t.Name()] {
+			continue
+		}
+		if parentTemplate.Lookup(t.Name()) == nil {
+			return fmt.Errorf("block %q defined in %s does not exist in its layout chain (%s)", t.Name(), name, parentPath)
+		}
+	}
+	return nil
+}
+
+// templateRefs returns the names of all templates invoked via {{template}}
+// (and therefore {{block}}) actions below the given node.
+func templateRefs(node parse.Node) []string {
+	var refs []string
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.TemplateNode:
+			refs = append(refs, n.Name)
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		}
+	}
+	walk(node)
+	return refs
+}
+
+// setTreeSources records the file each block of tmpl was defined in as the
+// ParseName of its tree, which also makes execution errors point at that file.
+// Blocks listed in sources (those that came in through includes) keep the
+// file recorded there.
+func setTreeSources(tmpl *template.Template, file string, sources map[string]string) {
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		if source, ok := sources[t.Name()]; ok && source != "" {
+			t.Tree.ParseName = source
+		} else {
+			t.Tree.ParseName = file
+		}
+	}
+}
+
+// treeSources maps the blocks of tmpl to the files they were defined in
+func treeSources(tmpl *template.Template) map[string]string {
+	sources := make(map[string]string)
+	if tmpl == nil {
+		return sources
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && t.Name() != "" {
+			sources[t.Name()] = t.Tree.ParseName
+		}
+	}
+	return sources
+}
+
 func (e *TemplateEngine) copyTemplates(baseTemplate *template.Template, includeTmpl *template.Template) error {
 	for _, t := range includeTmpl.Templates() {
 		if t.Name() != "" && t.Name() != includeTmpl.Name() {
@@ -424,26 +1163,35 @@ func (e *TemplateEngine) copyTemplates(baseTemplate *template.Template, includeT
 	return nil
 }
 
-func (e *TemplateEngine) processIncludes(s Source, content string, currentFile string, visited map[string]bool) (string, *template.Template, error) {
-	if tmpl, ok := e.inclCache[currentFile]; ok {
-		e.logger.Infof("[TMPLX] Returning cached include file %s", currentFile)
-		return tmpl.content, tmpl.tmpl, nil
+// processIncludes inlines the includes of content, the content of the last
+// file of chain, which lists the files including it first. It returns the
+// map of the lines of the result back to the files they came from.
+func (e *TemplateEngine) processIncludes(s Source, content string, chain []string) (string, *template.Template, lineMap, error) {
+	currentFile := chain[len(chain)-1]
+	e.resolveMu.Lock()
+	cached, ok := e.inclCache[currentFile]
+	e.resolveMu.Unlock()
+	e.cacheLookup(currentFile, CacheIncludes, ok)
+	if ok {
+		e.logf(LogCache, LogDebug, "[TMPLX] Returning cached include file %s", currentFile)
+		return cached.content, cached.tmpl, cached.lines, nil
 	}
 
-	e.logger.Infof("[TMPLX] Processing include file %s", currentFile)
+	e.logf(LogIncludes, LogDebug, "[TMPLX] Processing include file %s", currentFile)
 
 	// Create initial template for collecting block definitions
-	collectingTmpl := template.New("").Funcs(e.funcMap)
+	collectingTmpl := template.New("")
 
 	// Parse template to find includes
 	tmpl := template.New("").Funcs(e.funcMap)
 
 	parsed, err := tmpl.Parse(content)
 	if err != nil {
-		return "", nil, fmt.Errorf("error parsing template for includes: %v", err)
+		return "", nil, nil, newTemplateError(currentFile, err, fmt.Errorf("error parsing template for includes: %v", err))
 	}
 
 	processed := content
+	lines := newLineMap(currentFile, content)
 
 	// Find all include nodes and process them
 	for _, node := range parsed.Tree.Root.Nodes {
@@ -453,31 +1201,36 @@ func (e *TemplateEngine) processIncludes(s Source, content string, currentFile s
 				if len(cmd.Args) > 0 {
 					if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "include" {
 						if len(cmd.Args) < 2 {
-							return "", nil, fmt.Errorf("include requires a template name")
+							return "", nil, nil, fmt.Errorf("include requires a template name")
 						}
 						if str, ok := cmd.Args[1].(*parse.StringNode); ok {
 							includePath := str.Text
-							if visited[includePath] {
-								return "", nil, fmt.Errorf("circular include detected: %s", includePath)
+							e.resolveMu.Lock()
+							e.deps.add(currentFile, includePath)
+							e.resolveMu.Unlock()
+							includeChain := append(chain[:len(chain):len(chain)], includePath)
+							if containsString(chain, includePath) {
+								return "", nil, nil, withKind(ErrCircularInheritance, fmt.Errorf("circular include detected: %s", strings.Join(includeChain, " -> ")))
 							}
 
 							// Read the included template
 							includeFullPath := filepath.Join(s.Dir, includePath)
-							includeContent, err := fs.ReadFile(s.FS, includeFullPath)
+							rawInclude, err := e.readTemplate(s, includeFullPath)
+							if errors.Is(err, fs.ErrNotExist) {
+								return "", nil, nil, withKind(ErrIncludeNotFound, fmt.Errorf("error reading include %s: %w", includePath, err))
+							} else if err != nil {
+								return "", nil, nil, fmt.Errorf("error reading include %s: %w", includePath, err)
+							}
+							includeContent, err := e.preprocess(s, includePath, string(rawInclude))
 							if err != nil {
-								return "", nil, fmt.Errorf("error reading include %s: %v", includePath, err)
+								return "", nil, nil, err
 							}
+							e.stubUnknownFuncs(includePath, includeContent)
 
 							// Process nested includes
-							visitedCopy := make(map[string]bool)
-							for k, v := range visited {
-								visitedCopy[k] = v
-							}
-							visitedCopy[includePath] = true
-
-							processedInclude, includeTmpl, err := e.processIncludes(s, string(includeContent), includePath, visitedCopy)
+							processedInclude, includeTmpl, includeLines, err := e.processIncludes(s, includeContent, includeChain)
 							if err != nil {
-								return "", nil, fmt.Errorf("error processing nested includes in %s: %v", includePath, err)
+								return "", nil, nil, leadsTo(currentFile, fmt.Errorf("error processing nested includes in %s: %w", includePath, err))
 							}
 
 							// Copy any block definitions from the included template
@@ -486,14 +1239,20 @@ func (e *TemplateEngine) processIncludes(s Source, content string, currentFile s
 									if t.Name() != "" && t.Name() != includeTmpl.Name() {
 										_, err = collectingTmpl.AddParseTree(t.Name(), t.Tree)
 										if err != nil {
-											return "", nil, fmt.Errorf("error copying template %s: %v", t.Name(), err)
+											return "", nil, nil, fmt.Errorf("error copying template %s: %v", t.Name(), err)
 										}
 									}
 								}
 							}
 
 							// Replace the include directive with the actual content
-							processed = strings.Replace(processed, node.String(), processedInclude, 1)
+							directive := node.String()
+							if i := strings.Index(processed, directive); i >= 0 {
+								spliced := e.annotateInclude(includePath, processedInclude)
+								line := strings.Count(processed[:i], "\n") + 1
+								lines = lines.splice(line, strings.Count(directive, "\n"), strings.Count(spliced, "\n"), includeLines)
+								processed = processed[:i] + spliced + processed[i+len(directive):]
+							}
 						}
 					}
 				}
@@ -501,18 +1260,25 @@ func (e *TemplateEngine) processIncludes(s Source, content string, currentFile s
 		}
 	}
 
-	// Parse the processed content to get any block definitions
-	_, err = collectingTmpl.Parse(processed)
+	// Parse the processed content to get any block definitions. Funcs are
+	// attached late so stubs registered while reading nested includes are visible.
+	sources := treeSources(collectingTmpl)
+	_, err = collectingTmpl.Funcs(e.funcMap).Parse(processed)
 	if err != nil {
-		return "", nil, fmt.Errorf("error parsing processed content: %v", err)
+		return "", nil, nil, newTemplateError(currentFile, err, fmt.Errorf("error parsing processed content: %v", err)).locate(lines)
 	}
+	setTreeSources(collectingTmpl, currentFile, sources)
 
+	e.resolveMu.Lock()
 	e.inclCache[currentFile] = &inclCache{
 		content: processed,
 		tmpl:    collectingTmpl,
+		lines:   lines,
 	}
+	e.resolveMu.Unlock()
+	e.cacheLoaded(currentFile, CacheIncludes)
 
-	return processed, collectingTmpl, nil
+	return processed, collectingTmpl, lines, nil
 }
 
 // Helper function to remove extend directive
@@ -527,46 +1293,93 @@ func removeExtendDirective(content string) string {
 }
 
 func (e *TemplateEngine) LoadTemplates() error {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	defer e.dropVersions()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.loadTemplates()
+}
+
+func (e *TemplateEngine) loadTemplates() error {
+	if err := e.readLoaders(); err != nil {
+		return err
+	}
+	if e.lazy {
+		e.forget()
+		return nil
+	}
 	for i, s := range e.srcs {
 		if err := e.loadTemplatesForSource(s); err != nil {
-			return fmt.Errorf("error loading templates from source %d: %v", i, err)
+			return fmt.Errorf("error loading templates from source %d: %w", i, err)
 		}
 	}
 	return nil
 }
 
 func (e *TemplateEngine) loadTemplatesForSource(s Source) error {
-	e.logger.Infof("[TMPLX] Loading templates")
-	return fs.WalkDir(s.FS, s.Dir, func(path string, d fs.DirEntry, err error) error {
+	e.logf(LogLoad, LogInfo, "[TMPLX] Loading templates")
+	e.dirConfigs = make(map[string]*dirConfig)
+	defer func() { e.dirConfigs = nil }()
+	names, err := e.templateFiles(s)
+	if err != nil {
+		return err
+	}
+	return e.resolveAll(s, names)
+}
+
+// templateFiles returns the names of the templates to load from s
+func (e *TemplateEngine) templateFiles(s Source) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(s.FS, s.Dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if d.IsDir() || !strings.HasSuffix(path, ".html") {
+		if d.IsDir() {
 			return nil
 		}
-
-		relPath, err := filepath.Rel(s.Dir, path)
-		if err != nil {
+		if ok, err := e.loadable(s, path); err != nil || !ok {
 			return err
 		}
 
-		// Resolve template inheritance
-		e.logger.Infof("[TMPLX] Processing %s", relPath)
-		tmpl, err := e.resolveInheritance(s, relPath, make(map[string]bool))
+		relPath, err := filepath.Rel(s.Dir, path)
 		if err != nil {
-			return fmt.Errorf("error resolving inheritance for %s: %v", relPath, err)
+			return err
 		}
-
-		e.cache[relPath] = tmpl
+		names = append(names, relPath)
 		return nil
 	})
+	return names, err
 }
 
+// resolveFile resolves the inheritance of a template file and stores it
+func (e *TemplateEngine) resolveFile(s Source, name string) error {
+	e.logf(LogLoad, LogDebug, "[TMPLX] Processing %s", name)
+	tmpl, err := e.resolveInheritance(s, name, nil)
+	if err != nil {
+		return leadsTo(name, fmt.Errorf("error resolving inheritance for %s: %w", name, err))
+	}
+	return e.store(name, tmpl)
+}
+
+// GetTemplate returns the resolved template for name. With TextBackend this is
+// the html/template the text/template executor was built from.
 func (e *TemplateEngine) GetTemplate(name string) (*template.Template, error) {
+	if e.lazy {
+		if err := e.loadLazily(name); err != nil {
+			return nil, err
+		}
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.getTemplate(name)
+}
+
+func (e *TemplateEngine) getTemplate(name string) (*template.Template, error) {
 	tmpl, exists := e.cache[name]
 	if !exists {
-		return nil, fmt.Errorf("template %s not found", name)
+		return nil, notFound(name)
 	}
 	return tmpl, nil
 }
@@ -579,47 +1392,136 @@ func (e *TemplateEngine) MustGetTemplate(name string) *template.Template {
 	return tmpl
 }
 
-func (e *TemplateEngine) renderTo(w io.Writer, name string, data interface{}) error {
-	tmpl, exists := e.cache[name]
+func (e *TemplateEngine) renderTo(w io.Writer, name string, data interface{}, cfg renderConfig) error {
+	e, err := e.versioned(&cfg)
+	if err != nil {
+		return err
+	}
+	name = e.localeVariant(name, cfg.locale)
+	if e.noCache {
+		if err := e.refresh(name); err != nil {
+			return fmt.Errorf("error refreshing template %s: %w", name, err)
+		}
+	} else if e.lazy {
+		if err := e.loadLazily(name); err != nil {
+			return err
+		}
+	}
+	_, exists := e.executor(name)
+	e.cacheLookup(name, CacheTemplates, exists)
 	if !exists {
-		return fmt.Errorf("template %s not found", name)
+		return notFound(name)
+	}
+
+	fallback := e.fallbackFor(name, cfg)
+	if fallback != "" && e.noCache {
+		if err := e.refresh(fallback); err != nil {
+			return fmt.Errorf("error refreshing template %s: %w", fallback, err)
+		}
+	}
+	if fallback == "" {
+		err := e.renderPage(w, name, data, cfg)
+		if err != nil {
+			e.renderFailed(name, err)
+		}
+		return err
+	}
+
+	// Render into memory, so a failure midway can still be replaced
+	var buf bytes.Buffer
+	if err := e.renderPage(&buf, name, data, cfg); err != nil {
+		e.renderFailed(name, err)
+		e.logf(LogRender, LogError, "[TMPLX] Rendering fallback %s in place of %s: %v", fallback, name, err)
+		if ferr := e.renderPage(w, fallback, data, cfg); ferr != nil {
+			return fmt.Errorf("%w (fallback: %w)", err, ferr)
+		}
+		return nil
 	}
+	_, err = buf.WriteTo(w)
+	return err
+}
 
-	// Execute the root template
-	err := tmpl.Execute(w, data)
+// renderPage executes a template, post-processing its output if needed
+func (e *TemplateEngine) renderPage(w io.Writer, name string, data interface{}, cfg renderConfig) error {
+	e.logf(LogRender, LogDebug, "[TMPLX] Rendering %s", name)
+	processors := e.postProcessors(cfg)
+	html := e.htmlProcessed(name)
+	if html || len(processors) > 0 {
+		checks := html && e.devMode && len(e.outputChecks) > 0
+		if checks && cfg.trace == nil {
+			cfg.trace = &RenderTrace{}
+		}
+		var buf bytes.Buffer
+		if err := e.execute(&buf, name, data, cfg); err != nil {
+			return e.renderError(name, err, fmt.Errorf("error rendering template %s: %w", name, err))
+		}
+		out := buf.Bytes()
+		if checks {
+			e.logOutputProblems(name, buf.String(), cfg.trace)
+		}
+		out, err := postProcess(processors, name, out)
+		if err != nil {
+			return err
+		}
+		// audited before the engine adds markup of its own
+		if html && e.strictCSP != CSPOff {
+			if err := e.auditCSP(name, out); err != nil {
+				return fmt.Errorf("error rendering template %s: %v", name, err)
+			}
+		}
+		if html && e.criticalCSS != nil {
+			if out, err = e.inlineCriticalCSS(out); err != nil {
+				return fmt.Errorf("error rendering template %s: %v", name, err)
+			}
+		}
+		_, err = w.Write(out)
+		return err
+	}
+	err := e.execute(w, name, data, cfg)
 	if err != nil {
-		return fmt.Errorf("error rendering template %s: %v", name, err)
+		return e.renderError(name, err, fmt.Errorf("error rendering template %s: %w", name, err))
 	}
 
 	return nil
 }
 
-func (e *TemplateEngine) Render(name string, data interface{}) (string, error) {
+// htmlProcessed reports whether rendered output of the named template is
+// buffered to be checked or rewritten by the engine's HTML features before
+// it is written. Post-processors buffer the output of every template.
+func (e *TemplateEngine) htmlProcessed(name string) bool {
+	if e.criticalCSS == nil && e.strictCSP == CSPOff && !(e.devMode && len(e.outputChecks) > 0) {
+		return false
+	}
+	return strings.HasPrefix(e.contentType(name), "text/html")
+}
+
+func (e *TemplateEngine) Render(name string, data interface{}, opts ...RenderOption) (string, error) {
 	var buf strings.Builder
-	err := e.renderTo(&buf, name, data)
+	err := e.renderTo(&buf, name, data, newRenderConfig(opts))
 	if err != nil {
 		return "", err
 	}
 	return buf.String(), nil
 }
 
-func (e *TemplateEngine) RenderResponse(w io.Writer, name string, data interface{}) error {
-	return e.renderTo(w, name, data)
+// RenderResponse streams the rendered template directly to w. If execution
+// fails part way through, w will already have received partial output,
+// unless the engine was created with BufferOutput.
+func (e *TemplateEngine) RenderResponse(w io.Writer, name string, data interface{}, opts ...RenderOption) error {
+	if e.buffer {
+		return e.RenderResponseBuffered(w, name, data, opts...)
+	}
+	return e.renderTo(w, name, data, newRenderConfig(opts))
 }
 
-func DebugTemplate(t *template.Template) string {
-	var b strings.Builder
-	b.WriteString(fmt.Sprintf("Template %q:\n", t.Name()))
-
-	// List all associated templates
-	templates := t.Templates()
-	for _, tmpl := range templates {
-		b.WriteString(fmt.Sprintf("  - %q:\n", tmpl.Name()))
-		if tmpl.Tree != nil && tmpl.Tree.Root != nil {
-			b.WriteString(fmt.Sprintf("    Content: %s\n", tmpl.Tree.Root.String()))
-		}
+// RenderResponseBuffered renders the template into memory and only writes it
+// to w once execution has succeeded, so a failing render never sends a
+// partial page. This costs one buffer the size of the output.
+func (e *TemplateEngine) RenderResponseBuffered(w io.Writer, name string, data interface{}, opts ...RenderOption) error {
+	var buf bytes.Buffer
+	if err := e.renderTo(&buf, name, data, newRenderConfig(opts)); err != nil {
+		return err
 	}
-
-	fmt.Println(b.String())
-	return b.String()
+	_, err := buf.WriteTo(w)
+	return err
 }
@@ -916,3 +916,185 @@ func containsAll(t *testing.T, expectedParts []string, result string) {
 		}
 	}
 }
+
+func TestStrictBlocks(t *testing.T) {
+	tempDir, cleanup := setupTestTemplates(t)
+	defer cleanup()
+
+	writeTemplate(t, tempDir, "layouts/base.html", `<main>{{block "content" .}}Default{{end}}</main>`)
+	writeTemplate(t, tempDir, "pages/nested.html", `{{extend "layouts/base.html"}}
+{{define "content"}}{{block "subcontent" .}}Sub{{end}}{{end}}`)
+
+	t.Run("Known and nested blocks load", func(t *testing.T) {
+		engine := New(Options{Dir: tempDir, StrictBlocks: true})
+		if err := engine.Load(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	writeTemplate(t, tempDir, "pages/typo.html", `{{extend "layouts/base.html"}}
+{{define "contnet"}}Typo{{end}}`)
+
+	t.Run("Unknown block fails in strict mode", func(t *testing.T) {
+		engine := New(Options{Dir: tempDir, StrictBlocks: true})
+		err := engine.Load()
+		if err == nil || !strings.Contains(err.Error(), `"contnet"`) {
+			t.Fatalf("Expected strict block error, got %v", err)
+		}
+	})
+
+	t.Run("Unknown block is ignored by default", func(t *testing.T) {
+		engine := New(Options{Dir: tempDir})
+		if err := engine.Load(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestUnknownFuncs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{
+			Data: []byte(`<main>{{block "content" .}}{{legacyHelper .Title}}{{end}}</main>`),
+		},
+		"pages/home.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/base.html"}}
+{{define "content"}}<h1>{{.Title}}</h1>{{end}}`),
+		},
+		"pages/about.html": &fstest.MapFile{
+			Data: []byte(`{{include "partials/nav.html" .}}`),
+		},
+		"partials/nav.html": &fstest.MapFile{
+			Data: []byte(`<nav>{{navLinks .}}</nav>`),
+		},
+	}
+
+	t.Run("Unknown funcs fail by default", func(t *testing.T) {
+		engine := New(Options{FS: fsys})
+		if err := engine.Load(); err == nil {
+			t.Fatal("Expected error for unknown function, got nil")
+		}
+	})
+
+	t.Run("Unknown funcs render empty when ignored", func(t *testing.T) {
+		engine := New(Options{FS: fsys, UnknownFuncs: UnknownFuncIgnore})
+		if err := engine.Load(); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := engine.Render("pages/home.html", H{"Title": "Home"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		containsAll(t, []string{"<main><h1>Home</h1></main>"}, result)
+
+		result, err = engine.Render("pages/about.html", H{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		containsAll(t, []string{"<nav></nav>"}, result)
+	})
+}
+
+func TestCircularDependencyPath(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pages/a.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/b.html"}}{{define "content"}}A{{end}}`),
+		},
+		"layouts/b.html": &fstest.MapFile{
+			Data: []byte(`{{include "partials/c.tmpl" .}}{{block "content" .}}{{end}}`),
+		},
+		"partials/c.tmpl": &fstest.MapFile{
+			Data: []byte(`{{include "layouts/b.html" .}}`),
+		},
+	}
+
+	engine := New(Options{FS: fsys})
+	err := engine.Load()
+	if err == nil {
+		t.Fatal("Expected error for circular dependency, got nil")
+	}
+
+	expected := "layouts/b.html -> partials/c.tmpl -> layouts/b.html"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error to contain %q, got %q", expected, err.Error())
+	}
+}
+
+func TestDependencyParseError(t *testing.T) {
+	engine := New(Options{Loader: MapLoader{
+		"pages/a.html":      `{{include "partials/nav.tmpl" .}}A`,
+		"partials/nav.tmpl": `{{if}}`,
+	}})
+	err := engine.Load()
+	if err == nil || !strings.Contains(err.Error(), "partials/nav.tmpl") {
+		t.Errorf("Expected the parse error of the include, got %v", err)
+	}
+}
+
+func TestRenderResponseBuffered(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.html": &fstest.MapFile{Data: []byte(`<h1>Start</h1>{{index .Items 5}}`)},
+	}
+
+	engine := New(Options{FS: fsys})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	data := H{"Items": []string{"a"}}
+
+	var streamed strings.Builder
+	if err := engine.RenderResponse(&streamed, "page.html", data); err == nil {
+		t.Fatal("Expected render error, got nil")
+	}
+	if !strings.Contains(streamed.String(), "<h1>Start</h1>") {
+		t.Errorf("Expected streamed output to contain partial page, got %q", streamed.String())
+	}
+
+	var buffered strings.Builder
+	if err := engine.RenderResponseBuffered(&buffered, "page.html", data); err == nil {
+		t.Fatal("Expected render error, got nil")
+	}
+	if buffered.Len() != 0 {
+		t.Errorf("Expected no output from failed buffered render, got %q", buffered.String())
+	}
+
+	prev := DefaultEngine
+	DefaultEngine = engine
+	defer func() { DefaultEngine = prev }()
+
+	buffered.Reset()
+	if err := RenderResponseBuffered(&buffered, "page.html", H{"Items": []string{"a", "b", "c", "d", "e", "f"}}); err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{"<h1>Start</h1>f"}, buffered.String())
+}
+
+func TestFSWithDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/layouts/base.html": &fstest.MapFile{Data: []byte(`<b>{{block "content" .}}{{end}}</b>`)},
+		"templates/pages/home.html":   &fstest.MapFile{Data: []byte(`{{extend "layouts/base.html"}}{{define "content"}}home{{end}}`)},
+		"other/ignored.html":          &fstest.MapFile{Data: []byte(`ignored`)},
+	}
+
+	for _, dir := range []string{"templates", "./templates/", "/templates"} {
+		engine := New(Options{FS: fsys, Dir: dir})
+		if err := engine.Load(); err != nil {
+			t.Fatalf("%s: %v", dir, err)
+		}
+		result, err := engine.Render("pages/home.html", nil)
+		if err != nil {
+			t.Fatalf("%s: %v", dir, err)
+		}
+		if result != "<b>home</b>" {
+			t.Errorf("%s: unexpected output %q", dir, result)
+		}
+		if _, err := engine.GetTemplate("other/ignored.html"); err == nil {
+			t.Errorf("%s: expected templates outside Dir to be ignored", dir)
+		}
+	}
+
+	err := New(Options{FS: fsys, Dir: "missing"}).Load()
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected error naming the missing dir, got %v", err)
+	}
+}
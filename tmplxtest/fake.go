@@ -0,0 +1,92 @@
+// Package tmplxtest provides helpers for testing code that renders tmplx
+// templates: snapshot tests of rendered output with generated data, and a
+// recorder standing in for the engine in handler tests.
+package tmplxtest
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// FakeTime is the time every time.Time field is set to by Fake
+var FakeTime = time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC)
+
+// maxFakeDepth stops Fake from following recursive types forever
+const maxFakeDepth = 6
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Fake returns a value of the same type as sample filled with deterministic
+// fake data: strings are set to their field path (e.g. "Items.0.Name"),
+// numbers to 1, bools to true, times to FakeTime, and slices and maps get two
+// and one entries. Pointers are allocated; interfaces and funcs stay nil.
+// The same type always produces the same value, so renders can be
+// snapshotted. If sample is a pointer, a pointer to the filled value is
+// returned.
+func Fake(sample interface{}) interface{} {
+	typ := reflect.TypeOf(sample)
+	if typ == nil {
+		return nil
+	}
+	return fake(typ, "", 0).Interface()
+}
+
+func fake(typ reflect.Type, path string, depth int) reflect.Value {
+	v := reflect.New(typ).Elem()
+	if depth > maxFakeDepth {
+		return v
+	}
+
+	if typ == timeType {
+		v.Set(reflect.ValueOf(FakeTime))
+		return v
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		if path == "" {
+			path = typ.Name()
+		}
+		v.SetString(path)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	case reflect.Pointer:
+		v.Set(fake(typ.Elem(), path, depth+1).Addr())
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(typ, 2, 2))
+		for i := 0; i < 2; i++ {
+			v.Index(i).Set(fake(typ.Elem(), join(path, strconv.Itoa(i)), depth+1))
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			v.Index(i).Set(fake(typ.Elem(), join(path, strconv.Itoa(i)), depth+1))
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(typ))
+		key := fake(typ.Key(), join(path, "key"), depth+1)
+		v.SetMapIndex(key, fake(typ.Elem(), join(path, "value"), depth+1))
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			v.Field(i).Set(fake(f.Type, join(path, f.Name), depth+1))
+		}
+	}
+	return v
+}
+
+func join(path, elem string) string {
+	if path == "" {
+		return elem
+	}
+	return path + "." + elem
+}
@@ -0,0 +1,59 @@
+package tmplxtest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeAuthor struct {
+	Name string
+}
+
+type fakePost struct {
+	Title     string
+	Views     int
+	Published bool
+	Date      time.Time
+	Author    *fakeAuthor
+	Tags      []string
+	Meta      map[string]string
+	Next      *fakePost
+	hidden    string
+}
+
+func TestFake(t *testing.T) {
+	post := Fake(fakePost{}).(fakePost)
+
+	if post.Title != "Title" || post.Views != 1 || !post.Published || !post.Date.Equal(FakeTime) {
+		t.Errorf("Unexpected scalar fields %+v", post)
+	}
+	if post.Author == nil || post.Author.Name != "Author.Name" {
+		t.Errorf("Expected allocated author, got %+v", post.Author)
+	}
+	if !reflect.DeepEqual(post.Tags, []string{"Tags.0", "Tags.1"}) {
+		t.Errorf("Unexpected tags %v", post.Tags)
+	}
+	if !reflect.DeepEqual(post.Meta, map[string]string{"Meta.key": "Meta.value"}) {
+		t.Errorf("Unexpected meta %v", post.Meta)
+	}
+	if post.hidden != "" {
+		t.Error("Expected unexported fields left alone")
+	}
+
+	// recursive types end after a few levels
+	depth := 0
+	for p := &post; p != nil; p = p.Next {
+		depth++
+	}
+	if depth < 2 || depth > maxFakeDepth {
+		t.Errorf("Unexpected recursion depth %d", depth)
+	}
+
+	if !reflect.DeepEqual(Fake(fakePost{}), Fake(fakePost{})) {
+		t.Error("Expected Fake to be deterministic")
+	}
+	if p, ok := Fake(&fakeAuthor{}).(*fakeAuthor); !ok || p.Name != "Name" {
+		t.Errorf("Expected filled pointer, got %#v", Fake(&fakeAuthor{}))
+	}
+}
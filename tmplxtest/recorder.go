@@ -0,0 +1,141 @@
+package tmplxtest
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kalyan02/tmplx"
+)
+
+// Call is a single render recorded by a Recorder
+type Call struct {
+	// Name is the template that would have been rendered
+	Name string
+
+	// Data is the data it would have been rendered with
+	Data interface{}
+}
+
+// Recorder is a tmplx.Renderer that records each render instead of executing
+// templates, so handler tests can assert which page was rendered with which
+// data without loading any templates:
+//
+//	rec := tmplxtest.NewRecorder()
+//	h := LoginHandler(rec)
+//	h.ServeHTTP(httptest.NewRecorder(), req)
+//	rec.AssertRendered(t, "pages/login.html", LoginPage{Error: "bad password"})
+//
+// It is safe for concurrent use.
+type Recorder struct {
+	// Output is written for every render, empty by default
+	Output string
+
+	// Err, if set, is returned from every render instead of writing Output
+	Err error
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ tmplx.Renderer = (*Recorder)(nil)
+
+// NewRecorder returns an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) record(name string, data interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Name: name, Data: data})
+	return r.Err
+}
+
+// Render records the call and returns Output
+func (r *Recorder) Render(name string, data interface{}, opts ...tmplx.RenderOption) (string, error) {
+	if err := r.record(name, data); err != nil {
+		return "", err
+	}
+	return r.Output, nil
+}
+
+// RenderResponse records the call and writes Output to w
+func (r *Recorder) RenderResponse(w io.Writer, name string, data interface{}, opts ...tmplx.RenderOption) error {
+	if err := r.record(name, data); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, r.Output)
+	return err
+}
+
+// ExecuteTemplate records the call and writes Output to w
+func (r *Recorder) ExecuteTemplate(w io.Writer, name string, data interface{}) error {
+	return r.RenderResponse(w, name, data)
+}
+
+// RenderHTTP records the call and writes Output as a 200 HTML response
+func (r *Recorder) RenderHTTP(w http.ResponseWriter, req *http.Request, name string, data interface{}, opts ...tmplx.RenderOption) error {
+	if err := r.record(name, data); err != nil {
+		return err
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err := io.WriteString(w, r.Output)
+	return err
+}
+
+// Calls returns the recorded renders in order
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Last returns the most recent render, or false if there was none
+func (r *Recorder) Last() (Call, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.calls) == 0 {
+		return Call{}, false
+	}
+	return r.calls[len(r.calls)-1], true
+}
+
+// Rendered reports whether the named template was rendered at all
+func (r *Recorder) Rendered(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.calls {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset forgets every recorded render
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// AssertRendered fails t unless the most recent render was of the named
+// template with data deeply equal to data
+func (r *Recorder) AssertRendered(t testing.TB, name string, data interface{}) {
+	t.Helper()
+	last, ok := r.Last()
+	switch {
+	case !ok:
+		t.Errorf("expected %s to be rendered, nothing was", name)
+	case last.Name != name:
+		t.Errorf("expected %s to be rendered, got %s", name, last.Name)
+	case !reflect.DeepEqual(last.Data, data):
+		t.Errorf("expected %s rendered with\n%#v\ngot\n%#v", name, data, last.Data)
+	}
+}
@@ -0,0 +1,64 @@
+package tmplxtest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kalyan02/tmplx"
+)
+
+type loginPage struct {
+	Error string
+}
+
+func loginHandler(r tmplx.Renderer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		page := loginPage{}
+		if req.FormValue("password") != "secret" {
+			page.Error = "bad password"
+		}
+		if err := r.RenderHTTP(w, req, "pages/login.html", page); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder()
+	rec.Output = "<form>"
+	handler := loginHandler(rec)
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest("POST", "/login?password=wrong", nil))
+	rec.AssertRendered(t, "pages/login.html", loginPage{Error: "bad password"})
+	if resp.Body.String() != "<form>" || resp.Code != http.StatusOK {
+		t.Errorf("Unexpected response %d %q", resp.Code, resp.Body.String())
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/login?password=secret", nil))
+	rec.AssertRendered(t, "pages/login.html", loginPage{})
+	if len(rec.Calls()) != 2 || !rec.Rendered("pages/login.html") || rec.Rendered("pages/home.html") {
+		t.Errorf("Unexpected calls %v", rec.Calls())
+	}
+
+	// a mismatch is reported
+	fr := &failRecorder{TB: t}
+	rec.AssertRendered(fr, "pages/login.html", loginPage{Error: "other"})
+	if len(fr.errors) != 1 || !strings.Contains(fr.errors[0], "other") {
+		t.Errorf("Expected a data mismatch, got %v", fr.errors)
+	}
+
+	rec.Reset()
+	rec.Err = errors.New("boom")
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest("POST", "/login", nil))
+	if resp.Code != http.StatusInternalServerError {
+		t.Errorf("Expected the error to reach the handler, got %d", resp.Code)
+	}
+	if _, ok := rec.Last(); !ok {
+		t.Error("Expected failed renders recorded too")
+	}
+}
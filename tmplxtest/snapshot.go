@@ -0,0 +1,132 @@
+package tmplxtest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kalyan02/tmplx"
+)
+
+// UpdateEnv is the environment variable that, when set to a non-empty value,
+// makes snapshot tests write the current output instead of comparing it:
+//
+//	TMPLX_UPDATE_SNAPSHOTS=1 go test ./...
+const UpdateEnv = "TMPLX_UPDATE_SNAPSHOTS"
+
+// DefaultSnapshotDir is where snapshots are kept unless configured otherwise
+const DefaultSnapshotDir = "testdata/snapshots"
+
+// Snapshot renders the named template with data and compares the output to
+// the snapshot stored in DefaultSnapshotDir, failing t with the first
+// difference. A missing snapshot is created, so commit the snapshot files
+// and review changes to them like code.
+func Snapshot(t testing.TB, engine *tmplx.TemplateEngine, name string, data interface{}) {
+	t.Helper()
+	snapshot(t, engine, DefaultSnapshotDir, name, data)
+}
+
+// Snapshots renders templates with fake data generated from a registered
+// type, so every template has a snapshot without hand written fixtures and
+// any edit that changes output shows up as a diff.
+type Snapshots struct {
+	// Engine renders the templates
+	Engine *tmplx.TemplateEngine
+
+	// Dir is where snapshots are kept, DefaultSnapshotDir if empty
+	Dir string
+
+	types map[string]reflect.Type
+}
+
+// NewSnapshots returns Snapshots rendering with engine
+func NewSnapshots(engine *tmplx.TemplateEngine) *Snapshots {
+	return &Snapshots{Engine: engine, types: make(map[string]reflect.Type)}
+}
+
+// Register sets the data type of the named template. Only the type of
+// sample is used; its fields are filled by Fake.
+func (s *Snapshots) Register(name string, sample interface{}) {
+	s.types[name] = reflect.TypeOf(sample)
+}
+
+// Run snapshots every registered template in a subtest named after it
+func (s *Snapshots) Run(t *testing.T) {
+	t.Helper()
+	dir := s.Dir
+	if dir == "" {
+		dir = DefaultSnapshotDir
+	}
+
+	names := make([]string, 0, len(s.types))
+	for name := range s.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var data interface{}
+		if typ := s.types[name]; typ != nil {
+			data = Fake(reflect.Zero(typ).Interface())
+		}
+		t.Run(name, func(t *testing.T) {
+			snapshot(t, s.Engine, dir, name, data)
+		})
+	}
+}
+
+func snapshot(t testing.TB, engine *tmplx.TemplateEngine, dir, name string, data interface{}) {
+	t.Helper()
+
+	got, err := engine.Render(name, data)
+	if err != nil {
+		t.Errorf("error rendering %s: %v", name, err)
+		return
+	}
+
+	file := filepath.Join(dir, filepath.FromSlash(name)+".snap")
+	want, err := os.ReadFile(file)
+	if os.IsNotExist(err) || (err == nil && os.Getenv(UpdateEnv) != "") {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("error creating snapshot dir: %v", err)
+		}
+		if err := os.WriteFile(file, []byte(got), 0644); err != nil {
+			t.Fatalf("error writing snapshot %s: %v", file, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("error reading snapshot %s: %v", file, err)
+	}
+
+	if got != string(want) {
+		t.Errorf("%s differs from snapshot %s (set %s=1 to update):\n%s", name, file, UpdateEnv, diff(string(want), got))
+	}
+}
+
+// diff describes the first line where got differs from want, with the line
+// before it for context
+func diff(want, got string) string {
+	wl, gl := strings.Split(want, "\n"), strings.Split(got, "\n")
+	i := 0
+	for i < len(wl) && i < len(gl) && wl[i] == gl[i] {
+		i++
+	}
+
+	var b strings.Builder
+	if i > 0 {
+		b.WriteString("  " + wl[i-1] + "\n")
+	}
+	if i < len(wl) {
+		b.WriteString("- " + wl[i] + "\n")
+	}
+	if i < len(gl) {
+		b.WriteString("+ " + gl[i] + "\n")
+	}
+	b.WriteString("(line " + strconv.Itoa(i+1) + ")")
+	return b.String()
+}
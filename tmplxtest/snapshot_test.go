@@ -0,0 +1,83 @@
+package tmplxtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kalyan02/tmplx"
+)
+
+// failRecorder captures failures instead of failing the test
+type failRecorder struct {
+	testing.TB
+	errors []string
+}
+
+func (f *failRecorder) Helper() {}
+
+func (f *failRecorder) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *failRecorder) Fatalf(format string, args ...interface{}) {
+	f.Errorf(format, args...)
+}
+
+type snapshotPage struct {
+	Title string
+	Items []string
+}
+
+func TestSnapshots(t *testing.T) {
+	templates := tmplx.MapLoader{
+		"pages/list.html": `<h1>{{.Title}}</h1>{{range .Items}}<li>{{.}}</li>{{end}}`,
+	}
+	engine := tmplx.New(tmplx.Options{Loader: templates})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	snaps := NewSnapshots(engine)
+	snaps.Dir = dir
+	snaps.Register("pages/list.html", snapshotPage{})
+	snaps.Run(t)
+
+	content, err := os.ReadFile(filepath.Join(dir, "pages", "list.html.snap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "<h1>Title</h1><li>Items.0</li><li>Items.1</li>" {
+		t.Errorf("Unexpected snapshot %q", content)
+	}
+
+	// an unchanged render passes
+	rec := &failRecorder{TB: t}
+	snapshot(rec, engine, dir, "pages/list.html", Fake(snapshotPage{}))
+	if len(rec.errors) != 0 {
+		t.Errorf("Expected snapshot to match, got %v", rec.errors)
+	}
+
+	// an edited template fails with a diff
+	templates["pages/list.html"] = `<h2>{{.Title}}</h2>{{range .Items}}<li>{{.}}</li>{{end}}`
+	if err := engine.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	rec = &failRecorder{TB: t}
+	snapshot(rec, engine, dir, "pages/list.html", Fake(snapshotPage{}))
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], "+ <h2>Title</h2>") {
+		t.Errorf("Expected a diff, got %v", rec.errors)
+	}
+
+	// updating rewrites the snapshot
+	t.Setenv(UpdateEnv, "1")
+	rec = &failRecorder{TB: t}
+	snapshot(rec, engine, dir, "pages/list.html", Fake(snapshotPage{}))
+	content, _ = os.ReadFile(filepath.Join(dir, "pages", "list.html.snap"))
+	if len(rec.errors) != 0 || !strings.HasPrefix(string(content), "<h2>") {
+		t.Errorf("Expected snapshot updated, got %q, %v", content, rec.errors)
+	}
+}
@@ -0,0 +1,87 @@
+package tmplx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TraceSpan is a range of output lines produced by one template, block or include
+type TraceSpan struct {
+	// Kind is "template", "block" or "include"
+	Kind string
+
+	// Name is the template or include path, or the block name
+	Name string
+
+	// Source is the file the content was defined in
+	Source string
+
+	// StartLine and EndLine are the 1-based, inclusive output lines of the span
+	StartLine int
+	EndLine   int
+
+	// Depth is the nesting level of the span, 0 for the rendered template
+	Depth int
+
+	// Duration is the time spent rendering the span, nested spans included
+	Duration time.Duration
+}
+
+// RenderTrace maps the output of a render back to its templates
+type RenderTrace struct {
+	// Spans are in the order they began, so enclosing spans come first
+	Spans []TraceSpan
+}
+
+// At returns the innermost span covering the given output line, or nil.
+func (t *RenderTrace) At(line int) *TraceSpan {
+	var found *TraceSpan
+	for i := range t.Spans {
+		span := &t.Spans[i]
+		if line >= span.StartLine && line <= span.EndLine && (found == nil || span.Depth >= found.Depth) {
+			found = span
+		}
+	}
+	return found
+}
+
+// SourceAt returns the file that produced the given output line, or "" if
+// the line is outside the output.
+func (t *RenderTrace) SourceAt(line int) string {
+	if span := t.At(line); span != nil {
+		return span.Source
+	}
+	return ""
+}
+
+var errTraceDisabled = errors.New("tracing is not enabled, set Options.Trace or Options.DevMode")
+
+// RenderWithTrace renders a template like Render and also reports which
+// template, block or include produced each range of output lines. It
+// requires Options.Trace or Options.DevMode and the HTML backend.
+func (e *TemplateEngine) RenderWithTrace(name string, data interface{}, opts ...RenderOption) (string, *RenderTrace, error) {
+	if !e.instrument {
+		return "", nil, errTraceDisabled
+	}
+
+	cfg := newRenderConfig(opts)
+	cfg.trace = &RenderTrace{}
+
+	var buf strings.Builder
+	if err := e.renderTo(&buf, name, data, cfg); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), cfg.trace, nil
+}
+
+// String lists the spans one per line, indented by depth
+func (t *RenderTrace) String() string {
+	var b strings.Builder
+	for _, span := range t.Spans {
+		label := boundary{kind: span.Kind, name: span.Name, source: span.Source}
+		fmt.Fprintf(&b, "%s%d-%d %s\n", strings.Repeat("  ", span.Depth), span.StartLine, span.EndLine, label)
+	}
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderWithTrace(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{
+			Data: []byte(`<html>
+<head><title>{{block "title" .}}Site{{end}}</title></head>
+<body>
+{{include "partials/nav.html" .}}
+{{block "content" .}}{{end}}
+</body>
+</html>`),
+		},
+		"partials/nav.html": &fstest.MapFile{
+			Data: []byte("<nav>\n<a href=\"/\">Home</a>\n</nav>"),
+		},
+		"pages/home.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/base.html"}}
+{{define "content"}}<main>
+<p>Hello</p>
+</main>{{end}}`),
+		},
+	}
+
+	engine := New(Options{FS: fsys, Trace: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, trace, err := engine.RenderWithTrace("pages/home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result, "<!--") || strings.Contains(result, "tmplx") {
+		t.Errorf("Expected no marks in traced output, got: %s", result)
+	}
+
+	lines := strings.Split(result, "\n")
+	sources := map[string]string{
+		`<a href="/">Home</a>`: "partials/nav.html",
+		`<p>Hello</p>`:         "pages/home.html",
+		`<body>`:               "pages/home.html",
+	}
+	for i, line := range lines {
+		want, ok := sources[line]
+		if !ok {
+			continue
+		}
+		span := trace.At(i + 1)
+		if span == nil || span.Source != want {
+			t.Errorf("Expected line %d (%s) to come from %s, got %+v", i+1, line, want, span)
+		}
+	}
+
+	if span := trace.At(8); span == nil || span.Kind != "block" || span.Name != "content" {
+		t.Errorf("Expected line 8 in block content, got %+v\n%s", span, trace)
+	}
+
+	plain := New(Options{FS: fsys})
+	if err := plain.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := plain.RenderWithTrace("pages/home.html", nil); err == nil {
+		t.Error("Expected an error when tracing is not enabled")
+	}
+}
+
+func TestTraceOutputUnchanged(t *testing.T) {
+	loader := MapLoader{
+		"layouts/base.html": `<a href="{{block "url" .}}/home{{end}}">{{block "label" .}}Home{{end}}</a>
+<script>var page = {{block "data" .}}{"id": 1}{{end}}; var url = "{{template "url" .}}";</script>
+{{block "content" .}}{{end}}`,
+		"pages/home.html": `{{extend "layouts/base.html"}}{{define "url"}}/x?a=1{{end}}{{define "content"}}<p>Hi</p>{{end}}`,
+	}
+
+	plain := New(Options{Loader: loader})
+	if err := plain.Load(); err != nil {
+		t.Fatal(err)
+	}
+	want, err := plain.Render("pages/home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := New(Options{Loader: loader, Trace: true})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("pages/home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != want {
+		t.Errorf("Expected the same output as without Trace:\n%s\ngot:\n%s", want, result)
+	}
+	traced, trace, err := engine.RenderWithTrace("pages/home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if traced != want {
+		t.Errorf("Expected RenderWithTrace to match too, got:\n%s", traced)
+	}
+	if span := trace.At(3); span == nil || span.Name != "content" {
+		t.Errorf("Expected the content block on line 3, got %+v", span)
+	}
+}
@@ -0,0 +1,85 @@
+package tmplx
+
+import (
+	"html/template"
+	"text/template/parse"
+)
+
+// TreeTransform rewrites a parse tree of a resolved template before it is
+// cached, like a compiler pass. It is called for the template's own tree and
+// for each of its blocks, with name set to the template path (e.g.
+// "pages/home.html"); tree.Name is the block name and tree.ParseName the file
+// the tree was defined in. Trees are private copies and may be modified
+// freely.
+type TreeTransform func(name string, tree *parse.Tree) error
+
+// transformed applies the engine's transforms to a copy of tmpl
+func (e *TemplateEngine) transformed(name string, tmpl *template.Template) (*template.Template, error) {
+	return e.rebuild(name, tmpl, func(_ *template.Template, tree *parse.Tree) error {
+		for _, transform := range e.transforms {
+			if err := transform(name, tree); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TextTransform returns a TreeTransform applying fn to the literal text of
+// templates, i.e. everything outside actions, e.g. to point static asset
+// URLs at a CDN. Text inside if, range and with bodies is included.
+func TextTransform(fn func(text string) string) TreeTransform {
+	return func(_ string, tree *parse.Tree) error {
+		WalkTree(tree.Root, func(node parse.Node) {
+			if text, ok := node.(*parse.TextNode); ok {
+				text.Text = []byte(fn(string(text.Text)))
+			}
+		})
+		return nil
+	}
+}
+
+// WalkTree calls fn for node and every node below it, parents first. It
+// descends into lists and into the pipelines and bodies of if, range and
+// with, but not into the commands of a pipeline.
+func WalkTree(node parse.Node, fn func(parse.Node)) {
+	if node == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		fn(n)
+		for _, c := range n.Nodes {
+			WalkTree(c, fn)
+		}
+		return
+	case *parse.IfNode:
+		fn(n)
+		walkBranch(&n.BranchNode, fn)
+		return
+	case *parse.RangeNode:
+		fn(n)
+		walkBranch(&n.BranchNode, fn)
+		return
+	case *parse.WithNode:
+		fn(n)
+		walkBranch(&n.BranchNode, fn)
+		return
+	}
+	fn(node)
+}
+
+func walkBranch(b *parse.BranchNode, fn func(parse.Node)) {
+	if b.Pipe != nil {
+		fn(b.Pipe)
+	}
+	if b.List != nil {
+		WalkTree(b.List, fn)
+	}
+	if b.ElseList != nil {
+		WalkTree(b.ElseList, fn)
+	}
+}
@@ -0,0 +1,59 @@
+package tmplx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"text/template/parse"
+)
+
+func TestTransforms(t *testing.T) {
+	var seen []string
+	engine := New(Options{
+		Loader: MapLoader{
+			"layouts/base.html": `<link href="/static/site.css">{{block "content" .}}{{end}}`,
+			"pages/a.html":      `{{extend "layouts/base.html"}}{{define "content"}}{{if .}}<img src="/static/a.png">{{end}}{{end}}`,
+			"pages/b.html":      `{{extend "layouts/base.html"}}{{define "content"}}b{{end}}`,
+		},
+		Transforms: []TreeTransform{
+			TextTransform(func(text string) string {
+				return strings.ReplaceAll(text, `"/static/`, `"https://cdn.example.com/static/`)
+			}),
+			func(name string, tree *parse.Tree) error {
+				seen = append(seen, name+":"+tree.Name)
+				return nil
+			},
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the layout is shared by both pages but only rewritten once per page
+	for name, expected := range map[string]string{
+		"pages/a.html": `<link href="https://cdn.example.com/static/site.css"><img src="https://cdn.example.com/static/a.png">`,
+		"pages/b.html": `<link href="https://cdn.example.com/static/site.css">b`,
+	} {
+		result, err := engine.Render(name, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, result)
+		}
+	}
+
+	if !containsString(seen, "pages/a.html:content") || !containsString(seen, "layouts/base.html:base.html") {
+		t.Errorf("Expected transforms called per template and block, got %v", seen)
+	}
+
+	failing := New(Options{
+		Loader: MapLoader{"page.html": "x"},
+		Transforms: []TreeTransform{func(string, *parse.Tree) error {
+			return fmt.Errorf("nope")
+		}},
+	})
+	if err := failing.Load(); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("Expected transform error, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package tmplx
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ellipsis ends truncated text
+const ellipsis = "…"
+
+// richText returns the markup of v: template.HTML as it is, strings escaped
+// as text, so untrusted strings never become markup by being truncated
+func richText(v interface{}) (string, error) {
+	switch s := v.(type) {
+	case template.HTML:
+		return string(s), nil
+	case string:
+		return template.HTMLEscapeString(s), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("expected template.HTML or string, got %T", v)
+}
+
+// truncateHTML shortens rich text to n visible characters, cutting at a
+// word boundary where there is one, adding an ellipsis and closing the
+// elements left open. Tags and entities are never split.
+func truncateHTML(v interface{}, n int) (template.HTML, error) {
+	s, err := richText(v)
+	if err != nil {
+		return "", fmt.Errorf("truncateHTML: %v", err)
+	}
+
+	var b strings.Builder
+	var open []string
+	count := 0
+	for i := 0; i < len(s); {
+		if count == n {
+			return closeTruncated(&b, open, s[i:]), nil
+		}
+		switch s[i] {
+		case '<':
+			end := tagEnd(s[i:])
+			tag := s[i : i+end]
+			b.WriteString(tag)
+			i += end
+			trackElement(&open, tag)
+			continue
+		case '&':
+			if end := strings.IndexByte(s[i:], ';'); end > 0 && end < 12 {
+				b.WriteString(s[i : i+end+1])
+				i += end + 1
+				count++
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteRune(r)
+		i += size
+		count++
+	}
+	return template.HTML(b.String()), nil
+}
+
+// closeTruncated ends truncated output in b: if rest has more visible text,
+// the word cut in half is dropped, an ellipsis added and the open elements
+// closed
+func closeTruncated(b *strings.Builder, open []string, rest string) template.HTML {
+	if strings.TrimSpace(visibleText(rest)) == "" {
+		return template.HTML(b.String() + rest)
+	}
+	out := b.String()
+	if r, _ := utf8.DecodeRuneInString(rest); !unicode.IsSpace(r) && r != '<' {
+		// back up to the last space in the last run of text
+		text := out[strings.LastIndexByte(out, '>')+1:]
+		if i := strings.LastIndexFunc(text, unicode.IsSpace); i > 0 {
+			out = out[:len(out)-len(text)+i]
+		}
+	}
+	out = strings.TrimRightFunc(out, unicode.IsSpace) + ellipsis
+	for i := len(open) - 1; i >= 0; i-- {
+		out += "</" + open[i] + ">"
+	}
+	return template.HTML(out)
+}
+
+// tagEnd returns the length of the tag, comment or stray "<" at the start
+// of s
+func tagEnd(s string) int {
+	if strings.HasPrefix(s, "<!--") {
+		if end := strings.Index(s, "-->"); end >= 0 {
+			return end + len("-->")
+		}
+		return len(s)
+	}
+	if m := endTag.FindStringIndex(s); m != nil {
+		return m[1]
+	}
+	if m := htmlTag.FindStringIndex(s); m != nil && m[0] == 0 {
+		return m[1]
+	}
+	return 1
+}
+
+// trackElement updates the stack of open elements for a tag
+func trackElement(open *[]string, tag string) {
+	if m := endTag.FindStringSubmatch(tag); m != nil {
+		name := strings.ToLower(m[1])
+		for i := len(*open) - 1; i >= 0; i-- {
+			if (*open)[i] == name {
+				*open = (*open)[:i]
+				return
+			}
+		}
+		return
+	}
+	m := htmlTag.FindStringSubmatch(tag)
+	if m == nil || strings.HasSuffix(m[2], "/") {
+		return
+	}
+	if name := strings.ToLower(m[1]); !voidElements[name] {
+		*open = append(*open, name)
+	}
+}
+
+// lineBreaking are elements other than blocks separating the words around
+// them
+var lineBreaking = map[string]bool{"br": true, "li": true, "td": true, "th": true, "tr": true, "dt": true, "dd": true}
+
+// visibleText returns the text of rich text with its tags removed and
+// entities decoded, skipping scripts and styles
+func visibleText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			next := strings.IndexByte(s[i:], '<')
+			if next < 0 {
+				next = len(s) - i
+			}
+			b.WriteString(s[i : i+next])
+			i += next
+			continue
+		}
+		end := tagEnd(s[i:])
+		name := ""
+		if m := endTag.FindStringSubmatch(s[i : i+end]); m != nil {
+			name = strings.ToLower(m[1])
+		} else if m := htmlTag.FindStringSubmatch(s[i : i+end]); m != nil {
+			name = strings.ToLower(m[1])
+			if name == "script" || name == "style" {
+				if closing := strings.Index(strings.ToLower(s[i+end:]), "</"+name); closing >= 0 {
+					end += closing
+				}
+			}
+		}
+		if closesP[name] || lineBreaking[name] {
+			b.WriteByte(' ')
+		}
+		i += end
+	}
+	return html.UnescapeString(b.String())
+}
+
+// excerpt returns the plain text of rich text, with whitespace collapsed,
+// shortened to at most n characters at a word boundary
+func excerpt(v interface{}, n int) (string, error) {
+	s, err := richText(v)
+	if err != nil {
+		return "", fmt.Errorf("excerpt: %v", err)
+	}
+	text := strings.Join(strings.Fields(visibleText(s)), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text, nil
+	}
+
+	cut := string(runes[:n])
+	if !unicode.IsSpace(runes[n]) {
+		if i := strings.LastIndexByte(cut, ' '); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRightFunc(cut, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsPunct(r) }) + ellipsis, nil
+}
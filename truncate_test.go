@@ -0,0 +1,57 @@
+package tmplx
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestTruncateHTML(t *testing.T) {
+	for _, tc := range []struct {
+		html     template.HTML
+		n        int
+		expected template.HTML
+	}{
+		{"<p>Hello <b>brave new</b> world</p>", 13, "<p>Hello <b>brave…</b></p>"},
+		{"<p>Tom &amp; Jerry</p>", 5, "<p>Tom &amp;…</p>"},
+		{"<p>abc</p><p>def</p>", 3, "<p>abc…</p>"},
+		{"<p>short</p>", 5, "<p>short</p>"},
+		{"<p>short</p><!-- note -->", 10, "<p>short</p><!-- note -->"},
+		{`<p><img src="a.png" alt="">Café au lait</p>`, 6, `<p><img src="a.png" alt="">Café…</p>`},
+		{"Unsplittable", 4, "Unsp…"},
+	} {
+		got, err := truncateHTML(tc.html, tc.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.expected {
+			t.Errorf("truncateHTML(%q, %d): expected %q, got %q", tc.html, tc.n, tc.expected, got)
+		}
+	}
+
+	// plain strings are text, not markup
+	got, _ := truncateHTML("<script>alert(1)</script>", 100)
+	if got != "&lt;script&gt;alert(1)&lt;/script&gt;" {
+		t.Errorf("Expected escaped string, got %q", got)
+	}
+	if _, err := truncateHTML(42, 10); err == nil {
+		t.Error("Expected error for non-text value, got nil")
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{"teaser.html": `{{excerpt .Body 30}}|{{truncateHTML .Body 11}}`},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	body := template.HTML("<h2>Release notes</h2><p>Faster <em>builds</em> &amp; smaller\nbinaries.</p><script>track()</script>")
+	result, err := engine.Render("teaser.html", H{"Body": body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Release notes Faster builds…|<h2>Release…</h2>"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
@@ -0,0 +1,78 @@
+package tmplx
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// TurboStreamContentType is the content type of Turbo Stream responses
+const TurboStreamContentType = "text/vnd.turbo-stream.html; charset=utf-8"
+
+// TurboAction is one <turbo-stream> element of a Turbo Stream response
+type TurboAction struct {
+	// Action is the stream action: "append", "prepend", "replace", "update",
+	// "remove", "before", "after" or "refresh"
+	Action string
+
+	// Target is the id of the element the action applies to, or Targets a
+	// CSS selector matching several
+	Target  string
+	Targets string
+
+	// Template renders the content of the action, or only its Block if set.
+	// Actions such as "remove" need no content.
+	Template string
+	Block    string
+	Data     interface{}
+}
+
+// RenderTurboStream writes actions as a Turbo Stream response for Hotwire
+// frontends. Every action is rendered before anything is written, so a
+// failing render leaves the response untouched.
+func (e *TemplateEngine) RenderTurboStream(w http.ResponseWriter, actions []TurboAction) error {
+	var buf bytes.Buffer
+	for _, a := range actions {
+		if err := e.renderTurboAction(&buf, a); err != nil {
+			return err
+		}
+	}
+
+	w.Header().Set("Content-Type", TurboStreamContentType)
+	w.WriteHeader(http.StatusOK)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func (e *TemplateEngine) renderTurboAction(buf *bytes.Buffer, a TurboAction) error {
+	if a.Action == "" {
+		return fmt.Errorf("turbo stream action without an action")
+	}
+
+	fmt.Fprintf(buf, `<turbo-stream action="%s"`, template.HTMLEscapeString(a.Action))
+	if a.Target != "" {
+		fmt.Fprintf(buf, ` target="%s"`, template.HTMLEscapeString(a.Target))
+	}
+	if a.Targets != "" {
+		fmt.Fprintf(buf, ` targets="%s"`, template.HTMLEscapeString(a.Targets))
+	}
+	buf.WriteString(">")
+
+	if a.Template != "" {
+		buf.WriteString("<template>")
+		var err error
+		if a.Block != "" {
+			err = e.RenderBlock(buf, a.Template, a.Block, a.Data)
+		} else {
+			err = e.renderTo(buf, a.Template, a.Data, newRenderConfig(nil))
+		}
+		if err != nil {
+			return fmt.Errorf("error rendering turbo stream %s %s: %w", a.Action, a.Target+a.Targets, err)
+		}
+		buf.WriteString("</template>")
+	}
+
+	buf.WriteString("</turbo-stream>\n")
+	return nil
+}
@@ -0,0 +1,47 @@
+package tmplx
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRenderTurboStream(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"messages.html":         `<div id="messages">{{block "message" .}}<p>{{.}}</p>{{end}}</div>`,
+			"partials/counter.html": `<span>{{.}}</span>`,
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	err := engine.RenderTurboStream(rec, []TurboAction{
+		{Action: "append", Target: "messages", Template: "messages.html", Block: "message", Data: "<hi>"},
+		{Action: "update", Targets: ".counter", Template: "partials/counter.html", Data: 3},
+		{Action: "remove", Target: "flash"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Type"); got != TurboStreamContentType {
+		t.Errorf("Expected Turbo Stream content type, got %q", got)
+	}
+	expected := `<turbo-stream action="append" target="messages"><template><p>&lt;hi&gt;</p></template></turbo-stream>
+<turbo-stream action="update" targets=".counter"><template><span>3</span></template></turbo-stream>
+<turbo-stream action="remove" target="flash"></turbo-stream>
+`
+	if got := rec.Body.String(); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+
+	rec = httptest.NewRecorder()
+	err = engine.RenderTurboStream(rec, []TurboAction{
+		{Action: "append", Target: "messages", Template: "messages.html", Block: "message"},
+		{Action: "append", Target: "messages", Template: "missing.html"},
+	})
+	if err == nil || rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "" {
+		t.Errorf("Expected error and nothing written, got %v %q", err, rec.Body.String())
+	}
+}
@@ -0,0 +1,101 @@
+package tmplx
+
+import (
+	"embed"
+	"net/url"
+	"strconv"
+)
+
+//go:embed ui
+var uiFS embed.FS
+
+// UIPartials is the Loader of the built-in partials, named under tmplx/.
+// Options.UIPartials adds them to every source; each reads its data from a
+// field of the page data:
+//
+//	{{include "tmplx/pagination.html" .}}   .Pagination  Pagination
+//	{{include "tmplx/breadcrumbs.html" .}}  .Breadcrumbs []Breadcrumb
+//	{{include "tmplx/flash.html" .}}        .Flash       []Flash
+//	{{include "tmplx/form-errors.html" .}}  .FormErrors  []FieldError
+//
+// A template of the same name in a source replaces the built-in one.
+var UIPartials Loader = FSLoader(uiFS, "ui")
+
+// Pagination describes the pages of a list for tmplx/pagination.html.
+// Pages are numbered from 1.
+type Pagination struct {
+	Page  int
+	Pages int
+
+	// Path is the URL of the list, defaulting to the current page. Its query
+	// is kept in page links.
+	Path string
+
+	// Param is the query parameter holding the page, defaulting to "page"
+	Param string
+
+	// Window is how many pages either side of the current one are linked,
+	// defaulting to 2. The first and last pages are always linked.
+	Window int
+}
+
+func (p Pagination) HasPrev() bool { return p.Page > 1 }
+func (p Pagination) HasNext() bool { return p.Page < p.Pages }
+
+func (p Pagination) PrevURL() string { return p.URL(p.Page - 1) }
+func (p Pagination) NextURL() string { return p.URL(p.Page + 1) }
+
+// URL returns the link to page n
+func (p Pagination) URL(n int) string {
+	param := p.Param
+	if param == "" {
+		param = "page"
+	}
+	u, err := url.Parse(p.Path)
+	if err != nil {
+		u = &url.URL{}
+	}
+	q := u.Query()
+	q.Set(param, strconv.Itoa(n))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// Numbers returns the page numbers to link, with 0 standing for a gap
+func (p Pagination) Numbers() []int {
+	window := p.Window
+	if window <= 0 {
+		window = 2
+	}
+
+	var nums []int
+	for n := 1; n <= p.Pages; n++ {
+		if n == 1 || n == p.Pages || (n >= p.Page-window && n <= p.Page+window) {
+			nums = append(nums, n)
+		} else if len(nums) > 0 && nums[len(nums)-1] != 0 {
+			nums = append(nums, 0)
+		}
+	}
+	return nums
+}
+
+// Breadcrumb is a link in tmplx/breadcrumbs.html. The current page is the
+// crumb without a URL.
+type Breadcrumb struct {
+	Title string
+	URL   string
+}
+
+// Flash is a message banner in tmplx/flash.html. Kind is "info" (the
+// default), "success", "warning" or "error".
+type Flash struct {
+	Kind    string
+	Message string
+}
+
+// FieldError is a form validation error in tmplx/form-errors.html. Errors
+// not tied to a field leave Field empty.
+type FieldError struct {
+	Field   string
+	Message string
+}
@@ -0,0 +1,72 @@
+package tmplx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUIPartials(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"list.html": `{{include "tmplx/breadcrumbs.html" .}}{{include "tmplx/flash.html" .}}` +
+				`{{include "tmplx/form-errors.html" .}}{{include "tmplx/pagination.html" .}}`,
+			"tmplx/flash.html": `{{range .Flash}}<p>{{.Message}}</p>{{end}}`,
+		},
+		UIPartials: true,
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Render("list.html", H{
+		"Breadcrumbs": []Breadcrumb{{Title: "Home", URL: "/"}, {Title: "Posts"}},
+		"Flash":       []Flash{{Kind: "success", Message: "Saved"}},
+		"FormErrors":  []FieldError{{Field: "email", Message: "is required"}},
+		"Pagination":  Pagination{Page: 5, Pages: 10, Path: "/posts?sort=new"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	containsAll(t, []string{
+		`<li><a href="/">Home</a></li>`,
+		`<li aria-current="page">Posts</li>`,
+		`<p>Saved</p>`,
+		`<li><strong>email</strong>: is required</li>`,
+		`<a href="/posts?page=4&amp;sort=new" rel="prev">Previous</a>`,
+		`<a href="/posts?page=5&amp;sort=new" aria-current="page">5</a>`,
+		`<li><span>&hellip;</span></li>`,
+		`<a href="/posts?page=6&amp;sort=new" rel="next">Next</a>`,
+	}, result)
+	if strings.Contains(result, "tmplx-flash") {
+		t.Errorf("Expected the source's tmplx/flash.html to replace the built-in one, got %s", result)
+	}
+
+	result, err = engine.Render("list.html", H{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(result) != "" {
+		t.Errorf("Expected no output without data, got %q", result)
+	}
+
+	plain := New(Options{Loader: MapLoader{"list.html": `{{include "tmplx/flash.html" .}}`}})
+	if err := plain.Load(); err == nil {
+		t.Error("Expected built-in partials to be opt-in")
+	}
+}
+
+func TestPaginationNumbers(t *testing.T) {
+	for _, tc := range []struct {
+		p        Pagination
+		expected []int
+	}{
+		{Pagination{Page: 1, Pages: 3}, []int{1, 2, 3}},
+		{Pagination{Page: 5, Pages: 10}, []int{1, 0, 3, 4, 5, 6, 7, 0, 10}},
+		{Pagination{Page: 10, Pages: 10, Window: 1}, []int{1, 0, 9, 10}},
+	} {
+		if got := tc.p.Numbers(); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%+v: expected %v, got %v", tc.p, tc.expected, got)
+		}
+	}
+}
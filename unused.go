@@ -0,0 +1,165 @@
+package tmplx
+
+import (
+	"sort"
+	"text/template/parse"
+)
+
+// BlockRef identifies a block definition in a template file
+type BlockRef struct {
+	File  string
+	Block string
+	Line  int
+}
+
+// UnusedReport lists template cruft found by Unused
+type UnusedReport struct {
+	// Templates are never extended or included by another template. Pages
+	// rendered directly by the application show up here too, so this is most
+	// useful filtered to layout and partial directories.
+	Templates []string
+
+	// NeverOverridden are blocks defined in a layout that no template
+	// extending it redefines, so their default content is always used.
+	NeverOverridden []BlockRef
+
+	// Undeclared are blocks defined in a child that none of its layouts
+	// render, so their content never appears.
+	Undeclared []BlockRef
+}
+
+// Unused analyzes every template file in every source for templates nothing
+// refers to and blocks that are never overridden or never rendered. Files
+// that fail to parse are skipped; Lint reports them.
+func (e *TemplateEngine) Unused() *UnusedReport {
+	e.mu.RLock()
+	files, _ := e.scanTemplateFiles()
+	e.mu.RUnlock()
+	report := &UnusedReport{}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	referenced := make(map[string]bool)
+	parents := make(map[string]string)
+	for _, name := range names {
+		extends, includes := fileDirectives(files[name])
+		if extends != "" {
+			referenced[extends] = true
+			parents[name] = extends
+		}
+		for _, inc := range includes {
+			referenced[inc] = true
+		}
+	}
+
+	for _, name := range names {
+		if !referenced[name] {
+			report.Templates = append(report.Templates, name)
+		}
+	}
+
+	for _, layout := range names {
+		var children []string
+		for _, name := range names {
+			if extendsFrom(parents, name, layout) {
+				children = append(children, name)
+			}
+		}
+		if len(children) == 0 {
+			continue
+		}
+
+		overridden := make(map[string]bool)
+		for _, child := range children {
+			for block := range fileBlocks(files, child, make(map[string]bool)) {
+				overridden[block] = true
+			}
+		}
+
+		f := files[layout]
+		for _, block := range sortedBlocks(f, layout) {
+			if !overridden[block] {
+				report.NeverOverridden = append(report.NeverOverridden, BlockRef{File: layout, Block: block, Line: lineOf(f.trees[block], f.trees[block].Root)})
+			}
+		}
+	}
+
+	for _, name := range names {
+		extends, ok := parents[name]
+		if !ok {
+			continue
+		}
+		f := files[name]
+		for _, block := range undeclaredBlocks(files, name, extends) {
+			report.Undeclared = append(report.Undeclared, BlockRef{File: name, Block: block, Line: lineOf(f.trees[block], f.trees[block].Root)})
+		}
+	}
+
+	return report
+}
+
+// fileDirectives returns the extend target and includes of a parsed file
+func fileDirectives(f *lintFile) (string, []string) {
+	var extends string
+	var includes []string
+	for _, node := range f.root.Root.Nodes {
+		action, ok := node.(*parse.ActionNode)
+		switch directive, target := directiveOf(action, ok); directive {
+		case "extend":
+			extends = target
+		case "include":
+			includes = append(includes, target)
+		}
+	}
+	return extends, includes
+}
+
+// extendsFrom reports whether name has layout somewhere up its extends chain
+func extendsFrom(parents map[string]string, name, layout string) bool {
+	seen := make(map[string]bool)
+	for parent := parents[name]; parent != "" && !seen[parent]; parent = parents[parent] {
+		if parent == layout {
+			return true
+		}
+		seen[parent] = true
+	}
+	return false
+}
+
+// fileBlocks collects the blocks a file defines itself or through its includes
+func fileBlocks(files map[string]*lintFile, name string, visited map[string]bool) map[string]bool {
+	blocks := make(map[string]bool)
+	f := files[name]
+	if f == nil || visited[name] {
+		return blocks
+	}
+	visited[name] = true
+
+	for block := range f.trees {
+		if block != name {
+			blocks[block] = true
+		}
+	}
+	_, includes := fileDirectives(f)
+	for _, inc := range includes {
+		for block := range fileBlocks(files, inc, visited) {
+			blocks[block] = true
+		}
+	}
+	return blocks
+}
+
+func sortedBlocks(f *lintFile, name string) []string {
+	var blocks []string
+	for block := range f.trees {
+		if block != name {
+			blocks = append(blocks, block)
+		}
+	}
+	sort.Strings(blocks)
+	return blocks
+}
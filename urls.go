@@ -0,0 +1,103 @@
+package tmplx
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// transliterations spell common accented Latin letters in ASCII for slugs
+var transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ą': "a", 'æ': "ae",
+	'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'ł': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss", 'ť': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// slugify turns text into a URL slug: lower case, common accented letters
+// spelled in ASCII and everything but letters and digits collapsed into
+// single hyphens, e.g. "Crème Brûlée: 10 Tips!" to "creme-brulee-10-tips"
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		word := transliterations[r]
+		if word == "" && (r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) {
+			word = string(r)
+		}
+		if word == "" {
+			hyphen = b.Len() > 0
+			continue
+		}
+		if hyphen {
+			b.WriteByte('-')
+			hyphen = false
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// urlQuery returns base with query parameters set from key and value
+// pairs, replacing any it already has. An empty or nil value removes the
+// parameter. Parameters are sorted, so equal queries give equal URLs:
+//
+//	{{urlQuery .Request.URL.String "page" 2 "sort" .Sort}}
+func urlQuery(base string, pairs ...interface{}) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("urlQuery: expected key and value pairs, got %d arguments", len(pairs))
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("urlQuery: %v", err)
+	}
+	query := u.Query()
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return "", fmt.Errorf("urlQuery: key %v is not a string", pairs[i])
+		}
+		if pairs[i+1] == nil || pairs[i+1] == "" {
+			query.Del(key)
+			continue
+		}
+		query.Set(key, fmt.Sprint(pairs[i+1]))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// pathJoin joins URL path elements with single slashes, keeping the
+// scheme and host of a leading absolute URL and a trailing slash:
+//
+//	{{pathJoin "/blog" .Year (slugify .Title)}}
+func pathJoin(elems ...interface{}) string {
+	if len(elems) == 0 {
+		return ""
+	}
+	parts := make([]string, len(elems))
+	for i, e := range elems {
+		parts[i] = fmt.Sprint(e)
+	}
+	joined := path.Join(parts...)
+	if strings.HasSuffix(parts[len(parts)-1], "/") && joined != "/" {
+		joined += "/"
+	}
+
+	if u, err := url.Parse(parts[0]); err == nil && u.Scheme != "" && u.Host != "" {
+		rest := append([]string{u.Path, "/"}, parts[1:]...)
+		u.Path = path.Join(rest...)
+		if strings.HasSuffix(joined, "/") {
+			u.Path += "/"
+		}
+		return u.String()
+	}
+	return joined
+}
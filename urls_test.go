@@ -0,0 +1,56 @@
+package tmplx
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	for in, expected := range map[string]string{
+		"Crème Brûlée: 10 Tips!": "creme-brulee-10-tips",
+		"  Hello,   World  ":     "hello-world",
+		"Straße & Œuvre":         "strasse-oeuvre",
+		"Go 1.23 — What's New?":  "go-1-23-what-s-new",
+		"日本語 タイトル":               "日本語-タイトル",
+		"---":                    "",
+	} {
+		if got := slugify(in); got != expected {
+			t.Errorf("slugify(%q): expected %q, got %q", in, expected, got)
+		}
+	}
+}
+
+func TestURLHelpers(t *testing.T) {
+	engine := New(Options{
+		Loader: MapLoader{
+			"links.html": `<a href="{{urlQuery .URL "page" 3 "q" ""}}">next</a>` +
+				`<a href="{{pathJoin "/blog" .Year (slugify .Title)}}">post</a>` +
+				`<link rel="canonical" href="{{pathJoin "https://example.com/" "docs/" }}">`,
+		},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("links.html", H{"URL": "/search?q=go&sort=new&page=2", "Year": 2024, "Title": "Hello, World"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<a href="/search?page=3&amp;sort=new">next</a><a href="/blog/2024/hello-world">post</a>` +
+		`<link rel="canonical" href="https://example.com/docs/">`
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	if _, err := urlQuery("/x", "page"); err == nil {
+		t.Error("Expected error for odd arguments, got nil")
+	}
+	for _, tc := range []struct {
+		elems    []interface{}
+		expected string
+	}{
+		{[]interface{}{"a", "b/"}, "a/b/"},
+		{[]interface{}{"/", "/"}, "/"},
+		{[]interface{}{"https://cdn.example.com/static", "../img", "x.png"}, "https://cdn.example.com/img/x.png"},
+	} {
+		if got := pathJoin(tc.elems...); got != tc.expected {
+			t.Errorf("pathJoin(%v): expected %q, got %q", tc.elems, tc.expected, got)
+		}
+	}
+}
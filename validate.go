@@ -0,0 +1,251 @@
+package tmplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"reflect"
+	"text/template/parse"
+)
+
+// Validate checks every field and method referenced by the resolved template,
+// including its blocks and layouts, against the type of sample, so renamed
+// struct fields are caught before the template is rendered. The type of dot
+// is followed through with, range and template actions; values whose type
+// can only be known at render time (interfaces, untyped func results) are not
+// checked further. All problems found are returned joined in one error.
+func (e *TemplateEngine) Validate(name string, sample interface{}) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	tmpl, err := e.getTemplate(name)
+	if err != nil {
+		return err
+	}
+	if sample == nil {
+		return fmt.Errorf("validate %s: sample must not be nil", name)
+	}
+
+	typ := reflect.TypeOf(sample)
+	v := &validator{
+		e:       e,
+		tmpl:    tmpl,
+		root:    typ,
+		checked: make(map[string]bool),
+		seen:    make(map[string]bool),
+	}
+	v.checkTemplate(tmpl.Name(), typ)
+
+	return errors.Join(v.problems...)
+}
+
+type validator struct {
+	e        *TemplateEngine
+	tmpl     *template.Template
+	root     reflect.Type
+	problems []error
+	checked  map[string]bool // template name and dot type pairs already checked
+	seen     map[string]bool // problems already reported
+}
+
+type validateScope struct {
+	tree *parse.Tree
+	dot  reflect.Type
+	vars map[string]reflect.Type
+}
+
+func (v *validator) report(sc *validateScope, node parse.Node, format string, args ...interface{}) {
+	location, _ := sc.tree.ErrorContext(node)
+	msg := location + ": " + fmt.Sprintf(format, args...)
+	if !v.seen[msg] {
+		v.seen[msg] = true
+		v.problems = append(v.problems, errors.New(msg))
+	}
+}
+
+func (v *validator) checkTemplate(name string, dot reflect.Type) {
+	key := fmt.Sprintf("%s\x00%v", name, dot)
+	if v.checked[key] {
+		return
+	}
+	v.checked[key] = true
+
+	t := v.tmpl.Lookup(name)
+	if t == nil || t.Tree == nil {
+		return
+	}
+	sc := &validateScope{tree: t.Tree, dot: dot, vars: map[string]reflect.Type{"$": v.root}}
+	v.walk(sc, t.Tree.Root)
+}
+
+func (v *validator) walk(sc *validateScope, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			v.walk(sc, c)
+		}
+	case *parse.ActionNode:
+		v.pipe(sc, n.Pipe)
+	case *parse.IfNode:
+		v.pipe(sc, n.Pipe)
+		v.walk(sc, n.List)
+		v.walk(sc, n.ElseList)
+	case *parse.WithNode:
+		typ := v.pipe(sc, n.Pipe)
+		inner := *sc
+		inner.dot = typ
+		v.walk(&inner, n.List)
+		v.walk(sc, n.ElseList)
+	case *parse.RangeNode:
+		typ := v.pipe(sc, n.Pipe)
+		key, elem := rangeTypes(typ)
+		switch len(n.Pipe.Decl) {
+		case 1:
+			sc.vars[n.Pipe.Decl[0].Ident[0]] = elem
+		case 2:
+			sc.vars[n.Pipe.Decl[0].Ident[0]] = key
+			sc.vars[n.Pipe.Decl[1].Ident[0]] = elem
+		}
+		inner := *sc
+		inner.dot = elem
+		v.walk(&inner, n.List)
+		v.walk(sc, n.ElseList)
+	case *parse.TemplateNode:
+		dot := reflect.Type(nil)
+		if n.Pipe != nil {
+			dot = v.pipe(sc, n.Pipe)
+		}
+		v.checkTemplate(n.Name, dot)
+	}
+}
+
+// pipe checks a pipeline and returns its static result type, or nil if unknown
+func (v *validator) pipe(sc *validateScope, pipe *parse.PipeNode) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+	var typ reflect.Type
+	for _, cmd := range pipe.Cmds {
+		typ = v.command(sc, cmd)
+	}
+	if !pipe.IsAssign {
+		for _, decl := range pipe.Decl {
+			sc.vars[decl.Ident[0]] = typ
+		}
+	}
+	return typ
+}
+
+func (v *validator) command(sc *validateScope, cmd *parse.CommandNode) reflect.Type {
+	for _, arg := range cmd.Args[1:] {
+		v.arg(sc, arg)
+	}
+
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		if fn, ok := v.e.funcMap[ident.Ident]; ok {
+			if ft := reflect.TypeOf(fn); ft.Kind() == reflect.Func && ft.NumOut() > 0 && ft.Out(0).Kind() != reflect.Interface {
+				return ft.Out(0)
+			}
+		}
+		return nil
+	}
+	return v.arg(sc, cmd.Args[0])
+}
+
+// arg checks a single operand and returns its static type, or nil if unknown
+func (v *validator) arg(sc *validateScope, node parse.Node) reflect.Type {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return sc.dot
+	case *parse.FieldNode:
+		return v.fields(sc, n, sc.dot, n.Ident)
+	case *parse.VariableNode:
+		typ, ok := sc.vars[n.Ident[0]]
+		if !ok {
+			return nil
+		}
+		return v.fields(sc, n, typ, n.Ident[1:])
+	case *parse.ChainNode:
+		return v.fields(sc, n, v.arg(sc, n.Node), n.Field)
+	case *parse.PipeNode:
+		return v.pipe(sc, n)
+	}
+	return nil
+}
+
+func (v *validator) fields(sc *validateScope, node parse.Node, typ reflect.Type, names []string) reflect.Type {
+	for _, name := range names {
+		next, err := fieldType(typ, name)
+		if err != nil {
+			v.report(sc, node, "%v", err)
+			return nil
+		}
+		typ = next
+	}
+	return typ
+}
+
+// fieldType resolves .name on a value of type typ the way text/template does
+func fieldType(typ reflect.Type, name string) (reflect.Type, error) {
+	if typ == nil {
+		return nil, nil
+	}
+
+	if m, ok := typ.MethodByName(name); ok {
+		return methodResult(m.Type), nil
+	}
+	if typ.Kind() != reflect.Pointer && typ.Kind() != reflect.Interface {
+		if m, ok := reflect.PointerTo(typ).MethodByName(name); ok {
+			return methodResult(m.Type), nil
+		}
+	}
+
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		if f, ok := typ.FieldByName(name); ok && f.IsExported() {
+			return f.Type, nil
+		}
+		return nil, fmt.Errorf("can't evaluate field %s in type %s", name, typ)
+	case reflect.Map:
+		if typ.Key().Kind() == reflect.String {
+			return typ.Elem(), nil
+		}
+		return nil, fmt.Errorf("can't index map of type %s with field %s", typ, name)
+	case reflect.Interface:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("can't evaluate field %s in type %s", name, typ)
+}
+
+func methodResult(mt reflect.Type) reflect.Type {
+	if mt.NumOut() == 0 || mt.Out(0).Kind() == reflect.Interface {
+		return nil
+	}
+	return mt.Out(0)
+}
+
+// rangeTypes returns the key and element types of ranging over typ
+func rangeTypes(typ reflect.Type) (reflect.Type, reflect.Type) {
+	if typ == nil {
+		return nil, nil
+	}
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return reflect.TypeOf(0), typ.Elem()
+	case reflect.Map:
+		return typ.Key(), typ.Elem()
+	case reflect.Chan:
+		return typ.Elem(), typ.Elem()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return typ, typ
+	}
+	return nil, nil
+}
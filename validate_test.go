@@ -0,0 +1,71 @@
+package tmplx
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type validateUser struct {
+	Name  string
+	Email string
+	Tags  []string
+	Posts []validatePost
+	Meta  map[string]string
+	Extra interface{}
+}
+
+type validatePost struct {
+	Title string
+}
+
+func (p validatePost) Slug() string { return strings.ToLower(p.Title) }
+
+func TestValidate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{
+			Data: []byte(`<h1>{{.Name}}</h1>{{block "content" .}}{{end}}`),
+		},
+		"pages/good.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/base.html"}}
+{{define "content"}}{{range .Posts}}<a href="/{{.Slug}}">{{.Title}}</a>{{end}}
+{{range $i, $tag := .Tags}}{{$i}}{{$tag}}{{end}}{{.Meta.anything}}{{.Extra.Whatever}}
+{{with .Posts}}{{len .}}{{end}}{{template "post" index .Posts 0}}{{end}}
+{{define "post"}}{{.Title}}{{end}}`),
+		},
+		"pages/bad.html": &fstest.MapFile{
+			Data: []byte(`{{extend "layouts/base.html"}}
+{{define "content"}}{{.Emial}}
+{{range .Posts}}{{.Titel}}{{$.Nmae}}{{end}}
+{{template "post" .Posts}}{{end}}
+{{define "post"}}{{range .}}{{.Body}}{{end}}{{end}}`),
+		},
+	}
+
+	engine := New(Options{FS: fsys})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := engine.Validate("pages/good.html", validateUser{}); err != nil {
+		t.Errorf("Expected no problems, got: %v", err)
+	}
+	if err := engine.Validate("pages/good.html", &validateUser{}); err != nil {
+		t.Errorf("Expected no problems with a pointer sample, got: %v", err)
+	}
+
+	err := engine.Validate("pages/bad.html", validateUser{})
+	if err == nil {
+		t.Fatal("Expected validation to fail")
+	}
+	containsAll(t, []string{
+		"pages/bad.html:2:",
+		"field Emial",
+		"field Titel in type tmplx.validatePost",
+		"field Nmae",
+		"field Body",
+	}, err.Error())
+	if strings.Contains(err.Error(), "field Name") {
+		t.Errorf("Expected the layout's fields to validate, got: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package tmplx
+
+import (
+	"fmt"
+	"sync"
+)
+
+// VersionedLoader is a Loader that keeps every published version of its
+// templates. An engine can render a specific version with WithVersion, or be
+// pinned to one with Pin to roll back a bad publish.
+type VersionedLoader interface {
+	Loader
+
+	// Versions lists the available versions, oldest first
+	Versions() ([]string, error)
+
+	// At returns a Loader serving the templates as of version
+	At(version string) (Loader, error)
+}
+
+// VersionedMapLoader is an in-memory VersionedLoader. Each version is a
+// complete set of templates; List and Read serve the latest one.
+type VersionedMapLoader struct {
+	mu       sync.RWMutex
+	versions []string
+	sets     map[string]MapLoader
+}
+
+// Publish adds a version holding templates, which becomes the latest.
+// Publishing an existing version replaces its templates.
+func (l *VersionedMapLoader) Publish(version string, templates map[string]string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sets == nil {
+		l.sets = make(map[string]MapLoader)
+	}
+	if _, ok := l.sets[version]; !ok {
+		l.versions = append(l.versions, version)
+	}
+	set := make(MapLoader, len(templates))
+	for name, content := range templates {
+		set[name] = content
+	}
+	l.sets[version] = set
+}
+
+func (l *VersionedMapLoader) latest() MapLoader {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if len(l.versions) == 0 {
+		return MapLoader{}
+	}
+	return l.sets[l.versions[len(l.versions)-1]]
+}
+
+func (l *VersionedMapLoader) List() ([]string, error) {
+	return l.latest().List()
+}
+
+func (l *VersionedMapLoader) Read(name string) ([]byte, error) {
+	return l.latest().Read(name)
+}
+
+func (l *VersionedMapLoader) Versions() ([]string, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]string(nil), l.versions...), nil
+}
+
+func (l *VersionedMapLoader) At(version string) (Loader, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	set, ok := l.sets[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown template version %s", version)
+	}
+	return set, nil
+}
+
+// WithVersion renders the template as of version from every source whose
+// Loader is a VersionedLoader. Each version is loaded once, on first use, and
+// kept alongside the engine's own templates until the engine is reloaded or
+// gets new funcs.
+func WithVersion(version string) RenderOption {
+	return func(cfg *renderConfig) {
+		cfg.version = version
+	}
+}
+
+// loaderFor returns the loader to read a source from, honoring the version
+// the engine is pinned to. Loads call it with e.mu held.
+func (e *TemplateEngine) loaderFor(s Source) (Loader, error) {
+	if e.version == "" {
+		return s.Loader, nil
+	}
+	if vl, ok := s.Loader.(VersionedLoader); ok {
+		return vl.At(e.version)
+	}
+	return s.Loader, nil
+}
+
+// Pin reloads the engine with every VersionedLoader source at version, so an
+// earlier version can be restored the moment a bad one is published. An
+// empty version unpins the engine, following the latest templates again. If
+// loading fails the engine stays on its current version.
+func (e *TemplateEngine) Pin(version string) error {
+	// loadMu is held from the switch to the restore, so a concurrent Pin
+	// can't load in between
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+
+	e.mu.Lock()
+	prev := e.version
+	e.version = version
+	e.mu.Unlock()
+	if err := e.reload(); err != nil {
+		e.mu.Lock()
+		e.version = prev
+		e.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Version returns the version the engine is pinned to, or "" if it follows
+// the latest templates.
+func (e *TemplateEngine) Version() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.version
+}
+
+// versioned returns the engine to render cfg with: e itself, or a copy of e
+// loaded at the requested version. The version is cleared from cfg.
+func (e *TemplateEngine) versioned(cfg *renderConfig) (*TemplateEngine, error) {
+	version := cfg.version
+	cfg.version = ""
+	if version == "" || version == e.Version() {
+		return e, nil
+	}
+
+	e.versionsMu.Lock()
+	defer e.versionsMu.Unlock()
+	if v, ok := e.versions[version]; ok {
+		return v, nil
+	}
+
+	v := e.derive()
+	v.version = version
+	if err := v.Load(); err != nil {
+		return nil, fmt.Errorf("error loading version %s: %w", version, err)
+	}
+	if e.versions == nil {
+		e.versions = make(map[string]*TemplateEngine)
+	}
+	e.versions[version] = v
+	return v, nil
+}
+
+// dropVersions forgets the engines loaded for WithVersion, so they are
+// loaded again with the engine's current funcs and settings. It must be
+// called without e.mu held, as versioned holds versionsMu while deriving.
+func (e *TemplateEngine) dropVersions() {
+	e.versionsMu.Lock()
+	defer e.versionsMu.Unlock()
+	e.versions = nil
+}
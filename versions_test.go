@@ -0,0 +1,153 @@
+package tmplx
+
+import (
+	"html/template"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestVersions(t *testing.T) {
+	loader := &VersionedMapLoader{}
+	loader.Publish("v1", map[string]string{
+		"layouts/base.html": `<body>{{block "content" .}}{{end}}</body>`,
+		"pages/home.html":   `{{extend "layouts/base.html"}}{{define "content"}}one{{end}}`,
+	})
+	loader.Publish("v2", map[string]string{
+		"layouts/base.html": `<main>{{block "content" .}}{{end}}</main>`,
+		"pages/home.html":   `{{extend "layouts/base.html"}}{{define "content"}}two{{end}}`,
+	})
+
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, _ := engine.Render("pages/home.html", nil); result != "<main>two</main>" {
+		t.Errorf("Expected latest version, got %q", result)
+	}
+	result, err := engine.Render("pages/home.html", nil, WithVersion("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "<body>one</body>" {
+		t.Errorf("Expected v1, got %q", result)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := engine.RenderHTTP(rec, httptest.NewRequest("GET", "/", nil), "pages/home.html", nil, WithVersion("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Body.String() != "<body>one</body>" {
+		t.Errorf("Expected v1 over HTTP, got %q", rec.Body.String())
+	}
+
+	if _, err := engine.Render("pages/home.html", nil, WithVersion("v9")); err == nil {
+		t.Error("Expected error rendering an unknown version")
+	}
+
+	// roll back
+	if err := engine.Pin("v1"); err != nil {
+		t.Fatal(err)
+	}
+	if engine.Version() != "v1" {
+		t.Errorf("Expected engine pinned to v1, got %q", engine.Version())
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "<body>one</body>" {
+		t.Errorf("Expected pinned version, got %q", result)
+	}
+
+	if err := engine.Pin("v9"); err == nil {
+		t.Error("Expected error pinning an unknown version")
+	}
+	if engine.Version() != "v1" {
+		t.Errorf("Expected failed pin to keep v1, got %q", engine.Version())
+	}
+
+	if err := engine.Pin(""); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "<main>two</main>" {
+		t.Errorf("Expected latest version after unpinning, got %q", result)
+	}
+}
+
+func TestConcurrentPins(t *testing.T) {
+	loader := &VersionedMapLoader{}
+	loader.Publish("v1", map[string]string{"pages/home.html": `one`})
+	loader.Publish("v2", map[string]string{"pages/home.html": `two`})
+
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := engine.Pin("v1"); err != nil {
+					t.Error(err)
+				}
+				if err := engine.Pin("v9"); err == nil {
+					t.Error("Expected error pinning an unknown version")
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := engine.Render("pages/home.html", nil, WithVersion("v2")); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// failed pins always restore the version they replaced
+	if engine.Version() != "v1" {
+		t.Errorf("Expected engine pinned to v1, got %q", engine.Version())
+	}
+	if result, _ := engine.Render("pages/home.html", nil); result != "one" {
+		t.Errorf("Expected pinned version, got %q", result)
+	}
+}
+
+func TestVersionsFollowEngine(t *testing.T) {
+	loader := &VersionedMapLoader{}
+	loader.Publish("v1", map[string]string{"pages/home.html": `one`})
+	loader.Publish("v2", map[string]string{"pages/home.html": `two`})
+
+	engine := New(Options{Loader: loader})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("pages/home.html", nil, WithVersion("v1")); result != "one" {
+		t.Errorf("Expected v1, got %q", result)
+	}
+
+	// republishing a version shows up after a reload
+	loader.Publish("v1", map[string]string{"pages/home.html": `uno {{shout "x"}}`})
+	if err := engine.AddFuncs(template.FuncMap{"shout": strings.ToUpper}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.Render("pages/home.html", nil, WithVersion("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "uno X" {
+		t.Errorf("Expected v1 loaded again with the new funcs, got %q", result)
+	}
+
+	loader.Publish("v1", map[string]string{"pages/home.html": `eins`})
+	if err := engine.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := engine.Render("pages/home.html", nil, WithVersion("v1")); result != "eins" {
+		t.Errorf("Expected v1 loaded again after a reload, got %q", result)
+	}
+}
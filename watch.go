@@ -0,0 +1,98 @@
+package tmplx
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"time"
+)
+
+// fileStamp is what a dirWatcher compares to tell a file changed
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// dirWatcher is the Watcher of a source read from a file system. It polls
+// the files below the source's directory, reporting a change whenever one
+// is added, removed or modified.
+type dirWatcher struct {
+	fsys     fs.FS
+	dir      string
+	interval time.Duration
+}
+
+// dirWatcherFor returns the watcher of a file system source, or nil for
+// sources that can't change, like an embed.FS
+func (e *TemplateEngine) dirWatcherFor(s Source) Watcher {
+	if s.Loader != nil {
+		return nil
+	}
+	if _, ok := s.FS.(embed.FS); ok {
+		return nil
+	}
+	interval := e.watchInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &dirWatcher{fsys: s.FS, dir: s.Dir, interval: interval}
+}
+
+func (w *dirWatcher) Watch(ctx context.Context, changed func()) error {
+	last, err := w.snapshot()
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// a directory can be missing for a moment while files are
+			// moved around; try again next tick
+			current, err := w.snapshot()
+			if err != nil {
+				continue
+			}
+			if !sameFiles(last, current) {
+				last = current
+				changed()
+			}
+		}
+	}
+}
+
+// snapshot stamps every file below the directory
+func (w *dirWatcher) snapshot() (map[string]fileStamp, error) {
+	files := make(map[string]fileStamp)
+	err := fs.WalkDir(w.fsys, w.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[p] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files, err
+}
+
+func sameFiles(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, stamp := range a {
+		if other, ok := b[name]; !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
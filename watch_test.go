@@ -0,0 +1,87 @@
+package tmplx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchDir(t *testing.T) {
+	t.Run("plain", func(t *testing.T) { testWatchDir(t, false) })
+	// the built-in partials are layered over the directory
+	t.Run("UIPartials", func(t *testing.T) { testWatchDir(t, true) })
+}
+
+func testWatchDir(t *testing.T, ui bool) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("layouts/base.html", `<main>{{block "content" .}}{{end}}</main>`)
+	write("pages/home.html", `{{extend "layouts/base.html"}}{{define "content"}}v1{{end}}`)
+
+	reloaded := make(chan struct{}, 10)
+	engine := New(Options{
+		Dir:           dir,
+		WatchInterval: 5 * time.Millisecond,
+		UIPartials:    ui,
+		AfterReload: []ReloadHook{func(context.Context) error {
+			reloaded <- struct{}{}
+			return nil
+		}},
+	})
+	if err := engine.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- engine.Watch(ctx) }()
+
+	waitReload := func(expected string) {
+		t.Helper()
+		select {
+		case <-reloaded:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("No reload after changing templates, waiting for %q", expected)
+		}
+		if result, _ := engine.Render("pages/home.html", nil); result != expected {
+			t.Errorf("Expected %q, got %q", expected, result)
+		}
+	}
+
+	// let the watcher take its first snapshot
+	time.Sleep(20 * time.Millisecond)
+
+	// a layout change re-resolves the pages extending it
+	write("layouts/base.html", `<article>{{block "content" .}}{{end}}</article>`)
+	waitReload("<article>v1</article>")
+
+	write("pages/home.html", `{{extend "layouts/base.html"}}{{define "content"}}version 2{{end}}`)
+	waitReload("<article>version 2</article>")
+
+	write("pages/about.html", `about`)
+	waitReload("<article>version 2</article>")
+	if result, _ := engine.Render("pages/about.html", nil); result != "about" {
+		t.Errorf("Expected the new template to load, got %q", result)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not return after cancel")
+	}
+}